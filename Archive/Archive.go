@@ -0,0 +1,164 @@
+package Archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config 原始数据归档配置
+type Config struct {
+	Enabled        bool          `yaml:"enabled"`
+	Dir            string        `yaml:"dir"`            // 归档目录
+	MaxAge         time.Duration `yaml:"maxAge"`         // 保留时长，如 168h
+	MaxTotalSizeMB int64         `yaml:"maxTotalSizeMB"` // 归档目录总大小上限（MB），超出时删除最旧的文件
+	QueueSize      int           `yaml:"queueSize"`      // 待写入队列长度，队列满时丢弃
+}
+
+// 默认值
+const (
+	defaultDir            = "data/archive"
+	defaultMaxAge         = 7 * 24 * time.Hour
+	defaultMaxTotalSizeMB = 10 * 1024
+	defaultQueueSize      = 10000
+	cleanupInterval       = 10 * time.Minute
+)
+
+func (c *Config) applyDefaults() {
+	if c.Dir == "" {
+		c.Dir = defaultDir
+	}
+	if c.MaxAge <= 0 {
+		c.MaxAge = defaultMaxAge
+	}
+	if c.MaxTotalSizeMB <= 0 {
+		c.MaxTotalSizeMB = defaultMaxTotalSizeMB
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaultQueueSize
+	}
+}
+
+// Record 一条归档记录
+type Record struct {
+	ReceivedAt time.Time       `json:"received_at"`
+	ClientIP   string          `json:"client_ip"`
+	Project    string          `json:"project"`
+	Source     string          `json:"source"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// 全局归档队列，未启用时为 nil
+var (
+	queue   chan Record
+	queueMu sync.RWMutex
+)
+
+// Start 启动归档写入和定期清理
+func Start(cfg Config) error {
+	cfg.applyDefaults()
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return fmt.Errorf("创建归档目录失败: %v", err)
+	}
+
+	q := make(chan Record, cfg.QueueSize)
+	go func() {
+		for record := range q {
+			if err := writeRecord(cfg.Dir, record); err != nil {
+				log.Printf("[Archive] 写入归档失败: %v", err)
+			}
+		}
+	}()
+	go func() {
+		for {
+			cleanup(cfg.Dir, cfg.MaxAge, cfg.MaxTotalSizeMB*1024*1024)
+			time.Sleep(cleanupInterval)
+		}
+	}()
+
+	queueMu.Lock()
+	queue = q
+	queueMu.Unlock()
+
+	archiveDirMu.Lock()
+	archiveDir = cfg.Dir
+	archiveDirMu.Unlock()
+
+	log.Printf("[Archive] 已启动，目录: %s，保留: %v", cfg.Dir, cfg.MaxAge)
+	return nil
+}
+
+// Write 提交一条解密后的原始数据，未启用归档时直接返回
+func Write(project, source, clientIP string, receivedAt time.Time, payload []byte) {
+	queueMu.RLock()
+	q := queue
+	queueMu.RUnlock()
+	if q == nil {
+		return
+	}
+
+	record := Record{
+		ReceivedAt: receivedAt,
+		ClientIP:   clientIP,
+		Project:    project,
+		Source:     source,
+		Payload:    json.RawMessage(payload),
+	}
+	select {
+	case q <- record:
+	default:
+		log.Printf("[Archive] 归档队列已满，丢弃 project=%s source=%s 的数据", project, source)
+	}
+}
+
+// safeName 防止 project/source 中的 .. 或路径分隔符逃逸出归档目录
+func safeName(name string) string {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "_"
+	}
+	return name
+}
+
+// recordPath 归档文件路径：<dir>/<project>/<source>/<YYYY-MM-DD>/<HH>.jsonl.gz
+func recordPath(dir, project, source string, t time.Time) string {
+	return filepath.Join(dir, safeName(project), safeName(source), t.Format("2006-01-02"), t.Format("15")+".jsonl.gz")
+}
+
+// writeRecord 以独立 gzip member 追加写入一行 JSON
+// 多个 gzip member 拼接后仍是合法的 gzip 流，进程异常退出时不会损坏之前的数据
+func writeRecord(dir string, record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	path := recordPath(dir, record.Project, record.Source, record.ReceivedAt)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
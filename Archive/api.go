@@ -0,0 +1,91 @@
+package Archive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 归档目录，Start 时设置
+var (
+	archiveDir   string
+	archiveDirMu sync.RWMutex
+)
+
+// Dir 返回归档目录，未启用归档时为空
+func Dir() string {
+	archiveDirMu.RLock()
+	defer archiveDirMu.RUnlock()
+	return archiveDir
+}
+
+// 单次查询最多返回的记录数
+const maxQueryLimit = 10000
+
+var errLimitReached = errors.New("limit reached")
+
+// ParseTime 解析命令行和查询参数中的时间，支持 RFC3339 和本地时间格式
+func ParseTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间: %s", s)
+}
+
+// QueryHandler 查询归档记录（/api/archive），按 JSONL 返回
+// 参数：project、source、from、to（默认最近 1 小时）、limit（默认 1000）
+func QueryHandler(w http.ResponseWriter, r *http.Request) {
+	dir := Dir()
+	if dir == "" {
+		http.Error(w, "归档未启用", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	to := time.Now()
+	from := to.Add(-time.Hour)
+	var err error
+	if v := query.Get("from"); v != "" {
+		if from, err = ParseTime(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if to, err = ParseTime(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 1000
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			http.Error(w, "limit 参数无效", http.StatusBadRequest)
+			return
+		}
+	}
+	if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	count := 0
+	err = Read(dir, from, to, query.Get("project"), query.Get("source"), func(record Record) error {
+		if count >= limit {
+			return errLimitReached
+		}
+		count++
+		return encoder.Encode(record)
+	})
+	if err != nil && !errors.Is(err, errLimitReached) {
+		log.Printf("[Archive] 查询归档失败: %v", err)
+	}
+}
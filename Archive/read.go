@@ -0,0 +1,163 @@
+package Archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archiveFile 一个归档文件及其所属小时
+type archiveFile struct {
+	path string
+	hour time.Time
+	size int64
+}
+
+// listFiles 列出归档目录下所有文件，按小时从旧到新排序
+func listFiles(dir string) []archiveFile {
+	var files []archiveFile
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".jsonl.gz") {
+			return nil
+		}
+		hourStr := strings.TrimSuffix(d.Name(), ".jsonl.gz")
+		dateStr := filepath.Base(filepath.Dir(path))
+		hour, err := time.ParseInLocation("2006-01-02 15", dateStr+" "+hourStr, time.Local)
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, archiveFile{path: path, hour: hour, size: info.Size()})
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("[Archive] 遍历归档目录失败: %v", err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].hour.Equal(files[j].hour) {
+			return files[i].path < files[j].path
+		}
+		return files[i].hour.Before(files[j].hour)
+	})
+	return files
+}
+
+// Read 按时间窗口读取归档记录，project/source 为空时不过滤
+// 记录按文件（小时）顺序返回，fn 返回错误时停止读取
+func Read(dir string, from, to time.Time, project, source string, fn func(Record) error) error {
+	root := dir
+	if project != "" {
+		root = filepath.Join(dir, safeName(project))
+		if source != "" {
+			root = filepath.Join(root, safeName(source))
+		}
+	}
+
+	for _, f := range listFiles(root) {
+		if f.hour.Add(time.Hour).Before(from) || f.hour.After(to) {
+			continue
+		}
+		err := readFile(f.path, func(record Record) error {
+			if record.ReceivedAt.Before(from) || record.ReceivedAt.After(to) {
+				return nil
+			}
+			if source != "" && record.Source != source {
+				return nil
+			}
+			return fn(record)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFile 逐行读取一个归档文件，文件尾部不完整（进程异常退出）时忽略尾部
+func readFile(path string, fn func(Record) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		if err := file.Close(); err != nil {
+			log.Printf("关闭归档文件失败: %v", err)
+		}
+	}(file)
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		log.Printf("[Archive] 归档文件 %s 格式错误: %v", path, err)
+		return nil
+	}
+	defer func() {
+		if err := gz.Close(); err != nil {
+			log.Printf("关闭 gzip reader 失败: %v", err)
+		}
+	}()
+
+	reader := bufio.NewReader(gz)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			var record Record
+			if jsonErr := json.Unmarshal(line, &record); jsonErr != nil {
+				log.Printf("[Archive] 跳过无法解析的记录: %v", jsonErr)
+			} else if fnErr := fn(record); fnErr != nil {
+				return fnErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			log.Printf("[Archive] 读取归档文件 %s 中断: %v", path, err)
+			return nil
+		}
+	}
+}
+
+// cleanup 删除超过保留时长的文件，总大小仍超限时从最旧的文件开始删除
+func cleanup(dir string, maxAge time.Duration, maxTotalSize int64) {
+	files := listFiles(dir)
+	cutoff := time.Now().Add(-maxAge)
+
+	var total int64
+	var kept []archiveFile
+	for _, f := range files {
+		if f.hour.Add(time.Hour).Before(cutoff) {
+			removeFile(f.path)
+			continue
+		}
+		total += f.size
+		kept = append(kept, f)
+	}
+	for len(kept) > 0 && total > maxTotalSize {
+		removeFile(kept[0].path)
+		total -= kept[0].size
+		kept = kept[1:]
+	}
+}
+
+func removeFile(path string) {
+	if err := os.Remove(path); err != nil {
+		log.Printf("[Archive] 删除归档文件失败: %v", err)
+		return
+	}
+	// 日期目录为空时一并删除
+	_ = os.Remove(filepath.Dir(path))
+}
@@ -0,0 +1,228 @@
+package Daily
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config 每日统计配置
+type Config struct {
+	Dir              string            `yaml:"dir"`              // 每日历史目录
+	Timezone         string            `yaml:"timezone"`         // 默认日界时区，如 Asia/Shanghai，为空时使用服务器时区
+	ProjectTimezones map[string]string `yaml:"projectTimezones"` // 项目（编码或名称）-> 时区
+}
+
+const defaultDir = "data/daily"
+
+// Record 某个服务一天的最终统计
+type Record struct {
+	Date       string             `json:"date"` // YYYY-MM-DD（项目时区）
+	Project    string             `json:"project"`
+	Service    string             `json:"service"`
+	Values     map[string]float64 `json:"values"` // requests、success、errors 等 today_* 字段的最终值
+	RecordedAt time.Time          `json:"recorded_at"`
+}
+
+var (
+	dir       string // 未启动时为空，Save 不写入
+	defaultTZ = time.Local
+	projectTZ = map[string]*time.Location{}
+	mu        sync.RWMutex
+	writeMu   sync.Mutex
+)
+
+// Start 设置历史目录和时区
+func Start(cfg Config) error {
+	if cfg.Dir == "" {
+		cfg.Dir = defaultDir
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return fmt.Errorf("创建每日历史目录失败: %v", err)
+	}
+	if err := SetTimezones(cfg.Timezone, cfg.ProjectTimezones); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	dir = cfg.Dir
+	mu.Unlock()
+	log.Printf("[Daily] 每日历史目录: %s", cfg.Dir)
+	return nil
+}
+
+// Timezones 已加载、等待应用的时区配置
+type Timezones struct {
+	def      *time.Location
+	projects map[string]*time.Location
+}
+
+// LoadTimezones 加载默认时区和项目时区，不修改当前配置
+func LoadTimezones(timezone string, projectTimezones map[string]string) (*Timezones, error) {
+	tz := &Timezones{def: time.Local, projects: make(map[string]*time.Location, len(projectTimezones))}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("时区 %s 不合法: %v", timezone, err)
+		}
+		tz.def = loc
+	}
+	for project, name := range projectTimezones {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("项目 %s 的时区 %s 不合法: %v", project, name, err)
+		}
+		tz.projects[strings.ToLower(project)] = loc // viper 重新加载时 key 会转为小写
+	}
+	return tz, nil
+}
+
+// Apply 替换当前时区配置
+func (tz *Timezones) Apply() {
+	mu.Lock()
+	defaultTZ = tz.def
+	projectTZ = tz.projects
+	mu.Unlock()
+}
+
+// SetTimezones 设置默认时区和项目时区，时区不合法时返回错误并保留原配置
+func SetTimezones(timezone string, projectTimezones map[string]string) error {
+	tz, err := LoadTimezones(timezone, projectTimezones)
+	if err != nil {
+		return err
+	}
+	tz.Apply()
+	return nil
+}
+
+// Location 返回项目的日界时区，依次按传入的项目编码、名称查找
+func Location(projects ...string) *time.Location {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, project := range projects {
+		if loc, ok := projectTZ[strings.ToLower(project)]; ok {
+			return loc
+		}
+	}
+	return defaultTZ
+}
+
+// safeName 防止 project/service 中的 .. 或路径分隔符逃逸出目录
+func safeName(name string) string {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "_"
+	}
+	return name
+}
+
+func getDir() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return dir
+}
+
+// Save 追加一条每日记录，同一天多次写入时查询以最后一次为准
+func Save(record Record) error {
+	d := getDir()
+	if d == "" {
+		return nil
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	path := filepath.Join(d, safeName(record.Project), safeName(record.Service)+".jsonl")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Query 查询每日记录，project/service 为空时不过滤，from/to 为 YYYY-MM-DD（包含），为空时不限制
+// 结果按项目、服务、日期排序
+func Query(project, service, from, to string) ([]Record, error) {
+	d := getDir()
+	if d == "" {
+		return nil, nil
+	}
+
+	pattern := filepath.Join(d, "*", "*.jsonl")
+	if project != "" && service != "" {
+		pattern = filepath.Join(d, safeName(project), safeName(service)+".jsonl")
+	} else if project != "" {
+		pattern = filepath.Join(d, safeName(project), "*.jsonl")
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, path := range paths {
+		byDate, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for date, record := range byDate {
+			if service != "" && record.Service != service {
+				continue
+			}
+			if (from != "" && date < from) || (to != "" && date > to) {
+				continue
+			}
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Project != records[j].Project {
+			return records[i].Project < records[j].Project
+		}
+		if records[i].Service != records[j].Service {
+			return records[i].Service < records[j].Service
+		}
+		return records[i].Date < records[j].Date
+	})
+	return records, nil
+}
+
+// readFile 读取一个服务的全部记录，同一天取最后一条
+func readFile(path string) (map[string]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	byDate := map[string]Record{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Printf("[Daily] 跳过损坏的记录 %s: %v", path, err)
+			continue
+		}
+		byDate[record.Date] = record
+	}
+	return byDate, scanner.Err()
+}
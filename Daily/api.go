@@ -0,0 +1,27 @@
+package Daily
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// QueryHandler 查询每日历史（/api/daily）
+// 参数：project、service、from、to（YYYY-MM-DD，包含）
+func QueryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	records, err := Query(query.Get("project"), query.Get("service"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		log.Printf("[Daily] 查询每日历史失败: %v", err)
+		http.Error(w, "查询失败", http.StatusInternalServerError)
+		return
+	}
+	if records == nil {
+		records = []Record{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(records); err != nil {
+		log.Printf("[Daily] 响应失败: %v", err)
+	}
+}
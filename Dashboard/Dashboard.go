@@ -0,0 +1,73 @@
+package Dashboard
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//go:embed static
+var static embed.FS
+
+// Prefix 仪表盘路径，页面为 /dashboard/，接口为 /dashboard/api/*
+const Prefix = "/dashboard/"
+
+// Source 仪表盘数据来源
+type Source struct {
+	Gatherer     prometheus.Gatherer                    // 当前指标（Metrics.Gatherer，带 project_code/project_name 标签）
+	ProjectNames func() map[string]string               // 项目编码 -> 显示名称（projects.json）
+	LastSeen     func() map[string]map[string]time.Time // 项目编码 -> hostName -> 最后上报时间
+}
+
+// Handler 返回只读仪表盘（静态页面和 JSON 接口）
+func Handler(src Source) http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		log.Fatalf("加载仪表盘页面失败: %v", err)
+	}
+	fileServer := http.StripPrefix(Prefix, http.FileServer(http.FS(files)))
+
+	apis := map[string]func(*snapshot, string, *http.Request) interface{}{
+		"projects":     (*snapshot).projects,
+		"hosts":        (*snapshot).hosts,
+		"certificates": (*snapshot).certificates,
+		"containers":   (*snapshot).containers,
+		"traffic":      (*snapshot).traffic,
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "只支持 GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name, ok := strings.CutPrefix(r.URL.Path, Prefix+"api/")
+		if !ok {
+			fileServer.ServeHTTP(w, r)
+			return
+		}
+		api, ok := apis[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		s, err := newSnapshot(src)
+		if err != nil {
+			log.Printf("[Dashboard] 采集指标失败: %v", err)
+			http.Error(w, "采集指标失败", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(api(s, r.URL.Query().Get("project"), r)); err != nil {
+			log.Printf("[Dashboard] 响应失败: %v", err)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package Dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newTestSource 指标带 Metrics.Gatherer 展开后的 project_code 和 project_name 标签
+func newTestSource(t *testing.T) Source {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	gauge := func(name string, labels ...string) *prometheus.GaugeVec {
+		g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name}, labels)
+		registry.MustRegister(g)
+		return g
+	}
+
+	active := gauge("is_active", "hostName", "project_code", "project_name")
+	active.WithLabelValues("node-1", "shop", "商城").Set(1)
+	active.WithLabelValues("node-2", "shop", "商城").Set(0)
+	active.WithLabelValues("node-9", "unknown", "unknown").Set(1)
+
+	ssl := gauge("ssl_domain_days_left", "domain", "comment", "status", "resolve", "project_code", "project_name")
+	ssl.WithLabelValues("shop.example.com", "", "ok", "1.1.1.1", "shop", "商城").Set(12)
+	ssl.WithLabelValues("www.example.com", "", "ok", "1.1.1.2", "shop", "商城").Set(90)
+
+	containerLabels := []string{"namespace", "namespace_raw", "controllerName", "podName", "container", "project_code", "project_name"}
+	cpu := gauge("container_cpu_usage", containerLabels...)
+	memory := gauge("container_memory_usage", containerLabels...)
+	limit := gauge("container_cpu_limit_utilization", containerLabels...)
+	for i, pod := range []string{"api-0", "api-1", "web-0"} {
+		labels := []string{"default", "default", strings.Split(pod, "-")[0], pod, "app", "shop", "商城"}
+		cpu.WithLabelValues(labels...).Set([]float64{0.2, 0.8, 0.5}[i])
+		memory.WithLabelValues(labels...).Set([]float64{300, 100, 200}[i])
+	}
+	limit.WithLabelValues("default", "default", "api", "api-1", "app", "shop", "商城").Set(0.4)
+
+	return Source{
+		Gatherer:     registry,
+		ProjectNames: func() map[string]string { return map[string]string{"shop": "商城", "idle": ""} },
+		LastSeen: func() map[string]map[string]time.Time {
+			return map[string]map[string]time.Time{"shop": {"node-1": time.Unix(1700000000, 0)}}
+		},
+	}
+}
+
+func get(t *testing.T, h http.Handler, target string, v interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	if v != nil && w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+	}
+	return w
+}
+
+func TestProjectsAPI(t *testing.T) {
+	h := Handler(newTestSource(t))
+
+	var projects []Project
+	get(t, h, "/dashboard/api/projects", &projects)
+	want := []Project{
+		{Code: "idle", Name: "idle", ExpiringWithin: expiringDays},
+		{Code: "shop", Name: "商城", Hosts: 2, ActiveHosts: 1, Containers: 3, ExpiringCerts: 1, ExpiringWithin: expiringDays},
+		{Code: "unknown", Name: "unknown", Hosts: 1, ActiveHosts: 1, ExpiringWithin: expiringDays},
+	}
+	if len(projects) != len(want) {
+		t.Fatalf("projects = %+v, want %+v", projects, want)
+	}
+	for i := range want {
+		if projects[i] != want[i] {
+			t.Errorf("projects[%d] = %+v, want %+v", i, projects[i], want[i])
+		}
+	}
+
+	// 按编码和显示名称查询结果相同
+	for _, project := range []string{"shop", "商城"} {
+		var hosts []Host
+		get(t, h, "/dashboard/api/hosts?project="+project, &hosts)
+		if len(hosts) != 2 || hosts[0].Host != "node-2" || hosts[0].IsActive || hosts[1].LastSeen == nil {
+			t.Errorf("hosts?project=%s = %+v, want node-2 offline first and node-1 with last_seen", project, hosts)
+		}
+	}
+}
+
+func TestContainersAPI(t *testing.T) {
+	h := Handler(newTestSource(t))
+	tests := []struct {
+		query string
+		pods  []string
+	}{
+		{"", []string{"api-1", "web-0", "api-0"}},
+		{"?sort=memory", []string{"api-0", "web-0", "api-1"}},
+		{"?limit=1", []string{"api-1"}},
+		{"?limit=abc", []string{"api-1", "web-0", "api-0"}},
+		{"?project=unknown", nil},
+	}
+	for _, tt := range tests {
+		var containers []Container
+		get(t, h, "/dashboard/api/containers"+tt.query, &containers)
+		var pods []string
+		for _, c := range containers {
+			pods = append(pods, c.Pod)
+			wantLimit := -1.0
+			if c.Pod == "api-1" {
+				wantLimit = 0.4
+			}
+			if c.CpuLimitUtilization != wantLimit {
+				t.Errorf("%s: %s cpu_limit_utilization = %v, want %v", tt.query, c.Pod, c.CpuLimitUtilization, wantLimit)
+			}
+		}
+		if strings.Join(pods, ",") != strings.Join(tt.pods, ",") {
+			t.Errorf("containers%s = %v, want %v", tt.query, pods, tt.pods)
+		}
+	}
+}
+
+func TestHandlerRouting(t *testing.T) {
+	h := Handler(newTestSource(t))
+
+	if w := get(t, h, "/dashboard/", nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<html") {
+		t.Errorf("index = %d %q", w.Code, w.Body.String())
+	}
+	if w := get(t, h, "/dashboard/api/unknown", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown api status = %d, want 404", w.Code)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/dashboard/api/projects", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", w.Code)
+	}
+}
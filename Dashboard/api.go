@@ -0,0 +1,392 @@
+package Dashboard
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// 证书剩余天数不超过该值时计入项目的即将到期数量
+const expiringDays = 30
+
+// 容器排行默认和最大数量
+const (
+	defaultContainerLimit = 20
+	maxContainerLimit     = 200
+)
+
+// snapshot 一次请求内的指标快照
+type snapshot struct {
+	families map[string][]*dto.Metric
+	names    map[string]string // 项目编码 -> 显示名称
+	lastSeen map[string]map[string]time.Time
+}
+
+func newSnapshot(src Source) (*snapshot, error) {
+	families, err := src.Gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+	s := &snapshot{
+		families: make(map[string][]*dto.Metric, len(families)),
+		names:    map[string]string{},
+		lastSeen: map[string]map[string]time.Time{},
+	}
+	for _, mf := range families {
+		s.families[mf.GetName()] = mf.GetMetric()
+	}
+	if src.ProjectNames != nil {
+		for code, name := range src.ProjectNames() {
+			if name == "" {
+				name = code
+			}
+			s.names[code] = name
+		}
+	}
+	if src.LastSeen != nil {
+		s.lastSeen = src.LastSeen()
+	}
+	return s, nil
+}
+
+func labelValue(m *dto.Metric, name string) string {
+	for _, label := range m.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+func metricValue(m *dto.Metric) float64 {
+	if m.Counter != nil {
+		return m.GetCounter().GetValue()
+	}
+	return m.GetGauge().GetValue()
+}
+
+// series 返回指标的全部序列，project 不为空时只返回该项目的（项目编码或显示名称）
+func (s *snapshot) series(name, project string) []*dto.Metric {
+	if project == "" {
+		return s.families[name]
+	}
+	var result []*dto.Metric
+	for _, m := range s.families[name] {
+		if labelValue(m, "project_code") == project || labelValue(m, "project_name") == project {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// Project 项目概览
+type Project struct {
+	Code           string `json:"code"`
+	Name           string `json:"name"`
+	Hosts          int    `json:"hosts"`
+	ActiveHosts    int    `json:"active_hosts"`
+	Containers     int    `json:"containers"`
+	Services       int    `json:"services"`
+	ExpiringCerts  int    `json:"expiring_certificates"`
+	ExpiringWithin int    `json:"expiring_within_days"`
+}
+
+func (s *snapshot) projects(project string, _ *http.Request) interface{} {
+	byCode := map[string]*Project{}
+	get := func(code, name string) *Project {
+		p, ok := byCode[code]
+		if !ok {
+			p = &Project{Code: code, Name: name, ExpiringWithin: expiringDays}
+			byCode[code] = p
+		}
+		return p
+	}
+	fromLabels := func(m *dto.Metric) *Project {
+		return get(labelValue(m, "project_code"), labelValue(m, "project_name"))
+	}
+	for code, name := range s.names {
+		if project == "" || project == code || project == name {
+			get(code, name)
+		}
+	}
+
+	for _, m := range s.series("is_active", project) {
+		p := fromLabels(m)
+		p.Hosts++
+		if metricValue(m) >= 1 {
+			p.ActiveHosts++
+		}
+	}
+	for _, m := range s.series("container_cpu_usage", project) {
+		fromLabels(m).Containers++
+	}
+	for _, m := range s.series("trafficswitching_realtime_qps", project) {
+		fromLabels(m).Services++
+	}
+	for _, c := range s.certificateList(project) {
+		if c.DaysLeft <= expiringDays {
+			get(c.ProjectCode, c.Project).ExpiringCerts++
+		}
+	}
+
+	result := make([]Project, 0, len(byCode))
+	for _, p := range byCode {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
+	return result
+}
+
+// Host 主机状态
+type Host struct {
+	Project           string     `json:"project"`
+	ProjectCode       string     `json:"project_code"`
+	Host              string     `json:"host"`
+	IsActive          bool       `json:"is_active"`
+	LastSeen          *time.Time `json:"last_seen,omitempty"`
+	AgentVersion      float64    `json:"agent_version"`
+	CpuPercent        float64    `json:"cpu_percent"`
+	MemoryUsedPercent float64    `json:"memory_used_percent"`
+	DiskUsedPercent   float64    `json:"disk_used_percent"`
+}
+
+func (s *snapshot) hosts(project string, _ *http.Request) interface{} {
+	byKey := map[string]*Host{}
+	get := func(m *dto.Metric) *Host {
+		code, host := labelValue(m, "project_code"), labelValue(m, "hostName")
+		key := code + "\xff" + host
+		h, ok := byKey[key]
+		if !ok {
+			h = &Host{Project: labelValue(m, "project_name"), ProjectCode: code, Host: host}
+			if seen, ok := s.lastSeen[code][host]; ok {
+				h.LastSeen = &seen
+			}
+			byKey[key] = h
+		}
+		return h
+	}
+
+	for _, m := range s.series("is_active", project) {
+		get(m).IsActive = metricValue(m) >= 1
+	}
+	for _, m := range s.series("agent_version", project) {
+		get(m).AgentVersion = metricValue(m)
+	}
+	for _, m := range s.series("cpu_percent", project) {
+		get(m).CpuPercent = metricValue(m)
+	}
+	for _, m := range s.series("memory_used_percent", project) {
+		get(m).MemoryUsedPercent = metricValue(m)
+	}
+	for _, m := range s.series("disk_used_percent", project) {
+		get(m).DiskUsedPercent = metricValue(m)
+	}
+
+	result := make([]Host, 0, len(byKey))
+	for _, h := range byKey {
+		result = append(result, *h)
+	}
+	// 离线的排在前面
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].IsActive != result[j].IsActive {
+			return !result[i].IsActive
+		}
+		if result[i].Project != result[j].Project {
+			return result[i].Project < result[j].Project
+		}
+		return result[i].Host < result[j].Host
+	})
+	return result
+}
+
+// Certificate 证书到期信息
+type Certificate struct {
+	Project     string  `json:"project"`
+	ProjectCode string  `json:"project_code"`
+	Domain      string  `json:"domain"`
+	Comment     string  `json:"comment"`
+	Status      string  `json:"status"`
+	Resolve     string  `json:"resolve"`
+	DaysLeft    float64 `json:"days_left"`
+}
+
+func (s *snapshot) certificateList(project string) []Certificate {
+	series := s.series("ssl_domain_days_left", project)
+	result := make([]Certificate, 0, len(series))
+	for _, m := range series {
+		result = append(result, Certificate{
+			Project:     labelValue(m, "project_name"),
+			ProjectCode: labelValue(m, "project_code"),
+			Domain:      labelValue(m, "domain"),
+			Comment:     labelValue(m, "comment"),
+			Status:      labelValue(m, "status"),
+			Resolve:     labelValue(m, "resolve"),
+			DaysLeft:    metricValue(m),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].DaysLeft != result[j].DaysLeft {
+			return result[i].DaysLeft < result[j].DaysLeft
+		}
+		return result[i].Domain < result[j].Domain
+	})
+	return result
+}
+
+func (s *snapshot) certificates(project string, _ *http.Request) interface{} {
+	return s.certificateList(project)
+}
+
+// Container 容器资源使用
+type Container struct {
+	Project                string  `json:"project"`
+	ProjectCode            string  `json:"project_code"`
+	Namespace              string  `json:"namespace"`
+	Controller             string  `json:"controller"`
+	Pod                    string  `json:"pod"`
+	Container              string  `json:"container"`
+	CpuUsage               float64 `json:"cpu_usage"`
+	MemoryUsage            float64 `json:"memory_usage"`
+	CpuLimitUtilization    float64 `json:"cpu_limit_utilization"`    // 0-1，未设置 limit 时为 -1
+	MemoryLimitUtilization float64 `json:"memory_limit_utilization"` // 0-1，未设置 limit 时为 -1
+	Restarts               float64 `json:"restarts"`
+}
+
+// containers 容器使用排行，参数 sort=cpu（默认）|memory，limit 默认 20
+func (s *snapshot) containers(project string, r *http.Request) interface{} {
+	byKey := map[string]*Container{}
+	get := func(m *dto.Metric) *Container {
+		key := labelValue(m, "project_code") + "\xff" + labelValue(m, "namespace_raw") + "\xff" + labelValue(m, "podName") + "\xff" + labelValue(m, "container")
+		c, ok := byKey[key]
+		if !ok {
+			c = &Container{
+				Project:     labelValue(m, "project_name"),
+				ProjectCode: labelValue(m, "project_code"),
+				Namespace:   labelValue(m, "namespace_raw"),
+				Controller:  labelValue(m, "controllerName"),
+				Pod:         labelValue(m, "podName"),
+				Container:   labelValue(m, "container"),
+
+				CpuLimitUtilization:    -1,
+				MemoryLimitUtilization: -1,
+			}
+			byKey[key] = c
+		}
+		return c
+	}
+
+	for _, m := range s.series("container_cpu_usage", project) {
+		get(m).CpuUsage = metricValue(m)
+	}
+	for _, m := range s.series("container_memory_usage", project) {
+		get(m).MemoryUsage = metricValue(m)
+	}
+	for _, m := range s.series("container_cpu_limit_utilization", project) {
+		get(m).CpuLimitUtilization = metricValue(m)
+	}
+	for _, m := range s.series("container_memory_limit_utilization", project) {
+		get(m).MemoryLimitUtilization = metricValue(m)
+	}
+	for _, m := range s.series("container_restarts_total", project) {
+		get(m).Restarts = metricValue(m)
+	}
+
+	result := make([]Container, 0, len(byKey))
+	for _, c := range byKey {
+		result = append(result, *c)
+	}
+	byMemory := r.URL.Query().Get("sort") == "memory"
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].CpuUsage, result[j].CpuUsage
+		if byMemory {
+			a, b = result[i].MemoryUsage, result[j].MemoryUsage
+		}
+		if a != b {
+			return a > b
+		}
+		return result[i].Pod < result[j].Pod
+	})
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultContainerLimit
+	}
+	if limit > maxContainerLimit {
+		limit = maxContainerLimit
+	}
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// Traffic 流量切换服务状态
+type Traffic struct {
+	Project          string  `json:"project"`
+	ProjectCode      string  `json:"project_code"`
+	Service          string  `json:"service"`
+	Qps              float64 `json:"qps"`
+	SuccessQps       float64 `json:"success_qps"`
+	ErrorQps         float64 `json:"error_qps"`
+	SuccessRate      float64 `json:"success_rate"` // 实时成功率（0-1），无请求时为 -1
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`
+	TodayRequests    float64 `json:"today_requests"`
+	TodaySuccessRate float64 `json:"today_success_rate"` // 今日成功率（0-1），无请求时为 -1
+	todaySuccess     float64
+}
+
+func (s *snapshot) traffic(project string, _ *http.Request) interface{} {
+	byKey := map[string]*Traffic{}
+	get := func(m *dto.Metric) *Traffic {
+		key := labelValue(m, "project_code") + "\xff" + labelValue(m, "service")
+		t, ok := byKey[key]
+		if !ok {
+			t = &Traffic{Project: labelValue(m, "project_name"), ProjectCode: labelValue(m, "project_code"), Service: labelValue(m, "service")}
+			byKey[key] = t
+		}
+		return t
+	}
+
+	for _, m := range s.series("trafficswitching_realtime_qps", project) {
+		get(m).Qps = metricValue(m)
+	}
+	for _, m := range s.series("trafficswitching_realtime_success_qps", project) {
+		get(m).SuccessQps = metricValue(m)
+	}
+	for _, m := range s.series("trafficswitching_realtime_error_qps", project) {
+		get(m).ErrorQps = metricValue(m)
+	}
+	for _, m := range s.series("trafficswitching_realtime_avg_latency_ms", project) {
+		get(m).AvgLatencyMs = metricValue(m)
+	}
+	for _, m := range s.series("trafficswitching_today_requests", project) {
+		get(m).TodayRequests = metricValue(m)
+	}
+	for _, m := range s.series("trafficswitching_today_success", project) {
+		get(m).todaySuccess = metricValue(m)
+	}
+
+	result := make([]Traffic, 0, len(byKey))
+	for _, t := range byKey {
+		t.SuccessRate = ratio(t.SuccessQps, t.Qps)
+		t.TodaySuccessRate = ratio(t.todaySuccess, t.TodayRequests)
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Qps != result[j].Qps {
+			return result[i].Qps > result[j].Qps
+		}
+		return result[i].Service < result[j].Service
+	})
+	return result
+}
+
+func ratio(part, total float64) float64 {
+	if total <= 0 {
+		return -1
+	}
+	return part / total
+}
@@ -0,0 +1,117 @@
+// monitor-server 只读仪表盘，每 10 秒刷新一次
+(function () {
+  "use strict";
+
+  var REFRESH_MS = 10000;
+  var projectSelect = document.getElementById("project");
+  var sortSelect = document.getElementById("container-sort");
+
+  function esc(v) {
+    return String(v == null ? "" : v).replace(/[&<>"']/g, function (c) {
+      return { "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;", "'": "&#39;" }[c];
+    });
+  }
+
+  function num(v, digits) {
+    return typeof v === "number" ? v.toFixed(digits == null ? 2 : digits) : "";
+  }
+
+  function percent(v) {
+    return v < 0 ? "-" : (v * 100).toFixed(2) + "%";
+  }
+
+  function ago(iso) {
+    if (!iso) return "-";
+    var s = Math.max(0, Math.round((Date.now() - new Date(iso).getTime()) / 1000));
+    if (s < 60) return s + " 秒前";
+    if (s < 3600) return Math.floor(s / 60) + " 分钟前";
+    if (s < 86400) return Math.floor(s / 3600) + " 小时前";
+    return Math.floor(s / 86400) + " 天前";
+  }
+
+  function td(v, cls) {
+    return "<td" + (cls ? ' class="' + cls + '"' : "") + ">" + esc(v) + "</td>";
+  }
+
+  function fill(id, rows, render) {
+    var body = document.querySelector("#" + id + " tbody");
+    if (!rows.length) {
+      var cols = document.querySelectorAll("#" + id + " thead th").length;
+      body.innerHTML = '<tr><td colspan="' + cols + '" class="muted">无数据</td></tr>';
+      return;
+    }
+    body.innerHTML = rows.map(function (r) { return "<tr>" + render(r) + "</tr>"; }).join("");
+  }
+
+  function api(name, params) {
+    var query = new URLSearchParams(params || {});
+    if (projectSelect.value) query.set("project", projectSelect.value);
+    return fetch("api/" + name + "?" + query.toString(), { credentials: "same-origin" }).then(function (resp) {
+      if (!resp.ok) throw new Error(name + ": HTTP " + resp.status);
+      return resp.json();
+    });
+  }
+
+  function loadProjects() {
+    // 项目下拉框始终列出全部项目
+    return fetch("api/projects", { credentials: "same-origin" }).then(function (resp) { return resp.json(); }).then(function (projects) {
+      var current = projectSelect.value;
+      projectSelect.innerHTML = '<option value="">全部</option>' + projects.map(function (p) {
+        return '<option value="' + esc(p.code) + '">' + esc(p.name) + "（" + esc(p.code) + "）</option>";
+      }).join("");
+      projectSelect.value = current;
+      var shown = current ? projects.filter(function (p) { return p.code === current; }) : projects;
+      fill("projects", shown, function (p) {
+        return td(p.code) + td(p.name) +
+          td(p.active_hosts + " / " + p.hosts, p.active_hosts < p.hosts ? "num bad" : "num") +
+          td(p.containers, "num") + td(p.services, "num") +
+          td(p.expiring_certificates, p.expiring_certificates > 0 ? "num warn" : "num");
+      });
+    });
+  }
+
+  function refresh() {
+    Promise.all([
+      loadProjects(),
+      api("hosts").then(function (hosts) {
+        fill("hosts", hosts, function (h) {
+          return td(h.project) + td(h.host) +
+            td(h.is_active ? "在线" : "离线", h.is_active ? "good" : "bad") +
+            td(ago(h.last_seen)) + td(h.agent_version, "num") +
+            td(num(h.cpu_percent), "num") + td(num(h.memory_used_percent), "num") + td(num(h.disk_used_percent), "num");
+        });
+      }),
+      api("certificates").then(function (certs) {
+        fill("certificates", certs, function (c) {
+          var cls = c.days_left <= 7 ? "num bad" : c.days_left <= 30 ? "num warn" : "num";
+          return td(c.project) + td(c.domain) + td(c.comment) + td(c.status) + td(c.resolve) + td(num(c.days_left, 0), cls);
+        });
+      }),
+      api("containers", { sort: sortSelect.value }).then(function (containers) {
+        fill("containers", containers, function (c) {
+          return td(c.project) + td(c.namespace) + td(c.controller) + td(c.pod) + td(c.container) +
+            td(num(c.cpu_usage, 3), "num") + td(num(c.memory_usage, 0), "num") +
+            td(percent(c.cpu_limit_utilization), "num") + td(percent(c.memory_limit_utilization), "num") +
+            td(num(c.restarts, 0), "num");
+        });
+      }),
+      api("traffic").then(function (services) {
+        fill("traffic", services, function (t) {
+          return td(t.project) + td(t.service) + td(num(t.qps), "num") +
+            td(num(t.error_qps), t.error_qps > 0 ? "num warn" : "num") +
+            td(percent(t.success_rate), "num") + td(num(t.avg_latency_ms), "num") +
+            td(num(t.today_requests, 0), "num") + td(percent(t.today_success_rate), "num");
+        });
+      })
+    ]).then(function () {
+      document.getElementById("updated").textContent = "更新于 " + new Date().toLocaleTimeString();
+    }).catch(function (err) {
+      document.getElementById("updated").textContent = "刷新失败: " + err.message;
+    });
+  }
+
+  projectSelect.addEventListener("change", refresh);
+  sortSelect.addEventListener("change", refresh);
+  refresh();
+  setInterval(refresh, REFRESH_MS);
+})();
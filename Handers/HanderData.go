@@ -1,24 +1,16 @@
 package Handers
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
 	"log"
 	"monitor-server/Metrics"
 	"monitor-server/Modles"
-	"os"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
 )
 
-var projectNameDict map[string]string
-var projectNameDictMu sync.RWMutex
-
 // LabelSeparator 标签分隔符，使用不常见的字符串避免与字段值冲突
 const LabelSeparator = "|:|"
 
@@ -32,119 +24,200 @@ func SplitLabels(label string) []string {
 	return strings.Split(label, LabelSeparator)
 }
 
-// 处理 namespace，去掉 -v1 或 -v2
-func cleanNamespace(namespace string) string {
-	if strings.HasSuffix(namespace, "-v1") {
-		return namespace[:len(namespace)-3] // 去掉 -v1
-	} else if strings.HasSuffix(namespace, "-v2") {
-		return namespace[:len(namespace)-3] // 去掉 -v2
-	}
-	return namespace
-}
-
-// 读取配置文件的函数（线程安全）
-func LoadProjectDict(configPath string) error {
-	// 打开配置文件
-	file, err := os.Open(configPath)
-	if err != nil {
-		return fmt.Errorf("无法打开配置文件: %v", err)
-	}
-	defer func(file *os.File) {
-		if err := file.Close(); err != nil {
-			log.Printf("关闭配置文件失败: %v", err)
+// 处理 nginx 类型的数据
+func HandleNginxData(data []interface{}, project string, receivedAt time.Time) []Modles.Sample {
+	var samples []Modles.Sample
+	for _, item := range data {
+		var nginxData Modles.NginxSource
+		if err := mapstructure.Decode(item, &nginxData); err != nil {
+			log.Printf("解析 Nginx 数据失败: %v", err)
+			continue
 		}
-	}(file)
 
-	// 读取文件内容
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return fmt.Errorf("无法读取配置文件内容: %v", err)
-	}
+		metricLabel := JoinLabels(nginxData.HostName, project)
+		ts := sampleTime(nginxData.Timestamp, receivedAt)
+		if !acceptSample("nginx", project, metricLabel, nginxData.Timestamp, receivedAt) {
+			continue
+		}
+		samples = appendSample(samples, item, ts)
 
-	// 解析 JSON 数据到临时变量
-	var tempDict map[string]string
-	if err = json.Unmarshal(content, &tempDict); err != nil {
-		return fmt.Errorf("解析配置文件失败: %v", err)
-	}
+		// 更新 Nginx 指标并打印日志
+		Metrics.NginxIsRunMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.IsRun))
+		Metrics.NginxLoginUserCountMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.LoginUserCount))
+		Metrics.NginxRawTotalMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.RawTotal))
+		Metrics.NginxUdptotalMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.Udptotal))
+		Metrics.NginxTcpTotalMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.TcpTotal))
+		Metrics.NginxTotalTcpMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.TotalTcp))
+		Metrics.NginxInetTotalMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.InetTotal))
+		Metrics.NginxFragTotalMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.FragTotal))
+		Metrics.NginxTcpEstabMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.TcpEstab))
+		Metrics.NginxTcpClosedMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.TcpClosed))
+		Metrics.NginxTcpOrphanedMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.TcpOrphaned))
+		Metrics.NginxTcpTimewaitMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.TcpTimewait))
+		if legacyCounterGaugesEnabled() {
+			Metrics.NginxReTotalMetric.WithLabelValues(nginxData.HostName, project).Set(float64(nginxData.ReTotal))
+		}
+		nginxReTotalDeriver.observe(float64(nginxData.ReTotal), ts, nginxData.HostName, project)
 
-	// 加锁后赋值
-	projectNameDictMu.Lock()
-	projectNameDict = tempDict
-	projectNameDictMu.Unlock()
+		// stub_status、upstream、server 块
+		handleNginxStatus(nginxData, project, ts)
 
-	return nil
+		UpdateNginxMetricWithTimestamp(metricLabel, ts)
+	}
+	return samples
 }
 
-// 获取项目名称（线程安全）
-func getProjectName(project string) string {
-	projectNameDictMu.RLock()
-	defer projectNameDictMu.RUnlock()
-	if name, ok := projectNameDict[project]; ok && name != "" {
-		return name
+// 处理 Nginx 自身的状态数据，upstream 后端和 server 块各自独立过期
+func handleNginxStatus(nginxData Modles.NginxSource, project string, ts time.Time) {
+	hostName := nginxData.HostName
+
+	if stub := nginxData.StubStatus; stub != nil {
+		Metrics.NginxConnectionsActiveMetric.WithLabelValues(hostName, project).Set(stub.Active)
+		Metrics.NginxConnectionsReadingMetric.WithLabelValues(hostName, project).Set(stub.Reading)
+		Metrics.NginxConnectionsWritingMetric.WithLabelValues(hostName, project).Set(stub.Writing)
+		Metrics.NginxConnectionsWaitingMetric.WithLabelValues(hostName, project).Set(stub.Waiting)
+		nginxAcceptsDeriver.observe(stub.Accepts, ts, hostName, project)
+		nginxHandledDeriver.observe(stub.Handled, ts, hostName, project)
+		nginxRequestsDeriver.observe(stub.Requests, ts, hostName, project)
 	}
-	return project
-}
 
-// 处理 nginx 类型的数据
-func HandleNginxData(data []interface{}, project string) {
-	projectName := getProjectName(project)
-	for _, item := range data {
-		var nginxData Modles.NginxSource
-		if err := mapstructure.Decode(item, &nginxData); err != nil {
-			log.Printf("解析 Nginx 数据失败: %v", err)
+	for _, peer := range nginxData.UpstreamPeers {
+		if peer.Upstream == "" || peer.Server == "" {
 			continue
 		}
+		up := 0.0
+		if strings.EqualFold(peer.State, "up") {
+			up = 1
+		}
+		Metrics.NginxUpstreamPeerUpMetric.WithLabelValues(hostName, project, peer.Upstream, peer.Server).Set(up)
+		Metrics.NginxUpstreamPeerFailsMetric.WithLabelValues(hostName, project, peer.Upstream, peer.Server).Set(peer.Fails)
+		Metrics.NginxUpstreamPeerResponseTimeMsMetric.WithLabelValues(hostName, project, peer.Upstream, peer.Server).Set(peer.ResponseTimeMs)
+		NginxUpstreamTimestamp.Store(JoinLabels(hostName, project, peer.Upstream, peer.Server), ts)
+	}
 
-		// 更新 Nginx 指标并打印日志
-		Metrics.NginxIsRunMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.IsRun))
-		Metrics.NginxReTotalMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.ReTotal))
-		Metrics.NginxLoginUserCountMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.LoginUserCount))
-		Metrics.NginxRawTotalMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.RawTotal))
-		Metrics.NginxUdptotalMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.Udptotal))
-		Metrics.NginxTcpTotalMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.TcpTotal))
-		Metrics.NginxTotalTcpMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.TotalTcp))
-		Metrics.NginxInetTotalMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.InetTotal))
-		Metrics.NginxFragTotalMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.FragTotal))
-		Metrics.NginxTcpEstabMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.TcpEstab))
-		Metrics.NginxTcpClosedMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.TcpClosed))
-		Metrics.NginxTcpOrphanedMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.TcpOrphaned))
-		Metrics.NginxTcpTimewaitMetric.WithLabelValues(nginxData.HostName, projectName).Set(float64(nginxData.TcpTimewait))
-		UpdateNginxMetricWithTimestamp(JoinLabels(nginxData.HostName, projectName))
+	for _, server := range nginxData.Servers {
+		if server.ServerName == "" {
+			continue
+		}
+		nginxResponsesDeriver.observe(server.Status1xx, ts, hostName, project, server.ServerName, "1xx")
+		nginxResponsesDeriver.observe(server.Status2xx, ts, hostName, project, server.ServerName, "2xx")
+		nginxResponsesDeriver.observe(server.Status3xx, ts, hostName, project, server.ServerName, "3xx")
+		nginxResponsesDeriver.observe(server.Status4xx, ts, hostName, project, server.ServerName, "4xx")
+		nginxResponsesDeriver.observe(server.Status5xx, ts, hostName, project, server.ServerName, "5xx")
+		NginxServerTimestamp.Store(JoinLabels(hostName, project, server.ServerName), ts)
+	}
+}
+
+// hardValues 硬件指标与本次上报值的对应关系
+func hardValues(hardData Modles.HardSource) map[*Metrics.GaugeVec]float64 {
+	return map[*Metrics.GaugeVec]float64{
+		Metrics.CpuPercentMetric:        hardData.CPUPercent,
+		Metrics.DiskTotalMetric:         hardData.DiskTotal,
+		Metrics.DiskUsedMetric:          hardData.DiskUsed,
+		Metrics.DiskFreeMetric:          hardData.DiskFree,
+		Metrics.DiskUsedPercentMetric:   hardData.DiskUsedPercent,
+		Metrics.MemoryTotalMetric:       hardData.MemoryTotal,
+		Metrics.MemoryUsedMetric:        hardData.MemoryUsed,
+		Metrics.MemoryFreeMetric:        hardData.MemoryFree,
+		Metrics.MemoryUsedPercentMetric: hardData.MemoryUsedPercent,
+		Metrics.CpuLoad1Metric:          hardData.CPULoad1,
+		Metrics.CpuLoad5Metric:          hardData.CPULoad5,
+		Metrics.CpuLoad15Metric:         hardData.CPULoad15,
+		Metrics.CpuTotalMetric:          hardData.CPUCount,
 	}
 }
 
 // 处理硬件相关的数据
-func HandleHardData(data []interface{}, project string) {
-	projectName := getProjectName(project)
+func HandleHardData(data []interface{}, project string, receivedAt time.Time) []Modles.Sample {
+	var samples []Modles.Sample
+	legacy := hardLegacyLabelsEnabled()
 	for _, item := range data {
 		var hardData Modles.HardSource
 		if err := mapstructure.Decode(item, &hardData); err != nil {
 			log.Printf("解析硬件数据失败: %v", err)
 			continue
 		}
-		// 更新硬件相关指标
-		Metrics.CpuPercentMetric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.CPUPercent)
-		Metrics.DiskTotalMetric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.DiskTotal)
-		Metrics.DiskUsedMetric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.DiskUsed)
-		Metrics.DiskFreeMetric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.DiskFree)
-		Metrics.DiskUsedPercentMetric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.DiskUsedPercent)
-		Metrics.MemoryTotalMetric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.MemoryTotal)
-		Metrics.MemoryUsedMetric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.MemoryUsed)
-		Metrics.MemoryFreeMetric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.MemoryFree)
-		Metrics.MemoryUsedPercentMetric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.MemoryUsedPercent)
-		Metrics.CpuLoad1Metric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.CPULoad1)
-		Metrics.CpuLoad5Metric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.CPULoad5)
-		Metrics.CpuLoad15Metric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.CPULoad15)
-		Metrics.CpuTotalMetric.WithLabelValues(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(hardData.CPUCount)
-
-		UpdateHardMetricWithTimestamp(JoinLabels(hardData.HostName, projectName, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion))
 
+		metricLabel := JoinLabels(hardData.HostName, project)
+		ts := sampleTime(hardData.Timestamp, receivedAt)
+		if !acceptSample("hard", project, metricLabel, hardData.Timestamp, receivedAt) {
+			continue
+		}
+		samples = appendSample(samples, item, ts)
+
+		// 主机属性变化时先删除旧属性对应的 host_info 和旧版指标
+		hostInfo := []string{hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion}
+		updateHostInfo(metricLabel, hostInfo)
+
+		// 更新硬件相关指标（只按主机和项目区分）
+		for metric, value := range hardValues(hardData) {
+			metric.WithLabelValues(hardData.HostName, project).Set(value)
+			if legacy {
+				Metrics.HardLegacyMetrics[metric].WithLabelValues(hardData.HostName, project, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(value)
+			}
+		}
+		Metrics.HostInfoMetric.WithLabelValues(hardData.HostName, project, hardData.CPUModel, hardData.OSVersion, hardData.KernelVersion).Set(1)
+
+		// 挂载点、块设备、网卡各自独立过期
+		handleHardDevices(hardData, project, ts)
+
+		UpdateHardMetricWithTimestamp(metricLabel, ts)
+	}
+	return samples
+}
+
+// 处理主机的挂载点、块设备和网卡数据
+func handleHardDevices(hardData Modles.HardSource, project string, ts time.Time) {
+	hostName := hardData.HostName
+
+	for _, mp := range hardData.Mountpoints {
+		if mp.Mountpoint == "" {
+			continue
+		}
+		Metrics.MountpointTotalMetric.WithLabelValues(hostName, project, mp.Mountpoint, mp.Device, mp.FsType).Set(mp.Total)
+		Metrics.MountpointUsedMetric.WithLabelValues(hostName, project, mp.Mountpoint, mp.Device, mp.FsType).Set(mp.Used)
+		Metrics.MountpointFreeMetric.WithLabelValues(hostName, project, mp.Mountpoint, mp.Device, mp.FsType).Set(mp.Free)
+		Metrics.MountpointUsedPercentMetric.WithLabelValues(hostName, project, mp.Mountpoint, mp.Device, mp.FsType).Set(mp.UsedPercent)
+		Metrics.MountpointInodesTotalMetric.WithLabelValues(hostName, project, mp.Mountpoint, mp.Device, mp.FsType).Set(mp.InodesTotal)
+		Metrics.MountpointInodesUsedMetric.WithLabelValues(hostName, project, mp.Mountpoint, mp.Device, mp.FsType).Set(mp.InodesUsed)
+		Metrics.MountpointInodesFreeMetric.WithLabelValues(hostName, project, mp.Mountpoint, mp.Device, mp.FsType).Set(mp.InodesFree)
+		Metrics.MountpointInodesUsedPercentMetric.WithLabelValues(hostName, project, mp.Mountpoint, mp.Device, mp.FsType).Set(mp.InodesUsedPercent)
+		MountpointTimestamp.Store(JoinLabels(hostName, project, mp.Mountpoint, mp.Device, mp.FsType), ts)
+	}
+
+	for _, disk := range hardData.Disks {
+		if disk.Device == "" {
+			continue
+		}
+		Metrics.DiskReadIopsMetric.WithLabelValues(hostName, project, disk.Device).Set(disk.ReadIops)
+		Metrics.DiskWriteIopsMetric.WithLabelValues(hostName, project, disk.Device).Set(disk.WriteIops)
+		Metrics.DiskReadBytesPerSecMetric.WithLabelValues(hostName, project, disk.Device).Set(disk.ReadBytesPerSec)
+		Metrics.DiskWriteBytesPerSecMetric.WithLabelValues(hostName, project, disk.Device).Set(disk.WriteBytesPerSec)
+		Metrics.DiskReadAwaitMsMetric.WithLabelValues(hostName, project, disk.Device).Set(disk.ReadAwaitMs)
+		Metrics.DiskWriteAwaitMsMetric.WithLabelValues(hostName, project, disk.Device).Set(disk.WriteAwaitMs)
+		Metrics.DiskIoUtilPercentMetric.WithLabelValues(hostName, project, disk.Device).Set(disk.IoUtilPercent)
+		DiskIOTimestamp.Store(JoinLabels(hostName, project, disk.Device), ts)
+	}
+
+	for _, iface := range hardData.Interfaces {
+		if iface.Interface == "" {
+			continue
+		}
+		Metrics.NetworkRxBytesMetric.WithLabelValues(hostName, project, iface.Interface).Set(iface.RxBytes)
+		Metrics.NetworkTxBytesMetric.WithLabelValues(hostName, project, iface.Interface).Set(iface.TxBytes)
+		Metrics.NetworkRxPacketsMetric.WithLabelValues(hostName, project, iface.Interface).Set(iface.RxPackets)
+		Metrics.NetworkTxPacketsMetric.WithLabelValues(hostName, project, iface.Interface).Set(iface.TxPackets)
+		Metrics.NetworkRxErrorsMetric.WithLabelValues(hostName, project, iface.Interface).Set(iface.RxErrors)
+		Metrics.NetworkTxErrorsMetric.WithLabelValues(hostName, project, iface.Interface).Set(iface.TxErrors)
+		Metrics.NetworkRxDroppedMetric.WithLabelValues(hostName, project, iface.Interface).Set(iface.RxDropped)
+		Metrics.NetworkTxDroppedMetric.WithLabelValues(hostName, project, iface.Interface).Set(iface.TxDropped)
+		InterfaceTimestamp.Store(JoinLabels(hostName, project, iface.Interface), ts)
 	}
 }
 
 // 处理SSl证书数据
-func HandleSSLData(data []interface{}, project string) {
-	projectName := getProjectName(project)
+func HandleSSLData(data []interface{}, project string, receivedAt time.Time) []Modles.Sample {
+	var samples []Modles.Sample
 	for _, item := range data {
 		var sslData Modles.SslSource
 		if err := mapstructure.Decode(item, &sslData); err != nil {
@@ -163,18 +236,24 @@ func HandleSSLData(data []interface{}, project string) {
 		}
 
 		// 更新 SSL 指标并打印日志，添加 project 标签
-		metricLabel := JoinLabels(sslData.Domain, sslData.Comment, sslData.Status, resolve, projectName)
-		Metrics.SslDaysLeftMetric.WithLabelValues(sslData.Domain, sslData.Comment, sslData.Status, resolve, projectName).Set(float64(sslData.DaysLeft))
+		metricLabel := JoinLabels(sslData.Domain, sslData.Comment, sslData.Status, resolve, project)
+		ts := sampleTime(sslData.Timestamp, receivedAt)
+		if !acceptSample("ssl", project, metricLabel, sslData.Timestamp, receivedAt) {
+			continue
+		}
+		samples = appendSample(samples, item, ts)
+		Metrics.SslDaysLeftMetric.WithLabelValues(sslData.Domain, sslData.Comment, sslData.Status, resolve, project).Set(float64(sslData.DaysLeft))
 
 		// 存储时间戳
-		sslTimestamp.Store(metricLabel, time.Now())
+		sslTimestamp.Store(metricLabel, ts)
 
 	}
+	return samples
 }
 
 // 处理容器资源数据
-func HandleContainerResourceData(data []interface{}, project string) {
-	projectName := getProjectName(project)
+func HandleContainerResourceData(data []interface{}, project string, receivedAt time.Time) []Modles.Sample {
+	var samples []Modles.Sample
 
 	for _, item := range data {
 		var containerResource Modles.ContainerResource
@@ -182,20 +261,61 @@ func HandleContainerResourceData(data []interface{}, project string) {
 			log.Printf("解析容器资源数据失败: %v", err)
 			continue
 		}
-		containerNamespace := cleanNamespace(containerResource.Namespace)
+		containerNamespace, slot := normalizeNamespace(project, containerResource.Namespace)
 
-		Metrics.ContainerCpuUsageMetric.WithLabelValues(containerNamespace, containerResource.PodName, containerResource.Container, containerResource.ControllerName, projectName).Set(containerResource.UseCpu)
-		Metrics.ContainerMemoryUsageMetric.WithLabelValues(containerNamespace, containerResource.PodName, containerResource.Container, containerResource.ControllerName, projectName).Set(float64(containerResource.UseMemory))
-		Metrics.ContainerCpuLimitMetric.WithLabelValues(containerNamespace, containerResource.PodName, containerResource.Container, containerResource.ControllerName, projectName).Set(containerResource.LimitCpu)
-		Metrics.ContainerMemoryLimitMetric.WithLabelValues(containerNamespace, containerResource.PodName, containerResource.Container, containerResource.ControllerName, projectName).Set(float64(containerResource.LimitMemory))
-		Metrics.ContainerRestartCountMetric.WithLabelValues(containerNamespace, containerResource.PodName, containerResource.Container, containerResource.ControllerName, projectName).Set(float64(containerResource.RestartCount))
-		Metrics.ContainerLastTerminationTimeMetric.WithLabelValues(containerNamespace, containerResource.PodName, containerResource.Container, containerResource.ControllerName, projectName).Set(float64(containerResource.LastTerminationTime))
+		metricLabel := JoinLabels(containerNamespace, containerResource.Namespace, slot, containerResource.PodName, containerResource.Container, containerResource.ControllerName, project)
+		ts := sampleTime(containerResource.Timestamp, receivedAt)
+		if !acceptSample("k8s", project, metricLabel, containerResource.Timestamp, receivedAt) {
+			continue
+		}
+		samples = appendSample(samples, item, ts)
+
+		labels := []string{containerNamespace, containerResource.Namespace, slot, containerResource.PodName, containerResource.Container, containerResource.ControllerName, project}
+
+		Metrics.ContainerCpuUsageMetric.WithLabelValues(labels...).Set(containerResource.UseCpu)
+		Metrics.ContainerMemoryUsageMetric.WithLabelValues(labels...).Set(float64(containerResource.UseMemory))
+		Metrics.ContainerCpuLimitMetric.WithLabelValues(labels...).Set(containerResource.LimitCpu)
+		Metrics.ContainerMemoryLimitMetric.WithLabelValues(labels...).Set(float64(containerResource.LimitMemory))
+		Metrics.ContainerCpuRequestMetric.WithLabelValues(labels...).Set(containerResource.RequestCpu)
+		Metrics.ContainerMemoryRequestMetric.WithLabelValues(labels...).Set(float64(containerResource.RequestMemory))
+		Metrics.ContainerCpuThrottledRatioMetric.WithLabelValues(labels...).Set(containerResource.CpuThrottledRatio)
+		if legacyCounterGaugesEnabled() {
+			Metrics.ContainerRestartCountMetric.WithLabelValues(labels...).Set(float64(containerResource.RestartCount))
+		}
+		Metrics.ContainerLastTerminationTimeMetric.WithLabelValues(labels...).Set(float64(containerResource.LastTerminationTime))
+		containerRestartsDeriver.observe(float64(containerResource.RestartCount), ts, labels...)
+		containerThrottleDeriver.observe(containerResource.CpuThrottledSeconds, ts, labels...)
+		containerOomKillsDeriver.observe(float64(containerResource.OomKillCount), ts, labels...)
+
+		// 利用率
+		setRatio(Metrics.ContainerCpuLimitUtilizationMetric, containerResource.UseCpu, containerResource.LimitCpu, labels)
+		setRatio(Metrics.ContainerMemoryLimitUtilizationMetric, float64(containerResource.UseMemory), float64(containerResource.LimitMemory), labels)
+		setRatio(Metrics.ContainerCpuRequestUtilizationMetric, containerResource.UseCpu, containerResource.RequestCpu, labels)
+		setRatio(Metrics.ContainerMemoryRequestUtilizationMetric, float64(containerResource.UseMemory), float64(containerResource.RequestMemory), labels)
+
+		// 等待原因、终止原因、Pod 阶段和节点
+		updateContainerStatus(metricLabel, labels, containerStatus{
+			waitingReason:     containerResource.WaitingReason,
+			terminationReason: containerResource.LastTerminationReason,
+			phase:             containerResource.PodPhase,
+			node:              containerResource.NodeName,
+		})
+
+		UpdateContainerMetricWithTimestamp(metricLabel, ts)
+	}
+	return samples
+}
 
-		UpdateContainerMetricWithTimestamp(JoinLabels(containerNamespace, containerResource.PodName, containerResource.Container, containerResource.ControllerName, projectName))
+// setRatio 设置 value / base，base 为 0（未设置限制或请求）时删除该指标
+func setRatio(metric *Metrics.GaugeVec, value, base float64, labels []string) {
+	if base <= 0 {
+		metric.DeleteLabelValues(labels...)
+		return
 	}
+	metric.WithLabelValues(labels...).Set(value / base)
 }
-func HandleTrafficSwitchingData(data []interface{}, project string) {
-	projectName := getProjectName(project)
+func HandleTrafficSwitchingData(data []interface{}, project string, receivedAt time.Time) []Modles.Sample {
+	var samples []Modles.Sample
 
 	for _, item := range data {
 		var ts Modles.TrafficSwitchingSource
@@ -206,6 +326,13 @@ func HandleTrafficSwitchingData(data []interface{}, project string) {
 
 		service := ts.Service
 
+		metricLabel := JoinLabels(service, project)
+		sampledAt := sampleTime(ts.Timestamp, receivedAt)
+		if !acceptSample("trafficSwitching", project, metricLabel, ts.Timestamp, receivedAt) {
+			continue
+		}
+		samples = appendSample(samples, item, sampledAt)
+
 		// 解析 success_rate（兼容字符串、数字和nil）
 		var successRate float64
 		switch v := ts.TotalSuccessRate.(type) {
@@ -225,65 +352,78 @@ func HandleTrafficSwitchingData(data []interface{}, project string) {
 		}
 
 		// 累计统计
-		Metrics.TrafficSwitchingTotalRequests.WithLabelValues(service, projectName).Set(ts.TotalRequests)
-		Metrics.TrafficSwitchingTotalSuccess.WithLabelValues(service, projectName).Set(ts.TotalSuccess)
-		Metrics.TrafficSwitchingTotalErrors.WithLabelValues(service, projectName).Set(ts.TotalErrors)
-		Metrics.TrafficSwitchingTotalSuccessRate.WithLabelValues(service, projectName).Set(successRate)
+		if legacyCounterGaugesEnabled() {
+			Metrics.TrafficSwitchingTotalRequests.WithLabelValues(service, project).Set(ts.TotalRequests)
+			Metrics.TrafficSwitchingTotalSuccess.WithLabelValues(service, project).Set(ts.TotalSuccess)
+			Metrics.TrafficSwitchingTotalErrors.WithLabelValues(service, project).Set(ts.TotalErrors)
+		}
+		Metrics.TrafficSwitchingTotalSuccessRate.WithLabelValues(service, project).Set(successRate)
+		trafficRequestsDeriver.observe(ts.TotalRequests, sampledAt, service, project)
+		trafficSuccessDeriver.observe(ts.TotalSuccess, sampledAt, service, project)
+		trafficErrorsDeriver.observe(ts.TotalErrors, sampledAt, service, project)
 
 		// 今日统计
-		Metrics.TrafficSwitchingTodayRequests.WithLabelValues(service, projectName).Set(ts.TodayRequests)
-		Metrics.TrafficSwitchingTodaySuccess.WithLabelValues(service, projectName).Set(ts.TodaySuccess)
-		Metrics.TrafficSwitchingTodayErrors.WithLabelValues(service, projectName).Set(ts.TodayErrors)
-		Metrics.TrafficSwitchingTodayCanceled.WithLabelValues(service, projectName).Set(ts.TodayCanceled)
-		Metrics.TrafficSwitchingTodayStatus2xx.WithLabelValues(service, projectName).Set(ts.TodayStatus2xx)
-		Metrics.TrafficSwitchingTodayStatus3xx.WithLabelValues(service, projectName).Set(ts.TodayStatus3xx)
-		Metrics.TrafficSwitchingTodayStatus4xx.WithLabelValues(service, projectName).Set(ts.TodayStatus4xx)
-		Metrics.TrafficSwitchingTodayStatus5xx.WithLabelValues(service, projectName).Set(ts.TodayStatus5xx)
+		Metrics.TrafficSwitchingTodayRequests.WithLabelValues(service, project).Set(ts.TodayRequests)
+		Metrics.TrafficSwitchingTodaySuccess.WithLabelValues(service, project).Set(ts.TodaySuccess)
+		Metrics.TrafficSwitchingTodayErrors.WithLabelValues(service, project).Set(ts.TodayErrors)
+		Metrics.TrafficSwitchingTodayCanceled.WithLabelValues(service, project).Set(ts.TodayCanceled)
+		Metrics.TrafficSwitchingTodayStatus2xx.WithLabelValues(service, project).Set(ts.TodayStatus2xx)
+		Metrics.TrafficSwitchingTodayStatus3xx.WithLabelValues(service, project).Set(ts.TodayStatus3xx)
+		Metrics.TrafficSwitchingTodayStatus4xx.WithLabelValues(service, project).Set(ts.TodayStatus4xx)
+		Metrics.TrafficSwitchingTodayStatus5xx.WithLabelValues(service, project).Set(ts.TodayStatus5xx)
+		handleTrafficSwitchingToday(metricLabel, service, project, todayValues(ts), sampledAt)
 
 		// 实时统计
-		Metrics.TrafficSwitchingRealtimeQPS.WithLabelValues(service, projectName).Set(ts.RealtimeQPS)
-		Metrics.TrafficSwitchingRealtimeSuccessQPS.WithLabelValues(service, projectName).Set(ts.RealtimeSuccessQPS)
-		Metrics.TrafficSwitchingRealtimeErrorQPS.WithLabelValues(service, projectName).Set(ts.RealtimeErrorQPS)
-		Metrics.TrafficSwitchingRealtimeActiveConnections.WithLabelValues(service, projectName).Set(ts.RealtimeActiveConnections)
-		Metrics.TrafficSwitchingRealtimeAvgLatencyMs.WithLabelValues(service, projectName).Set(ts.RealtimeAvgLatencyMs)
-		Metrics.TrafficSwitchingRealtimeMaxLatencyMs.WithLabelValues(service, projectName).Set(ts.RealtimeMaxLatencyMs)
+		Metrics.TrafficSwitchingRealtimeQPS.WithLabelValues(service, project).Set(ts.RealtimeQPS)
+		Metrics.TrafficSwitchingRealtimeSuccessQPS.WithLabelValues(service, project).Set(ts.RealtimeSuccessQPS)
+		Metrics.TrafficSwitchingRealtimeErrorQPS.WithLabelValues(service, project).Set(ts.RealtimeErrorQPS)
+		Metrics.TrafficSwitchingRealtimeActiveConnections.WithLabelValues(service, project).Set(ts.RealtimeActiveConnections)
+		Metrics.TrafficSwitchingRealtimeAvgLatencyMs.WithLabelValues(service, project).Set(ts.RealtimeAvgLatencyMs)
+		Metrics.TrafficSwitchingRealtimeMaxLatencyMs.WithLabelValues(service, project).Set(ts.RealtimeMaxLatencyMs)
+
+		// 延迟分布
+		handleTrafficSwitchingLatency(metricLabel, service, project, ts.Latency)
+
+		// 按后端、路由拆分
+		handleTrafficSwitchingBreakdown(metricLabel, service, project, ts.Backends, ts.Routes, sampledAt)
 
 		// 错误类型
-		Metrics.TrafficSwitchingErrorBackendError.WithLabelValues(service, projectName).Set(ts.ErrorBackendError)
-		Metrics.TrafficSwitchingErrorBrokenPipe.WithLabelValues(service, projectName).Set(ts.ErrorBrokenPipe)
-		Metrics.TrafficSwitchingErrorConnectionRefused.WithLabelValues(service, projectName).Set(ts.ErrorConnectionRefused)
-		Metrics.TrafficSwitchingErrorConnectionReset.WithLabelValues(service, projectName).Set(ts.ErrorConnectionReset)
-		Metrics.TrafficSwitchingErrorDNSError.WithLabelValues(service, projectName).Set(ts.ErrorDNSError)
-		Metrics.TrafficSwitchingErrorEOF.WithLabelValues(service, projectName).Set(ts.ErrorEOF)
-		Metrics.TrafficSwitchingErrorTimeout.WithLabelValues(service, projectName).Set(ts.ErrorTimeout)
+		Metrics.TrafficSwitchingErrorBackendError.WithLabelValues(service, project).Set(ts.ErrorBackendError)
+		Metrics.TrafficSwitchingErrorBrokenPipe.WithLabelValues(service, project).Set(ts.ErrorBrokenPipe)
+		Metrics.TrafficSwitchingErrorConnectionRefused.WithLabelValues(service, project).Set(ts.ErrorConnectionRefused)
+		Metrics.TrafficSwitchingErrorConnectionReset.WithLabelValues(service, project).Set(ts.ErrorConnectionReset)
+		Metrics.TrafficSwitchingErrorDNSError.WithLabelValues(service, project).Set(ts.ErrorDNSError)
+		Metrics.TrafficSwitchingErrorEOF.WithLabelValues(service, project).Set(ts.ErrorEOF)
+		Metrics.TrafficSwitchingErrorTimeout.WithLabelValues(service, project).Set(ts.ErrorTimeout)
 
 		// 代理缓存
-		Metrics.TrafficSwitchingProxyCacheSize.WithLabelValues(service, projectName).Set(ts.ProxyCacheSize)
-		Metrics.TrafficSwitchingProxyMaxCacheSize.WithLabelValues(service, projectName).Set(ts.ProxyMaxCacheSize)
+		Metrics.TrafficSwitchingProxyCacheSize.WithLabelValues(service, project).Set(ts.ProxyCacheSize)
+		Metrics.TrafficSwitchingProxyMaxCacheSize.WithLabelValues(service, project).Set(ts.ProxyMaxCacheSize)
 
 		// Runtime
-		Metrics.TrafficSwitchingRuntimeGoroutines.WithLabelValues(service, projectName).Set(ts.RuntimeGoroutines)
-		Metrics.TrafficSwitchingRuntimeMemoryMB.WithLabelValues(service, projectName).Set(ts.RuntimeMemoryMB)
-		Metrics.TrafficSwitchingRuntimeCPUCores.WithLabelValues(service, projectName).Set(ts.RuntimeCPUCores)
-		Metrics.TrafficSwitchingRuntimeGomaxprocs.WithLabelValues(service, projectName).Set(ts.RuntimeGomaxprocs)
-		Metrics.TrafficSwitchingRuntimeGcCycles.WithLabelValues(service, projectName).Set(ts.RuntimeGcCycles)
+		Metrics.TrafficSwitchingRuntimeGoroutines.WithLabelValues(service, project).Set(ts.RuntimeGoroutines)
+		Metrics.TrafficSwitchingRuntimeMemoryMB.WithLabelValues(service, project).Set(ts.RuntimeMemoryMB)
+		Metrics.TrafficSwitchingRuntimeCPUCores.WithLabelValues(service, project).Set(ts.RuntimeCPUCores)
+		Metrics.TrafficSwitchingRuntimeGomaxprocs.WithLabelValues(service, project).Set(ts.RuntimeGomaxprocs)
+		Metrics.TrafficSwitchingRuntimeGcCycles.WithLabelValues(service, project).Set(ts.RuntimeGcCycles)
 
 		// Transport 配置
-		Metrics.TrafficSwitchingTransportMaxConnsPerHost.WithLabelValues(service, projectName).Set(ts.TransportMaxConnsPerHost)
-		Metrics.TrafficSwitchingTransportMaxIdleConns.WithLabelValues(service, projectName).Set(ts.TransportMaxIdleConns)
-		Metrics.TrafficSwitchingTransportMaxIdleConnsPerHost.WithLabelValues(service, projectName).Set(ts.TransportMaxIdleConnsPerHost)
+		Metrics.TrafficSwitchingTransportMaxConnsPerHost.WithLabelValues(service, project).Set(ts.TransportMaxConnsPerHost)
+		Metrics.TrafficSwitchingTransportMaxIdleConns.WithLabelValues(service, project).Set(ts.TransportMaxIdleConns)
+		Metrics.TrafficSwitchingTransportMaxIdleConnsPerHost.WithLabelValues(service, project).Set(ts.TransportMaxIdleConnsPerHost)
 
 		// 上报时间戳
-		Metrics.TrafficSwitchingTimestamp.WithLabelValues(service, projectName).Set(ts.Timestamp)
+		Metrics.TrafficSwitchingTimestamp.WithLabelValues(service, project).Set(ts.Timestamp)
 
 		// 更新心跳时间戳
-		UpdateTrafficSwitchingTimestamp(JoinLabels(service, projectName))
+		UpdateTrafficSwitchingTimestamp(metricLabel, sampledAt)
 	}
+	return samples
 }
 
 // 更新心跳数据
-func HandleHeartData(data []interface{}, project string) {
-	projectName := getProjectName(project)
+func HandleHeartData(data []interface{}, project string, receivedAt time.Time) []Modles.Sample {
+	var samples []Modles.Sample
 	for _, item := range data {
 		var heartData Modles.HeartSource
 		if err := mapstructure.Decode(item, &heartData); err != nil {
@@ -291,19 +431,26 @@ func HandleHeartData(data []interface{}, project string) {
 			continue
 		}
 
+		metricLabel := JoinLabels(heartData.Hostname, project)
+		ts := sampleTime(heartData.Timestamp, receivedAt)
+		if !acceptSample("heart", project, metricLabel, heartData.Timestamp, receivedAt) {
+			continue
+		}
+		samples = appendSample(samples, item, ts)
+
 		// 更新心跳指标
-		Metrics.IsActiveMetric.WithLabelValues(heartData.Hostname, projectName).Set(float64(heartData.IsActive))
-		Metrics.AgentVerisonMetric.WithLabelValues(heartData.Hostname, projectName).Set(float64(heartData.Version))
+		Metrics.IsActiveMetric.WithLabelValues(heartData.Hostname, project).Set(float64(heartData.IsActive))
+		Metrics.AgentVerisonMetric.WithLabelValues(heartData.Hostname, project).Set(float64(heartData.Version))
 
 		// 记录时间戳
-		metricLabel := JoinLabels(heartData.Hostname, projectName)
-		agentHeartbeatTimes.Store(metricLabel, time.Now())
+		agentHeartbeatTimes.Store(metricLabel, ts)
 	}
+	return samples
 }
 
 // 更新控制器数据
-func HandleControllertResourceData(data []interface{}, project string) {
-	projectName := getProjectName(project)
+func HandleControllertResourceData(data []interface{}, project string, receivedAt time.Time) []Modles.Sample {
+	var samples []Modles.Sample
 	for _, item := range data {
 		var controllerData Modles.ControllerResource
 		if err := mapstructure.Decode(item, &controllerData); err != nil {
@@ -311,13 +458,50 @@ func HandleControllertResourceData(data []interface{}, project string) {
 			continue
 		}
 
-		containerNamespace := cleanNamespace(controllerData.Namespace)
+		containerNamespace, slot := normalizeNamespace(project, controllerData.Namespace)
+
+		// 旧版 agent 用 container 字段上报控制器名称
+		controllerName := controllerData.ControllerName
+		if controllerName == "" {
+			controllerName = controllerData.Container
+		}
+
+		metricLabel := JoinLabels(containerNamespace, controllerData.Namespace, slot, controllerName, controllerData.ControllerType, project)
+		ts := sampleTime(controllerData.Timestamp, receivedAt)
+		if !acceptSample("k8sController", project, metricLabel, controllerData.Timestamp, receivedAt) {
+			continue
+		}
+		samples = appendSample(samples, item, ts)
+		labels := []string{containerNamespace, controllerData.Namespace, slot, controllerName, controllerData.ControllerType, project}
 
 		// 更新控制器指标
-		Metrics.ControllerReplicasMetric.WithLabelValues(containerNamespace, controllerData.Container, controllerData.ControllerType, projectName).Set(float64(controllerData.Replicas))
-		Metrics.ControllerReplicasAvailableMetric.WithLabelValues(containerNamespace, controllerData.Container, controllerData.ControllerType, projectName).Set(float64(controllerData.ReplicasAvailable))
-		Metrics.ControllerReplicasUnavailableMetric.WithLabelValues(containerNamespace, controllerData.Container, controllerData.ControllerType, projectName).Set(float64(controllerData.ReplicasUnavailable))
+		Metrics.ControllerReplicasMetric.WithLabelValues(labels...).Set(float64(controllerData.Replicas))
+		Metrics.ControllerReplicasAvailableMetric.WithLabelValues(labels...).Set(float64(controllerData.ReplicasAvailable))
+		Metrics.ControllerReplicasUnavailableMetric.WithLabelValues(labels...).Set(float64(controllerData.ReplicasUnavailable))
+		Metrics.ControllerReplicasReadyMetric.WithLabelValues(labels...).Set(float64(controllerData.ReplicasReady))
+		Metrics.ControllerReplicasUpdatedMetric.WithLabelValues(labels...).Set(float64(controllerData.ReplicasUpdated))
+		Metrics.ControllerGenerationMetric.WithLabelValues(labels...).Set(float64(controllerData.Generation))
+		Metrics.ControllerObservedGenerationMetric.WithLabelValues(labels...).Set(float64(controllerData.ObservedGeneration))
+
+		// 没有 HPA 时不输出 HPA 指标
+		if controllerData.HpaMaxReplicas > 0 {
+			Metrics.ControllerHpaMinReplicasMetric.WithLabelValues(labels...).Set(float64(controllerData.HpaMinReplicas))
+			Metrics.ControllerHpaMaxReplicasMetric.WithLabelValues(labels...).Set(float64(controllerData.HpaMaxReplicas))
+			Metrics.ControllerHpaCurrentReplicasMetric.WithLabelValues(labels...).Set(float64(controllerData.HpaCurrentReplicas))
+		} else {
+			Metrics.ControllerHpaMinReplicasMetric.DeleteLabelValues(labels...)
+			Metrics.ControllerHpaMaxReplicasMetric.DeleteLabelValues(labels...)
+			Metrics.ControllerHpaCurrentReplicasMetric.DeleteLabelValues(labels...)
+		}
+
+		if controllerData.LastSuccessTime > 0 {
+			Metrics.ControllerLastSuccessTimeMetric.WithLabelValues(labels...).Set(float64(controllerData.LastSuccessTime))
+		}
+
+		updateControllerConditions(metricLabel, labels, controllerData.Conditions)
+		updateRolloutStuck(metricLabel, labels, controllerData, ts)
 
-		UpdateControllerMetricWithTimestamp(JoinLabels(containerNamespace, controllerData.Container, controllerData.ControllerType, projectName))
+		UpdateControllerMetricWithTimestamp(metricLabel, ts)
 	}
+	return samples
 }
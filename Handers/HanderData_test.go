@@ -0,0 +1,281 @@
+package Handers
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"monitor-server/Metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// collectSeries 收集指标的全部序列，key 为按标签名排序的 name=value 列表
+func collectSeries(t *testing.T, c prometheus.Collector) map[string]float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 1024)
+	c.Collect(ch)
+	close(ch)
+
+	series := map[string]float64{}
+	for metric := range ch {
+		m := &dto.Metric{}
+		if err := metric.Write(m); err != nil {
+			t.Fatal(err)
+		}
+		pairs := make([]string, 0, len(m.GetLabel()))
+		for _, l := range m.GetLabel() {
+			pairs = append(pairs, l.GetName()+"="+l.GetValue())
+		}
+		sort.Strings(pairs)
+		var value float64
+		switch {
+		case m.Gauge != nil:
+			value = m.GetGauge().GetValue()
+		case m.Counter != nil:
+			value = m.GetCounter().GetValue()
+		case m.Untyped != nil:
+			value = m.GetUntyped().GetValue()
+		}
+		series[strings.Join(pairs, ",")] = value
+	}
+	return series
+}
+
+// projectSeries 只保留指定项目的序列，避免不同用例之间相互影响
+func projectSeries(t *testing.T, c prometheus.Collector, project string) map[string]float64 {
+	t.Helper()
+	series := map[string]float64{}
+	for key, value := range collectSeries(t, c) {
+		if strings.Contains(","+key+",", ",project="+project+",") {
+			series[key] = value
+		}
+	}
+	return series
+}
+
+func TestHandleHardDataHostInfo(t *testing.T) {
+	SetHardLegacyLabels(true)
+	defer SetHardLegacyLabels(false)
+
+	const project = "test-hard"
+	now := time.Now()
+	report := func(cpu float64, kernel string, at time.Time) {
+		HandleHardData([]interface{}{map[string]interface{}{
+			"hostName":       "web1",
+			"cpu_percent":    cpu,
+			"cpu_model":      "Xeon",
+			"os_version":     "Ubuntu 22.04",
+			"kernel_version": kernel,
+		}}, project, at)
+	}
+
+	report(10, "5.15", now)
+	report(20, "6.1", now.Add(time.Second))
+
+	if got, want := projectSeries(t, Metrics.CpuPercentMetric, project), map[string]float64{
+		"hostName=web1,project=" + project: 20,
+	}; !equalSeries(got, want) {
+		t.Errorf("cpu_percent = %v, want %v", got, want)
+	}
+
+	// 内核版本变化后只保留新属性的 host_info 和旧版指标
+	wantInfo := map[string]float64{
+		"cpu_model=Xeon,hostName=web1,kernel_version=6.1,os_version=Ubuntu 22.04,project=" + project: 1,
+	}
+	if got := projectSeries(t, Metrics.HostInfoMetric, project); !equalSeries(got, wantInfo) {
+		t.Errorf("host_info = %v, want %v", got, wantInfo)
+	}
+	wantLegacy := map[string]float64{
+		"cpu_model=Xeon,hostName=web1,kernel_version=6.1,os_version=Ubuntu 22.04,project=" + project: 20,
+	}
+	if got := projectSeries(t, Metrics.HardLegacyMetrics[Metrics.CpuPercentMetric], project); !equalSeries(got, wantLegacy) {
+		t.Errorf("legacy cpu_percent = %v, want %v", got, wantLegacy)
+	}
+
+	// 关闭旧版指标后立即清空
+	SetHardLegacyLabels(false)
+	if got := projectSeries(t, Metrics.HardLegacyMetrics[Metrics.CpuPercentMetric], project); len(got) != 0 {
+		t.Errorf("legacy cpu_percent after disable = %v, want empty", got)
+	}
+}
+
+func equalSeries(got, want map[string]float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for k, v := range want {
+		if g, ok := got[k]; !ok || g != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ageTimestamps 将包含 substr 的标签的最后采样时间改到一天前，使其在下次检查时过期
+func ageTimestamps(store interface {
+	Range(func(key, value interface{}) bool)
+	Store(key, value interface{})
+}, substr string) {
+	store.Range(func(key, value interface{}) bool {
+		if strings.Contains(key.(string), substr) {
+			store.Store(key, time.Now().Add(-24*time.Hour))
+		}
+		return true
+	})
+}
+
+func TestHardDevicesExpireIndependently(t *testing.T) {
+	const project = "test-hard-device"
+	HandleHardData([]interface{}{map[string]interface{}{
+		"hostName": "db1",
+		"mountpoints": []interface{}{
+			map[string]interface{}{"mountpoint": "/", "device": "sda1", "fs_type": "ext4", "used_percent": 40.0},
+			map[string]interface{}{"mountpoint": "/data", "device": "sdb1", "fs_type": "xfs", "used_percent": 90.0},
+			map[string]interface{}{"device": "tmpfs", "used_percent": 1.0}, // 缺少挂载点，跳过
+		},
+		"disks":      []interface{}{map[string]interface{}{"device": "sda", "io_util_percent": 12.5}},
+		"interfaces": []interface{}{map[string]interface{}{"interface": "eth0", "rx_bytes": 1024.0}},
+	}}, project, time.Now())
+
+	if got, want := projectSeries(t, Metrics.MountpointUsedPercentMetric, project), map[string]float64{
+		"device=sda1,fs_type=ext4,hostName=db1,mountpoint=/,project=" + project:    40,
+		"device=sdb1,fs_type=xfs,hostName=db1,mountpoint=/data,project=" + project: 90,
+	}; !equalSeries(got, want) {
+		t.Errorf("mountpoint_used_percent = %v, want %v", got, want)
+	}
+
+	// 只有 /data 和 eth0 停止上报
+	ageTimestamps(&MountpointTimestamp, "/data")
+	ageTimestamps(&InterfaceTimestamp, "eth0")
+	CheckHardDeviceHeartbeats()
+
+	if got, want := projectSeries(t, Metrics.MountpointUsedPercentMetric, project), map[string]float64{
+		"device=sda1,fs_type=ext4,hostName=db1,mountpoint=/,project=" + project: 40,
+	}; !equalSeries(got, want) {
+		t.Errorf("mountpoint_used_percent after expiry = %v, want %v", got, want)
+	}
+	if got := projectSeries(t, Metrics.DiskIoUtilPercentMetric, project); len(got) != 1 {
+		t.Errorf("disk_io_util_percent after expiry = %v, want 1 series", got)
+	}
+	if got := projectSeries(t, Metrics.NetworkRxBytesMetric, project); len(got) != 0 {
+		t.Errorf("network_rx_bytes after expiry = %v, want empty", got)
+	}
+}
+
+func TestHandleContainerResourceStatusAndRatios(t *testing.T) {
+	const project = "test-container"
+	now := time.Now()
+	report := func(at time.Time, fields map[string]interface{}) {
+		item := map[string]interface{}{
+			"namespace":      "shop",
+			"podName":        "api-0",
+			"container":      "api",
+			"controllerName": "api",
+			"useCpu":         1.0,
+			"limitCpu":       2.0,
+		}
+		for k, v := range fields {
+			item[k] = v
+		}
+		HandleContainerResourceData([]interface{}{item}, project, at)
+	}
+
+	report(now, map[string]interface{}{"waitingReason": "CrashLoopBackOff", "podPhase": "Running", "nodeName": "node-1"})
+
+	if got := projectSeries(t, Metrics.ContainerCpuLimitUtilizationMetric, project); len(got) != 1 || firstValue(got) != 0.5 {
+		t.Errorf("cpu limit utilization = %v, want 0.5", got)
+	}
+	// 未设置 request 时不输出 request 利用率
+	if got := projectSeries(t, Metrics.ContainerCpuRequestUtilizationMetric, project); len(got) != 0 {
+		t.Errorf("cpu request utilization without request = %v, want empty", got)
+	}
+	if got := projectSeries(t, Metrics.ContainerWaitingReasonMetric, project); len(got) != 1 {
+		t.Errorf("waiting reason = %v, want 1 series", got)
+	}
+
+	// 容器恢复运行、调度到新节点，旧的状态序列被删除
+	report(now.Add(time.Second), map[string]interface{}{"requestCpu": 0.5, "podPhase": "Running", "nodeName": "node-2"})
+
+	if got := projectSeries(t, Metrics.ContainerWaitingReasonMetric, project); len(got) != 0 {
+		t.Errorf("waiting reason after recovery = %v, want empty", got)
+	}
+	podInfo := projectSeries(t, Metrics.ContainerPodInfoMetric, project)
+	if len(podInfo) != 1 {
+		t.Fatalf("pod info = %v, want 1 series", podInfo)
+	}
+	for key := range podInfo {
+		if !strings.Contains(key, "node=node-2") {
+			t.Errorf("pod info = %v, want node-2", podInfo)
+		}
+	}
+	if got := projectSeries(t, Metrics.ContainerCpuRequestUtilizationMetric, project); firstValue(got) != 2 {
+		t.Errorf("cpu request utilization = %v, want 2", got)
+	}
+}
+
+// firstValue 返回只有一个序列时的值，否则返回 -1
+func firstValue(series map[string]float64) float64 {
+	if len(series) != 1 {
+		return -1
+	}
+	for _, v := range series {
+		return v
+	}
+	return -1
+}
+
+func TestHandleNginxStatus(t *testing.T) {
+	const project = "test-nginx-status"
+	now := time.Now()
+	report := func(at time.Time, status2xx, status5xx float64) {
+		HandleNginxData([]interface{}{map[string]interface{}{
+			"hostName":    "lb1",
+			"stub_status": map[string]interface{}{"active": 12.0, "accepts": 100.0, "handled": 100.0, "requests": 300.0},
+			"upstream_peers": []interface{}{
+				map[string]interface{}{"upstream": "api", "server": "10.0.0.1:80", "state": "UP", "response_time_ms": 15.0},
+				map[string]interface{}{"upstream": "api", "server": "10.0.0.2:80", "state": "unhealthy", "fails": 3.0},
+				map[string]interface{}{"upstream": "api", "state": "up"}, // 缺少后端地址，跳过
+			},
+			"servers": []interface{}{
+				map[string]interface{}{"server_name": "shop.example.com", "status_2xx": status2xx, "status_5xx": status5xx},
+			},
+		}}, project, at)
+	}
+
+	report(now, 1000, 10)
+	report(now.Add(10*time.Second), 1500, 4) // 5xx 归零后重新累计
+
+	wantUp := map[string]float64{
+		"hostName=lb1,peer=10.0.0.1:80,project=" + project + ",upstream=api": 1,
+		"hostName=lb1,peer=10.0.0.2:80,project=" + project + ",upstream=api": 0,
+	}
+	if got := projectSeries(t, Metrics.NginxUpstreamPeerUpMetric, project); !equalSeries(got, wantUp) {
+		t.Errorf("nginx_upstream_peer_up = %v, want %v", got, wantUp)
+	}
+
+	responses := projectSeries(t, Metrics.NginxServerResponsesCounter, project)
+	prefix := "code=%s,hostName=lb1,project=" + project + ",server_name=shop.example.com"
+	for code, want := range map[string]float64{"2xx": 1500, "5xx": 14} {
+		if got := responses[strings.Replace(prefix, "%s", code, 1)]; got != want {
+			t.Errorf("nginx_server_responses_total{code=%q} = %v, want %v", code, got, want)
+		}
+	}
+
+	if got := projectSeries(t, Metrics.NginxConnectionsActiveMetric, project); firstValue(got) != 12 {
+		t.Errorf("nginx_connections_active = %v, want 12", got)
+	}
+
+	// 后端从 upstream 中移除后单独过期，server 块不受影响
+	ageTimestamps(&NginxUpstreamTimestamp, "10.0.0.2:80")
+	CheckNginxStatusHeartbeats()
+	delete(wantUp, "hostName=lb1,peer=10.0.0.2:80,project="+project+",upstream=api")
+	if got := projectSeries(t, Metrics.NginxUpstreamPeerUpMetric, project); !equalSeries(got, wantUp) {
+		t.Errorf("nginx_upstream_peer_up after expiry = %v, want %v", got, wantUp)
+	}
+	if got := projectSeries(t, Metrics.NginxServerResponsesCounter, project); len(got) != 5 {
+		t.Errorf("nginx_server_responses_total after expiry = %d series, want 5", len(got))
+	}
+}
@@ -0,0 +1,349 @@
+package Handers
+
+import (
+	"encoding/json"
+	"log"
+	"monitor-server/Metrics"
+	"monitor-server/Modles"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// 事件缓存限制
+const (
+	maxEventsPerNamespace = 200       // 每个 project/namespace 最多保留的事件数
+	eventRetention        = time.Hour // 事件保留时长，超过后不再计入 k8s_events
+	defaultEventLimit     = 100
+	maxEventLimit         = 1000
+)
+
+// K8sEventRecord 缓存中的事件，同一事件（uid 相同）再次上报时更新次数和时间
+type K8sEventRecord struct {
+	Project      string    `json:"project"`      // 项目编码
+	ProjectName  string    `json:"project_name"` // 显示名称，查询时按当前项目登记填写
+	Namespace    string    `json:"namespace"`    // 归一化后的 namespace，查询时按当前规则填写
+	NamespaceRaw string    `json:"namespace_raw"`
+	Slot         string    `json:"slot"` // 查询时按当前规则填写
+	Kind         string    `json:"kind"`
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	Reason       string    `json:"reason"`
+	Message      string    `json:"message"`
+	Count        int32     `json:"count"`
+	Source       string    `json:"source"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+
+	uid string
+}
+
+// eventBuffer 单个 project/namespace 的事件缓存，字段由自身的 mu 保护
+// 归一化后的 namespace 和 slot 不保存在缓存中，输出指标和查询时按当前规则计算
+type eventBuffer struct {
+	project      string
+	namespaceRaw string
+
+	mu      sync.Mutex
+	removed bool                       // 已从 eventBuffers 删除，不再写入
+	labels  []string                   // 已输出序列的标签 namespace, namespace_raw, slot, project
+	events  []*K8sEventRecord          // 按写入顺序，超出上限时淘汰最早写入的事件
+	index   map[string]*K8sEventRecord // uid -> 事件
+	exposed map[[2]string]bool         // 已输出的 type/reason 组合
+}
+
+// eventBuffers key 格式: project|:|namespace_raw
+// eventBuffersMu 只保护 map 本身，不同 namespace 的上报和查询互不阻塞
+// 加锁顺序：eventBuffersMu 在前，eventBuffer.mu 在后
+var (
+	eventBuffers   = map[string]*eventBuffer{}
+	eventBuffersMu sync.RWMutex
+)
+
+// lockEventBuffer 返回已加锁的 project/namespace 事件缓存，不存在时创建
+func lockEventBuffer(project, namespaceRaw string) *eventBuffer {
+	key := JoinLabels(project, namespaceRaw)
+	for {
+		eventBuffersMu.RLock()
+		buffer, ok := eventBuffers[key]
+		eventBuffersMu.RUnlock()
+		if !ok {
+			eventBuffersMu.Lock()
+			if buffer, ok = eventBuffers[key]; !ok {
+				buffer = &eventBuffer{
+					project:      project,
+					namespaceRaw: namespaceRaw,
+					index:        map[string]*K8sEventRecord{},
+					exposed:      map[[2]string]bool{},
+				}
+				eventBuffers[key] = buffer
+			}
+			eventBuffersMu.Unlock()
+		}
+
+		buffer.mu.Lock()
+		if !buffer.removed {
+			return buffer
+		}
+		// 取得之后被 CheckK8sEventHeartbeats 删除，重新查找
+		buffer.mu.Unlock()
+	}
+}
+
+// parseEventTime 解析事件时间（RFC3339），失败时返回零值
+func parseEventTime(values ...string) time.Time {
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// HandleK8sEventData 处理 Kubernetes 事件数据
+func HandleK8sEventData(data []interface{}, project string, receivedAt time.Time) []Modles.Sample {
+	touched := map[*eventBuffer]bool{}
+	var samples []Modles.Sample
+	for _, item := range data {
+		var event Modles.K8sEvent
+		if err := mapstructure.Decode(item, &event); err != nil {
+			log.Printf("解析 Kubernetes 事件失败: %v", err)
+			continue
+		}
+
+		namespaceRaw := event.Metadata.Namespace
+		if namespaceRaw == "" {
+			namespaceRaw = event.InvolvedObject.Namespace
+		}
+		if namespaceRaw == "" || event.Reason == "" {
+			continue
+		}
+
+		lastSeen := parseEventTime(event.LastTimestamp, event.EventTime)
+		if lastSeen.IsZero() {
+			lastSeen = sampleTime(event.Timestamp, receivedAt)
+		}
+		if receivedAt.Sub(lastSeen) > eventRetention {
+			Metrics.IngestDroppedSamples.WithLabelValues("k8sEvent", "stale").Inc()
+			continue
+		}
+		firstSeen := parseEventTime(event.FirstTimestamp)
+		if firstSeen.IsZero() {
+			firstSeen = lastSeen
+		}
+		count := event.Count
+		if count <= 0 {
+			count = 1
+		}
+
+		uid := event.Metadata.UID
+		if uid == "" {
+			uid = JoinLabels(namespaceRaw, event.Metadata.Name)
+		}
+
+		buffer := lockEventBuffer(project, namespaceRaw)
+		touched[buffer] = true
+		if record, ok := buffer.index[uid]; ok {
+			// 同一事件再次上报，只接受更新的数据
+			if !lastSeen.Before(record.LastSeen) {
+				record.Count = count
+				record.Message = event.Message
+				record.LastSeen = lastSeen
+				samples = appendSample(samples, item, lastSeen)
+			}
+			buffer.mu.Unlock()
+			continue
+		}
+
+		record := &K8sEventRecord{
+			Project:      project,
+			NamespaceRaw: namespaceRaw,
+			Kind:         event.InvolvedObject.Kind,
+			Name:         event.InvolvedObject.Name,
+			Type:         event.Type,
+			Reason:       event.Reason,
+			Message:      event.Message,
+			Count:        count,
+			Source:       event.Source.Component,
+			FirstSeen:    firstSeen,
+			LastSeen:     lastSeen,
+			uid:          uid,
+		}
+		buffer.events = append(buffer.events, record)
+		buffer.index[uid] = record
+		if len(buffer.events) > maxEventsPerNamespace {
+			delete(buffer.index, buffer.events[0].uid)
+			buffer.events = buffer.events[1:]
+		}
+		buffer.mu.Unlock()
+		samples = appendSample(samples, item, lastSeen)
+	}
+
+	for buffer := range touched {
+		buffer.mu.Lock()
+		if !buffer.removed {
+			buffer.refresh(receivedAt)
+		}
+		buffer.mu.Unlock()
+	}
+	return samples
+}
+
+// namespaceLabels 按当前规则归一化 namespace，namespace 规则重新加载后立即生效
+func (b *eventBuffer) namespaceLabels() (string, string) {
+	return normalizeNamespace(b.project, b.namespaceRaw)
+}
+
+// refresh 清理过期事件并重新计算 k8s_events（调用方需持有 b.mu）
+// 归一化结果变化时（namespace 规则重新加载）删除旧标签的序列
+func (b *eventBuffer) refresh(now time.Time) {
+	kept := b.events[:0]
+	for _, record := range b.events {
+		if now.Sub(record.LastSeen) > eventRetention {
+			delete(b.index, record.uid)
+			continue
+		}
+		kept = append(kept, record)
+	}
+	b.events = kept
+
+	namespace, slot := b.namespaceLabels()
+	labels := []string{namespace, b.namespaceRaw, slot, b.project}
+	if !slices.Equal(labels, b.labels) {
+		for typeReason := range b.exposed {
+			Metrics.K8sEventsMetric.DeleteLabelValues(append(b.labels, typeReason[0], typeReason[1])...)
+			delete(b.exposed, typeReason)
+		}
+		b.labels = labels
+	}
+
+	counts := map[[2]string]float64{}
+	for _, record := range b.events {
+		counts[[2]string{record.Type, record.Reason}] += float64(record.Count)
+	}
+	for typeReason := range b.exposed {
+		if _, ok := counts[typeReason]; !ok {
+			Metrics.K8sEventsMetric.DeleteLabelValues(append(b.labels, typeReason[0], typeReason[1])...)
+			delete(b.exposed, typeReason)
+		}
+	}
+	for typeReason, count := range counts {
+		Metrics.K8sEventsMetric.WithLabelValues(append(b.labels, typeReason[0], typeReason[1])...).Set(count)
+		b.exposed[typeReason] = true
+	}
+}
+
+// CheckK8sEventHeartbeats 定期清理过期事件，没有事件的 namespace 整体删除
+func CheckK8sEventHeartbeats() {
+	now := time.Now()
+
+	var empty []string
+	for key, buffer := range snapshotEventBuffers() {
+		buffer.mu.Lock()
+		buffer.refresh(now)
+		if len(buffer.events) == 0 {
+			empty = append(empty, key)
+		}
+		buffer.mu.Unlock()
+	}
+	if len(empty) == 0 {
+		return
+	}
+
+	eventBuffersMu.Lock()
+	defer eventBuffersMu.Unlock()
+	for _, key := range empty {
+		buffer, ok := eventBuffers[key]
+		if !ok {
+			continue
+		}
+		// 加锁后再确认一次，期间可能写入了新事件
+		buffer.mu.Lock()
+		if len(buffer.events) == 0 {
+			buffer.removed = true
+			delete(eventBuffers, key)
+		}
+		buffer.mu.Unlock()
+	}
+}
+
+// snapshotEventBuffers 复制当前的事件缓存列表，遍历时不持有 eventBuffersMu
+func snapshotEventBuffers() map[string]*eventBuffer {
+	eventBuffersMu.RLock()
+	defer eventBuffersMu.RUnlock()
+	buffers := make(map[string]*eventBuffer, len(eventBuffers))
+	for key, buffer := range eventBuffers {
+		buffers[key] = buffer
+	}
+	return buffers
+}
+
+// K8sEventsHandler 查询最近的 Kubernetes 事件（/api/k8s/events），按最后发生时间倒序
+// 参数：project（项目编码或名称）、namespace（归一化或原始名称）、type、reason、limit（默认 100）
+func K8sEventsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := defaultEventLimit
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "limit 参数无效")
+			return
+		}
+	}
+	if limit > maxEventLimit {
+		limit = maxEventLimit
+	}
+
+	// 项目参数可以是编码或显示名称
+	project := query.Get("project")
+	namespace := query.Get("namespace")
+	eventType := query.Get("type")
+	reason := query.Get("reason")
+
+	var events []K8sEventRecord
+	for _, buffer := range snapshotEventBuffers() {
+		projectName := getProjectName(buffer.project)
+		if project != "" && buffer.project != project && projectName != project {
+			continue
+		}
+		normalized, slot := buffer.namespaceLabels()
+		if namespace != "" && normalized != namespace && buffer.namespaceRaw != namespace {
+			continue
+		}
+
+		buffer.mu.Lock()
+		for _, record := range buffer.events {
+			if (eventType != "" && record.Type != eventType) || (reason != "" && record.Reason != reason) {
+				continue
+			}
+			event := *record
+			event.ProjectName = projectName
+			event.Namespace = normalized
+			event.Slot = slot
+			events = append(events, event)
+		}
+		buffer.mu.Unlock()
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].LastSeen.After(events[j].LastSeen) })
+	total := len(events)
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"total":  total,
+		"events": events,
+	}); err != nil {
+		log.Printf("响应失败: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package Handers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"monitor-server/Metrics"
+)
+
+func k8sEvent(uid, namespace, eventType, reason string, count int, lastSeen time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata":       map[string]interface{}{"uid": uid, "namespace": namespace, "name": uid},
+		"involvedObject": map[string]interface{}{"kind": "Pod", "name": "api-0", "namespace": namespace},
+		"type":           eventType,
+		"reason":         reason,
+		"message":        reason + " " + uid,
+		"count":          count,
+		"lastTimestamp":  lastSeen.UTC().Format(time.RFC3339),
+	}
+}
+
+func TestHandleK8sEventData(t *testing.T) {
+	const project = "test-events"
+	now := time.Now().Truncate(time.Second)
+
+	HandleK8sEventData([]interface{}{
+		k8sEvent("e1", "shop", "Warning", "BackOff", 3, now.Add(-time.Minute)),
+		k8sEvent("e2", "shop", "Warning", "BackOff", 2, now.Add(-time.Minute)),
+		k8sEvent("e3", "shop", "Normal", "Pulled", 1, now.Add(-time.Minute)),
+		k8sEvent("e4", "shop", "Warning", "Unhealthy", 1, now.Add(-2*time.Hour)), // 超过保留时长，丢弃
+		k8sEvent("e5", "", "Warning", "BackOff", 1, now),                         // 没有 namespace，跳过
+	}, project, now)
+
+	// 同一事件再次上报时更新次数；较旧的重复上报被忽略
+	HandleK8sEventData([]interface{}{
+		k8sEvent("e1", "shop", "Warning", "BackOff", 5, now),
+		k8sEvent("e3", "shop", "Normal", "Pulled", 9, now.Add(-2*time.Minute)),
+	}, project, now)
+
+	want := map[string]float64{
+		"namespace=shop,namespace_raw=shop,project=" + project + ",reason=BackOff,slot=,type=Warning": 7,
+		"namespace=shop,namespace_raw=shop,project=" + project + ",reason=Pulled,slot=,type=Normal":   1,
+	}
+	if got := projectSeries(t, Metrics.K8sEventsMetric, project); !equalSeries(got, want) {
+		t.Errorf("k8s_events = %v, want %v", got, want)
+	}
+
+	// 查询接口按最后发生时间倒序，支持按 type 过滤
+	req := httptest.NewRequest(http.MethodGet, "/api/k8s/events?project="+project+"&type=Warning&limit=1", nil)
+	rec := httptest.NewRecorder()
+	K8sEventsHandler(rec, req)
+
+	var resp struct {
+		Total  int              `json:"total"`
+		Events []K8sEventRecord `json:"events"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v (%s)", err, rec.Body.String())
+	}
+	if resp.Total != 2 || len(resp.Events) != 1 {
+		t.Fatalf("total = %d, events = %d; want 2, 1", resp.Total, len(resp.Events))
+	}
+	if e := resp.Events[0]; e.Reason != "BackOff" || e.Count != 5 || !e.LastSeen.Equal(now) {
+		t.Errorf("latest event = %+v, want e1 with count 5", e)
+	}
+}
+
+func TestK8sEventBufferLimit(t *testing.T) {
+	const project = "test-events-limit"
+	now := time.Now()
+
+	data := make([]interface{}, 0, maxEventsPerNamespace+10)
+	for i := 0; i < maxEventsPerNamespace+10; i++ {
+		data = append(data, k8sEvent("uid-"+strconv.Itoa(i), "batch", "Normal", "Created", 1, now))
+	}
+	HandleK8sEventData(data, project, now)
+
+	// 超出上限时淘汰最早写入的事件
+	want := map[string]float64{
+		"namespace=batch,namespace_raw=batch,project=" + project + ",reason=Created,slot=,type=Normal": maxEventsPerNamespace,
+	}
+	if got := projectSeries(t, Metrics.K8sEventsMetric, project); !equalSeries(got, want) {
+		t.Errorf("k8s_events = %v, want %v", got, want)
+	}
+}
+
+func TestK8sEventsHandlerInvalidLimit(t *testing.T) {
+	rec := httptest.NewRecorder()
+	K8sEventsHandler(rec, httptest.NewRequest(http.MethodGet, "/api/k8s/events?limit=abc", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestK8sEventLabelsFollowNamespaceRules(t *testing.T) {
+	const project = "test-events-rules"
+	now := time.Now().Truncate(time.Second)
+	old := currentRuntime().namespaceRules
+	t.Cleanup(func() { updateRuntime(func(s *runtimeSettings) { s.namespaceRules = old }) })
+
+	if err := SetNamespaceRules(nil); err != nil {
+		t.Fatal(err)
+	}
+	HandleK8sEventData([]interface{}{k8sEvent("r1", "shop-blue", "Warning", "BackOff", 2, now)}, project, now)
+
+	query := func(namespace string) []K8sEventRecord {
+		rec := httptest.NewRecorder()
+		K8sEventsHandler(rec, httptest.NewRequest(http.MethodGet, "/api/k8s/events?project="+project+"&namespace="+namespace, nil))
+		var resp struct {
+			Events []K8sEventRecord `json:"events"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v (%s)", err, rec.Body.String())
+		}
+		return resp.Events
+	}
+
+	steps := []struct {
+		name      string
+		rules     []NamespaceRule
+		want      map[string]float64
+		namespace string // 查询参数
+		wantSlot  string
+	}{
+		{
+			name:      "no rules",
+			want:      map[string]float64{"namespace=shop-blue,namespace_raw=shop-blue,project=" + project + ",reason=BackOff,slot=,type=Warning": 2},
+			namespace: "shop-blue",
+		},
+		{
+			name:      "rule added after buffer created",
+			rules:     []NamespaceRule{{Match: `^(.+)-(blue|green)$`, Replace: "$1", Slot: "$2"}},
+			want:      map[string]float64{"namespace=shop,namespace_raw=shop-blue,project=" + project + ",reason=BackOff,slot=blue,type=Warning": 2},
+			namespace: "shop",
+			wantSlot:  "blue",
+		},
+		{
+			name:      "rule removed again",
+			want:      map[string]float64{"namespace=shop-blue,namespace_raw=shop-blue,project=" + project + ",reason=BackOff,slot=,type=Warning": 2},
+			namespace: "shop-blue",
+		},
+	}
+	for _, step := range steps {
+		if err := SetNamespaceRules(step.rules); err != nil {
+			t.Fatal(err)
+		}
+		CheckK8sEventHeartbeats()
+		if got := projectSeries(t, Metrics.K8sEventsMetric, project); !equalSeries(got, step.want) {
+			t.Errorf("%s: k8s_events = %v, want %v", step.name, got, step.want)
+		}
+		events := query(step.namespace)
+		if len(events) != 1 || events[0].Namespace != step.namespace || events[0].Slot != step.wantSlot {
+			t.Errorf("%s: events = %+v, want namespace %s slot %q", step.name, events, step.namespace, step.wantSlot)
+		}
+	}
+}
+
+func TestK8sEventConcurrentIngest(t *testing.T) {
+	const project = "test-events-concurrent"
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			namespace := "ns-" + strconv.Itoa(i%4)
+			for j := 0; j < 50; j++ {
+				HandleK8sEventData([]interface{}{
+					k8sEvent(namespace+"-"+strconv.Itoa(i)+"-"+strconv.Itoa(j), namespace, "Normal", "Created", 1, now),
+				}, project, now)
+				if j%10 == 0 {
+					CheckK8sEventHeartbeats()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// 每个 namespace 两个 goroutine 各写入 50 个事件，不会因并发清理丢失
+	want := map[string]float64{}
+	for i := 0; i < 4; i++ {
+		namespace := "ns-" + strconv.Itoa(i)
+		want["namespace="+namespace+",namespace_raw="+namespace+",project="+project+",reason=Created,slot=,type=Normal"] = 100
+	}
+	if got := projectSeries(t, Metrics.K8sEventsMetric, project); !equalSeries(got, want) {
+		t.Errorf("k8s_events = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,355 @@
+package Handers
+
+import (
+	"fmt"
+	"math"
+	"monitor-server/Modles"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// 手工解析 OTLP protobuf，只解析指标相关字段，避免引入整套 OTLP/gRPC 依赖
+// 字段编号参考 opentelemetry-proto: collector/metrics/v1, metrics/v1, resource/v1, common/v1
+
+// walkProto 遍历 protobuf 消息的每个字段
+// bytes 类型字段通过 data 返回，varint/fixed32/fixed64 通过 scalar 返回
+func walkProto(b []byte, fn func(num protowire.Number, typ protowire.Type, data []byte, scalar uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var data []byte
+		var scalar uint64
+		switch typ {
+		case protowire.VarintType:
+			scalar, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			scalar, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			scalar = uint64(v)
+		case protowire.BytesType:
+			data, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := fn(num, typ, data, scalar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packedFixed64 解析 packed 或非 packed 的 repeated fixed64/double 字段
+func packedFixed64(typ protowire.Type, data []byte, scalar uint64) ([]uint64, error) {
+	if typ == protowire.Fixed64Type {
+		return []uint64{scalar}, nil
+	}
+	if typ != protowire.BytesType {
+		return nil, fmt.Errorf("repeated fixed64 字段类型错误: %v", typ)
+	}
+	values := make([]uint64, 0, len(data)/8)
+	for len(data) > 0 {
+		v, n := protowire.ConsumeFixed64(data)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		values = append(values, v)
+		data = data[n:]
+	}
+	return values, nil
+}
+
+// decodeOtlpMetricsProto 解析 ExportMetricsServiceRequest
+func decodeOtlpMetricsProto(b []byte) (*Modles.OtlpExportMetricsRequest, error) {
+	req := &Modles.OtlpExportMetricsRequest{}
+	err := walkProto(b, func(num protowire.Number, typ protowire.Type, data []byte, _ uint64) error {
+		if num == 1 && typ == protowire.BytesType {
+			rm, err := decodeOtlpResourceMetrics(data)
+			if err != nil {
+				return err
+			}
+			req.ResourceMetrics = append(req.ResourceMetrics, rm)
+		}
+		return nil
+	})
+	return req, err
+}
+
+func decodeOtlpResourceMetrics(b []byte) (Modles.OtlpResourceMetrics, error) {
+	var rm Modles.OtlpResourceMetrics
+	err := walkProto(b, func(num protowire.Number, typ protowire.Type, data []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1: // resource
+			return walkProto(data, func(num protowire.Number, typ protowire.Type, data []byte, _ uint64) error {
+				if num == 1 && typ == protowire.BytesType {
+					kv, err := decodeOtlpKeyValue(data)
+					if err != nil {
+						return err
+					}
+					rm.Resource.Attributes = append(rm.Resource.Attributes, kv)
+				}
+				return nil
+			})
+		case 2: // scope_metrics
+			sm, err := decodeOtlpScopeMetrics(data)
+			if err != nil {
+				return err
+			}
+			rm.ScopeMetrics = append(rm.ScopeMetrics, sm)
+		}
+		return nil
+	})
+	return rm, err
+}
+
+func decodeOtlpScopeMetrics(b []byte) (Modles.OtlpScopeMetrics, error) {
+	var sm Modles.OtlpScopeMetrics
+	err := walkProto(b, func(num protowire.Number, typ protowire.Type, data []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1: // scope
+			return walkProto(data, func(num protowire.Number, typ protowire.Type, data []byte, _ uint64) error {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					sm.Scope.Name = string(data)
+				case num == 2 && typ == protowire.BytesType:
+					sm.Scope.Version = string(data)
+				}
+				return nil
+			})
+		case 2: // metrics
+			m, err := decodeOtlpMetric(data)
+			if err != nil {
+				return err
+			}
+			sm.Metrics = append(sm.Metrics, m)
+		}
+		return nil
+	})
+	return sm, err
+}
+
+func decodeOtlpMetric(b []byte) (Modles.OtlpMetric, error) {
+	var m Modles.OtlpMetric
+	err := walkProto(b, func(num protowire.Number, typ protowire.Type, data []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			m.Name = string(data)
+		case 2:
+			m.Description = string(data)
+		case 3:
+			m.Unit = string(data)
+		case 5: // gauge
+			m.Gauge = &Modles.OtlpGauge{}
+			return walkProto(data, func(num protowire.Number, typ protowire.Type, data []byte, _ uint64) error {
+				if num == 1 && typ == protowire.BytesType {
+					p, err := decodeOtlpNumberDataPoint(data)
+					if err != nil {
+						return err
+					}
+					m.Gauge.DataPoints = append(m.Gauge.DataPoints, p)
+				}
+				return nil
+			})
+		case 7: // sum
+			m.Sum = &Modles.OtlpSum{}
+			return walkProto(data, func(num protowire.Number, typ protowire.Type, data []byte, scalar uint64) error {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					p, err := decodeOtlpNumberDataPoint(data)
+					if err != nil {
+						return err
+					}
+					m.Sum.DataPoints = append(m.Sum.DataPoints, p)
+				case num == 2 && typ == protowire.VarintType:
+					m.Sum.AggregationTemporality = int(scalar)
+				case num == 3 && typ == protowire.VarintType:
+					m.Sum.IsMonotonic = scalar != 0
+				}
+				return nil
+			})
+		case 9: // histogram
+			m.Histogram = &Modles.OtlpHistogram{}
+			return walkProto(data, func(num protowire.Number, typ protowire.Type, data []byte, scalar uint64) error {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					p, err := decodeOtlpHistogramDataPoint(data)
+					if err != nil {
+						return err
+					}
+					m.Histogram.DataPoints = append(m.Histogram.DataPoints, p)
+				case num == 2 && typ == protowire.VarintType:
+					m.Histogram.AggregationTemporality = int(scalar)
+				}
+				return nil
+			})
+		case 11: // summary
+			m.Summary = &Modles.OtlpSummary{}
+			return walkProto(data, func(num protowire.Number, typ protowire.Type, data []byte, _ uint64) error {
+				if num == 1 && typ == protowire.BytesType {
+					p, err := decodeOtlpSummaryDataPoint(data)
+					if err != nil {
+						return err
+					}
+					m.Summary.DataPoints = append(m.Summary.DataPoints, p)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return m, err
+}
+
+func decodeOtlpNumberDataPoint(b []byte) (Modles.OtlpNumberDataPoint, error) {
+	var p Modles.OtlpNumberDataPoint
+	err := walkProto(b, func(num protowire.Number, typ protowire.Type, data []byte, scalar uint64) error {
+		switch {
+		case num == 7 && typ == protowire.BytesType:
+			kv, err := decodeOtlpKeyValue(data)
+			if err != nil {
+				return err
+			}
+			p.Attributes = append(p.Attributes, kv)
+		case num == 3 && typ == protowire.Fixed64Type:
+			p.TimeUnixNano = Modles.OtlpUint64(scalar)
+		case num == 4 && typ == protowire.Fixed64Type:
+			v := math.Float64frombits(scalar)
+			p.AsDouble = &v
+		case num == 6 && typ == protowire.Fixed64Type:
+			v := Modles.OtlpInt64(int64(scalar))
+			p.AsInt = &v
+		}
+		return nil
+	})
+	return p, err
+}
+
+func decodeOtlpHistogramDataPoint(b []byte) (Modles.OtlpHistogramDataPoint, error) {
+	var p Modles.OtlpHistogramDataPoint
+	err := walkProto(b, func(num protowire.Number, typ protowire.Type, data []byte, scalar uint64) error {
+		switch {
+		case num == 9 && typ == protowire.BytesType:
+			kv, err := decodeOtlpKeyValue(data)
+			if err != nil {
+				return err
+			}
+			p.Attributes = append(p.Attributes, kv)
+		case num == 3 && typ == protowire.Fixed64Type:
+			p.TimeUnixNano = Modles.OtlpUint64(scalar)
+		case num == 4 && typ == protowire.Fixed64Type:
+			p.Count = Modles.OtlpUint64(scalar)
+		case num == 5 && typ == protowire.Fixed64Type:
+			v := math.Float64frombits(scalar)
+			p.Sum = &v
+		case num == 6:
+			values, err := packedFixed64(typ, data, scalar)
+			if err != nil {
+				return err
+			}
+			for _, v := range values {
+				p.BucketCounts = append(p.BucketCounts, Modles.OtlpUint64(v))
+			}
+		case num == 7:
+			values, err := packedFixed64(typ, data, scalar)
+			if err != nil {
+				return err
+			}
+			for _, v := range values {
+				p.ExplicitBounds = append(p.ExplicitBounds, math.Float64frombits(v))
+			}
+		}
+		return nil
+	})
+	return p, err
+}
+
+func decodeOtlpSummaryDataPoint(b []byte) (Modles.OtlpSummaryDataPoint, error) {
+	var p Modles.OtlpSummaryDataPoint
+	err := walkProto(b, func(num protowire.Number, typ protowire.Type, data []byte, scalar uint64) error {
+		switch {
+		case num == 7 && typ == protowire.BytesType:
+			kv, err := decodeOtlpKeyValue(data)
+			if err != nil {
+				return err
+			}
+			p.Attributes = append(p.Attributes, kv)
+		case num == 3 && typ == protowire.Fixed64Type:
+			p.TimeUnixNano = Modles.OtlpUint64(scalar)
+		case num == 4 && typ == protowire.Fixed64Type:
+			p.Count = Modles.OtlpUint64(scalar)
+		case num == 5 && typ == protowire.Fixed64Type:
+			p.Sum = math.Float64frombits(scalar)
+		case num == 6 && typ == protowire.BytesType:
+			var q Modles.OtlpQuantileValue
+			err := walkProto(data, func(num protowire.Number, typ protowire.Type, _ []byte, scalar uint64) error {
+				if typ == protowire.Fixed64Type {
+					switch num {
+					case 1:
+						q.Quantile = math.Float64frombits(scalar)
+					case 2:
+						q.Value = math.Float64frombits(scalar)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			p.QuantileValues = append(p.QuantileValues, q)
+		}
+		return nil
+	})
+	return p, err
+}
+
+func decodeOtlpKeyValue(b []byte) (Modles.OtlpKeyValue, error) {
+	var kv Modles.OtlpKeyValue
+	err := walkProto(b, func(num protowire.Number, typ protowire.Type, data []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			kv.Key = string(data)
+		case 2:
+			return walkProto(data, func(num protowire.Number, typ protowire.Type, data []byte, scalar uint64) error {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					s := string(data)
+					kv.Value.StringValue = &s
+				case num == 2 && typ == protowire.VarintType:
+					v := scalar != 0
+					kv.Value.BoolValue = &v
+				case num == 3 && typ == protowire.VarintType:
+					v := Modles.OtlpInt64(int64(scalar))
+					kv.Value.IntValue = &v
+				case num == 4 && typ == protowire.Fixed64Type:
+					v := math.Float64frombits(scalar)
+					kv.Value.DoubleValue = &v
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return kv, err
+}
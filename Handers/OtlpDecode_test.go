@@ -0,0 +1,220 @@
+package Handers
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"monitor-server/Modles"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func pbBytes(num protowire.Number, fields ...[]byte) []byte {
+	var data []byte
+	for _, f := range fields {
+		data = append(data, f...)
+	}
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+	return protowire.AppendBytes(b, data)
+}
+
+func pbString(num protowire.Number, s string) []byte {
+	return pbBytes(num, []byte(s))
+}
+
+func pbVarint(num protowire.Number, v uint64) []byte {
+	b := protowire.AppendTag(nil, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func pbFixed64(num protowire.Number, v uint64) []byte {
+	b := protowire.AppendTag(nil, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, v)
+}
+
+func pbDouble(num protowire.Number, v float64) []byte {
+	return pbFixed64(num, math.Float64bits(v))
+}
+
+func pbPackedDoubles(num protowire.Number, values ...float64) []byte {
+	var data []byte
+	for _, v := range values {
+		data = protowire.AppendFixed64(data, math.Float64bits(v))
+	}
+	return pbBytes(num, data)
+}
+
+// pbRequest 将单个 metric 包装成 ExportMetricsServiceRequest
+func pbRequest(metric []byte) []byte {
+	return pbBytes(1,
+		pbBytes(1, pbBytes(1, pbString(1, "service.name"), pbBytes(2, pbString(1, "api")))),
+		pbBytes(2, pbBytes(1, pbString(1, "scope"), pbString(2, "1.0")), metric),
+	)
+}
+
+func TestDecodeOtlpMetricsProto(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	i := func(v int64) *Modles.OtlpInt64 { n := Modles.OtlpInt64(v); return &n }
+	s := func(v string) *string { return &v }
+
+	tests := []struct {
+		name   string
+		metric []byte
+		want   Modles.OtlpMetric
+	}{
+		{
+			name: "gauge double",
+			metric: pbBytes(2, pbString(1, "cpu"), pbString(3, "1"),
+				pbBytes(5, pbBytes(1,
+					pbBytes(7, pbString(1, "core"), pbBytes(2, pbString(1, "0"))),
+					pbFixed64(3, 1000),
+					pbDouble(4, 0.5),
+				)),
+			),
+			want: Modles.OtlpMetric{Name: "cpu", Unit: "1", Gauge: &Modles.OtlpGauge{DataPoints: []Modles.OtlpNumberDataPoint{{
+				Attributes:   []Modles.OtlpKeyValue{{Key: "core", Value: Modles.OtlpAnyValue{StringValue: s("0")}}},
+				TimeUnixNano: 1000,
+				AsDouble:     f(0.5),
+			}}}},
+		},
+		{
+			name: "monotonic sum int",
+			metric: pbBytes(2, pbString(1, "requests"),
+				pbBytes(7,
+					pbBytes(1, pbFixed64(3, 2000), pbFixed64(6, 42)),
+					pbVarint(2, 2),
+					pbVarint(3, 1),
+				),
+			),
+			want: Modles.OtlpMetric{Name: "requests", Sum: &Modles.OtlpSum{
+				DataPoints:             []Modles.OtlpNumberDataPoint{{TimeUnixNano: 2000, AsInt: i(42)}},
+				AggregationTemporality: 2,
+				IsMonotonic:            true,
+			}},
+		},
+		{
+			name: "histogram packed",
+			metric: pbBytes(2, pbString(1, "latency"),
+				pbBytes(9,
+					pbBytes(1,
+						pbFixed64(4, 3),
+						pbDouble(5, 1.5),
+						pbBytes(6, protowire.AppendFixed64(protowire.AppendFixed64(nil, 1), 2)),
+						pbPackedDoubles(7, 0.1),
+					),
+					pbVarint(2, 1),
+				),
+			),
+			want: Modles.OtlpMetric{Name: "latency", Histogram: &Modles.OtlpHistogram{
+				DataPoints: []Modles.OtlpHistogramDataPoint{{
+					Count:          3,
+					Sum:            f(1.5),
+					BucketCounts:   []Modles.OtlpUint64{1, 2},
+					ExplicitBounds: []float64{0.1},
+				}},
+				AggregationTemporality: 1,
+			}},
+		},
+		{
+			name: "histogram unpacked",
+			metric: pbBytes(2, pbString(1, "latency"),
+				pbBytes(9, pbBytes(1,
+					pbFixed64(6, 4), pbFixed64(6, 5),
+					pbDouble(7, 0.2),
+				)),
+			),
+			want: Modles.OtlpMetric{Name: "latency", Histogram: &Modles.OtlpHistogram{
+				DataPoints: []Modles.OtlpHistogramDataPoint{{
+					BucketCounts:   []Modles.OtlpUint64{4, 5},
+					ExplicitBounds: []float64{0.2},
+				}},
+			}},
+		},
+		{
+			name: "summary",
+			metric: pbBytes(2, pbString(1, "rt"),
+				pbBytes(11, pbBytes(1,
+					pbFixed64(4, 10),
+					pbDouble(5, 2.5),
+					pbBytes(6, pbDouble(1, 0.99), pbDouble(2, 0.3)),
+				)),
+			),
+			want: Modles.OtlpMetric{Name: "rt", Summary: &Modles.OtlpSummary{DataPoints: []Modles.OtlpSummaryDataPoint{{
+				Count:          10,
+				Sum:            2.5,
+				QuantileValues: []Modles.OtlpQuantileValue{{Quantile: 0.99, Value: 0.3}},
+			}}}},
+		},
+		{
+			name: "attribute types",
+			metric: pbBytes(2, pbString(1, "up"),
+				pbBytes(5, pbBytes(1,
+					pbBytes(7, pbString(1, "ok"), pbBytes(2, pbVarint(2, 1))),
+					pbBytes(7, pbString(1, "n"), pbBytes(2, pbVarint(3, 7))),
+					pbBytes(7, pbString(1, "r"), pbBytes(2, pbDouble(4, 0.25))),
+				)),
+			),
+			want: Modles.OtlpMetric{Name: "up", Gauge: &Modles.OtlpGauge{DataPoints: []Modles.OtlpNumberDataPoint{{
+				Attributes: []Modles.OtlpKeyValue{
+					{Key: "ok", Value: Modles.OtlpAnyValue{BoolValue: func() *bool { b := true; return &b }()}},
+					{Key: "n", Value: Modles.OtlpAnyValue{IntValue: i(7)}},
+					{Key: "r", Value: Modles.OtlpAnyValue{DoubleValue: f(0.25)}},
+				},
+			}}}},
+		},
+		{
+			name:   "unknown fields ignored",
+			metric: pbBytes(2, pbString(1, "x"), pbVarint(100, 1), pbString(101, "y")),
+			want:   Modles.OtlpMetric{Name: "x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := decodeOtlpMetricsProto(pbRequest(tt.metric))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(req.ResourceMetrics) != 1 || len(req.ResourceMetrics[0].ScopeMetrics) != 1 {
+				t.Fatalf("unexpected structure: %+v", req)
+			}
+			rm := req.ResourceMetrics[0]
+			if got := rm.Resource.Attributes; len(got) != 1 || got[0].Key != "service.name" || got[0].Value.String() != "api" {
+				t.Errorf("resource attributes = %+v", got)
+			}
+			sm := rm.ScopeMetrics[0]
+			if sm.Scope.Name != "scope" || sm.Scope.Version != "1.0" {
+				t.Errorf("scope = %+v", sm.Scope)
+			}
+			if len(sm.Metrics) != 1 {
+				t.Fatalf("metrics = %d, want 1", len(sm.Metrics))
+			}
+			if !reflect.DeepEqual(sm.Metrics[0], tt.want) {
+				t.Errorf("metric = %+v, want %+v", sm.Metrics[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeOtlpMetricsProtoInvalid(t *testing.T) {
+	valid := pbRequest(pbBytes(2, pbString(1, "cpu")))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated", valid[:len(valid)-1]},
+		{"bad tag", []byte{0x00}},
+		{"truncated varint", []byte{0x08, 0xff}},
+		{"bad packed length", pbRequest(pbBytes(2, pbBytes(9, pbBytes(1, pbBytes(6, []byte{1, 2, 3})))))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeOtlpMetricsProto(tt.data); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
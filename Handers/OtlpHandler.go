@@ -0,0 +1,329 @@
+package Handers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"log"
+	"monitor-server/Metrics"
+	"monitor-server/Modles"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// OTLP 资源属性中用于识别项目的键（按顺序查找）
+var otlpProjectAttributes = []string{"project", "monitor.project", "service.namespace"}
+
+// otlpShards OTLP 数据按项目分片加锁
+var otlpShards shardedMutex
+
+// OtlpConfig OTLP 接收配置
+type OtlpConfig struct {
+	Token string `yaml:"token"` // collector 的访问令牌，为空时只接受项目独立的 otlpToken
+}
+
+// SetOtlpConfig 设置 OTLP 接收配置（线程安全）
+func SetOtlpConfig(cfg OtlpConfig) {
+	updateRuntime(func(s *runtimeSettings) {
+		s.otlpToken = cfg.Token
+	})
+}
+
+func getOtlpToken() string {
+	return currentRuntime().otlpToken
+}
+
+// otlpTokenAuthorized 校验 OTLP 请求携带的访问令牌
+// collector 无法对数据做 AES 加密，通过请求头传递独立的令牌（otlp.token 或项目的 otlpToken），
+// 不接受 AES 密钥，避免明文传输的请求泄露解密全部上报数据的密钥
+// 返回令牌所属的项目编码，使用全局令牌时为空
+func otlpTokenAuthorized(r *http.Request) (string, bool) {
+	provided := []byte(r.Header.Get("X-Monitor-Token"))
+	if len(provided) == 0 {
+		provided = []byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	}
+	if len(provided) == 0 {
+		return "", false
+	}
+	if token := getOtlpToken(); token != "" && subtle.ConstantTimeCompare(provided, []byte(token)) == 1 {
+		return "", true
+	}
+	for code, token := range projectOtlpTokens() {
+		if subtle.ConstantTimeCompare(provided, token) == 1 {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// OtlpMetricsHandler 接收 OTLP/HTTP 指标（/v1/metrics），支持 protobuf 和 JSON 编码
+func OtlpMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "仅支持 POST 请求")
+		return
+	}
+	tokenProject, ok := otlpTokenAuthorized(r)
+	if !ok {
+		log.Printf("[OTLP] 访问令牌校验失败")
+		writeJSONError(w, http.StatusUnauthorized, "访问令牌无效")
+		return
+	}
+
+	maxBodySize := getMaxRequestBodySize()
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "读取请求体失败")
+		return
+	}
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			log.Printf("关闭请求体失败: %v", err)
+		}
+	}(r.Body)
+	if int64(len(body)) >= maxBodySize {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, "请求体过大")
+		return
+	}
+
+	// collector 默认开启 gzip 压缩
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		body, err = Decompress(body)
+		if err != nil {
+			log.Printf("[OTLP] 解压失败: %v", err)
+			writeJSONError(w, http.StatusBadRequest, "数据解压失败")
+			return
+		}
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if idx := strings.Index(contentType, ";"); idx != -1 {
+		contentType = contentType[:idx]
+	}
+
+	var req *Modles.OtlpExportMetricsRequest
+	switch strings.TrimSpace(contentType) {
+	case "application/x-protobuf":
+		req, err = decodeOtlpMetricsProto(body)
+	case "application/json":
+		req = &Modles.OtlpExportMetricsRequest{}
+		err = json.Unmarshal(body, req)
+	default:
+		writeJSONError(w, http.StatusUnsupportedMediaType, "仅支持 application/x-protobuf 和 application/json")
+		return
+	}
+	if err != nil {
+		log.Printf("[OTLP] 数据解析失败: %v", err)
+		writeJSONError(w, http.StatusBadRequest, "数据格式错误")
+		return
+	}
+
+	// 按项目拆分，整个请求作为一个任务提交到 worker pool，启用 rejectWhenFull 且队列满时整体返回 503 由 collector 重试
+	type otlpResource struct {
+		metrics          Modles.OtlpResourceMetrics
+		project, service string
+	}
+	var resources []otlpResource
+	for _, rm := range req.ResourceMetrics {
+		resourceMetrics := rm
+		project, service := otlpResourceIdentity(resourceMetrics.Resource.Attributes)
+		if !isValidProject(project) {
+			log.Printf("[OTLP] 资源缺少有效的 project 属性: %q，跳过", project)
+			continue
+		}
+		// 设置了 otlpToken 的项目只接受该令牌，使用项目令牌时只能上报该项目
+		reason := ""
+		if p, ok := lookupProject(project); (ok && p.OtlpToken != "" && tokenProject != project) || (tokenProject != "" && tokenProject != project) {
+			reason = "key_mismatch"
+		} else if _, r := admitProject(project, "otlp"); r != "" {
+			reason = r
+		}
+		if reason != "" {
+			Metrics.IngestRejectedPayloads.WithLabelValues("otlp", reason).Inc()
+			log.Printf("[OTLP] 拒绝上报: project=%s reason=%s", project, reason)
+			continue
+		}
+		resources = append(resources, otlpResource{resourceMetrics, project, service})
+	}
+	task := func() {
+		for _, res := range resources {
+			mu := otlpShards.getShard(res.project)
+			mu.Lock()
+			HandleOtlpResourceMetrics(res.metrics, res.project, res.service)
+			mu.Unlock()
+		}
+	}
+	if len(resources) > 0 && !submitTask("otlp", task) {
+		Metrics.IngestRejectedPayloads.WithLabelValues("otlp", "queue_full").Inc()
+		log.Printf("[OTLP] 任务队列已满，拒绝上报")
+		writeJSONError(w, http.StatusServiceUnavailable, "服务繁忙，请稍后重试")
+		return
+	}
+
+	// ExportMetricsServiceResponse 为空消息
+	if contentType == "application/x-protobuf" {
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("{}")); err != nil {
+		log.Printf("响应失败: %v", err)
+	}
+}
+
+// otlpResourceIdentity 从资源属性中提取 project 和 service
+func otlpResourceIdentity(attributes []Modles.OtlpKeyValue) (string, string) {
+	values := make(map[string]string, len(attributes))
+	for _, kv := range attributes {
+		values[kv.Key] = kv.Value.String()
+	}
+	project := ""
+	for _, key := range otlpProjectAttributes {
+		if v := values[key]; v != "" {
+			project = v
+			break
+		}
+	}
+	service := values["service.name"]
+	if service == "" {
+		service = "unknown"
+	}
+	return project, service
+}
+
+// sanitizeOtlpName 将 OTLP 名称转换为合法的 Prometheus 名称
+func sanitizeOtlpName(name string) string {
+	var b strings.Builder
+	for i, c := range name {
+		isAlpha := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+		isDigit := c >= '0' && c <= '9'
+		switch {
+		case isAlpha || (isDigit && i > 0):
+			b.WriteRune(c)
+		case isDigit:
+			b.WriteString("_")
+			b.WriteRune(c)
+		default:
+			b.WriteString("_")
+		}
+	}
+	return b.String()
+}
+
+// otlpLabels 生成标签名和标签值，project/service 优先，其余属性按名称排序
+func otlpLabels(project, service string, attributes []Modles.OtlpKeyValue) ([]string, []string) {
+	names := []string{"project", "service"}
+	values := []string{project, service}
+	// project_code/project_name 在输出时由 project 展开，属性不能占用
+	seen := map[string]bool{"project": true, "service": true, "project_code": true, "project_name": true}
+
+	extra := make(map[string]string, len(attributes))
+	for _, kv := range attributes {
+		name := sanitizeOtlpName(kv.Key)
+		if name == "" || seen[name] || strings.HasPrefix(name, "__") {
+			continue
+		}
+		seen[name] = true
+		extra[name] = kv.Value.String()
+	}
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		names = append(names, k)
+		values = append(values, extra[k])
+	}
+	return names, values
+}
+
+// otlpSeriesKey 生成时间序列唯一键
+func otlpSeriesKey(name string, labelNames, labelValues []string) string {
+	parts := make([]string, 0, len(labelNames)+1)
+	parts = append(parts, name)
+	for i := range labelNames {
+		parts = append(parts, labelNames[i]+"="+labelValues[i])
+	}
+	return JoinLabels(parts...)
+}
+
+// HandleOtlpResourceMetrics 将一个资源下的 OTLP 指标写入自定义 registry
+func HandleOtlpResourceMetrics(rm Modles.OtlpResourceMetrics, project, service string) {
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			name := "otlp_" + sanitizeOtlpName(m.Name)
+			help := m.Description
+			if help == "" {
+				help = "OTLP 指标 " + m.Name
+			}
+
+			switch {
+			case m.Gauge != nil:
+				for _, p := range m.Gauge.DataPoints {
+					labelNames, labelValues := otlpLabels(project, service, p.Attributes)
+					key := otlpSeriesKey(name, labelNames, labelValues)
+					if Metrics.OtlpMetrics.SetValue(key, name, help, Metrics.OtlpKindGauge, labelNames, labelValues, p.Value(), false) {
+						UpdateOtlpMetricWithTimestamp(key)
+					}
+				}
+
+			case m.Sum != nil:
+				kind := Metrics.OtlpKindGauge
+				sumName := name
+				if m.Sum.IsMonotonic {
+					kind = Metrics.OtlpKindCounter
+					if !strings.HasSuffix(sumName, "_total") {
+						sumName += "_total"
+					}
+				}
+				accumulate := m.Sum.AggregationTemporality == Modles.OtlpTemporalityDelta
+				for _, p := range m.Sum.DataPoints {
+					labelNames, labelValues := otlpLabels(project, service, p.Attributes)
+					key := otlpSeriesKey(sumName, labelNames, labelValues)
+					if Metrics.OtlpMetrics.SetValue(key, sumName, help, kind, labelNames, labelValues, p.Value(), accumulate) {
+						UpdateOtlpMetricWithTimestamp(key)
+					}
+				}
+
+			case m.Histogram != nil:
+				accumulate := m.Histogram.AggregationTemporality == Modles.OtlpTemporalityDelta
+				for _, p := range m.Histogram.DataPoints {
+					// OTLP 的 bucket_counts 为各区间计数，Prometheus 需要累计计数
+					buckets := make(map[float64]uint64, len(p.ExplicitBounds))
+					var cumulative uint64
+					for i, bound := range p.ExplicitBounds {
+						if i < len(p.BucketCounts) {
+							cumulative += uint64(p.BucketCounts[i])
+						}
+						buckets[bound] = cumulative
+					}
+					sum := 0.0
+					if p.Sum != nil {
+						sum = *p.Sum
+					}
+					labelNames, labelValues := otlpLabels(project, service, p.Attributes)
+					key := otlpSeriesKey(name, labelNames, labelValues)
+					if Metrics.OtlpMetrics.SetHistogram(key, name, help, labelNames, labelValues, uint64(p.Count), sum, buckets, accumulate) {
+						UpdateOtlpMetricWithTimestamp(key)
+					}
+				}
+
+			case m.Summary != nil:
+				for _, p := range m.Summary.DataPoints {
+					quantiles := make(map[float64]float64, len(p.QuantileValues))
+					for _, q := range p.QuantileValues {
+						quantiles[q.Quantile] = q.Value
+					}
+					labelNames, labelValues := otlpLabels(project, service, p.Attributes)
+					key := otlpSeriesKey(name, labelNames, labelValues)
+					if Metrics.OtlpMetrics.SetSummary(key, name, help, labelNames, labelValues, uint64(p.Count), p.Sum, quantiles) {
+						UpdateOtlpMetricWithTimestamp(key)
+					}
+				}
+			}
+		}
+	}
+}
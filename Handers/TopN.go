@@ -0,0 +1,249 @@
+package Handers
+
+import (
+	"log"
+	"monitor-server/Metrics"
+	"monitor-server/Modles"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// 默认每个项目保留的排行数量
+const defaultTopN = 50
+
+// 排行之外的数据合并到该标签值
+const otherLabel = "other"
+
+// SetTopN 设置日志统计每个项目保留的排行数量，<= 0 时使用默认值
+func SetTopN(n int) {
+	if n <= 0 {
+		n = defaultTopN
+	}
+	updateRuntime(func(s *runtimeSettings) {
+		s.topN = n
+	})
+}
+
+func getTopN() int {
+	return currentRuntime().topN
+}
+
+// topNSource 一种日志统计数据
+// 每次上报视为该项目的完整排行：不在本次排行中的旧时间序列会被删除
+type topNSource struct {
+	source string
+	metric *Metrics.GaugeVec
+
+	mu     sync.Mutex
+	series map[string][]string // project -> 当前输出的标签值
+	// Timestamp 项目最后一次上报的采样时间，key 为 project
+	Timestamp sync.Map
+}
+
+func newTopNSource(source string, metric *Metrics.GaugeVec) *topNSource {
+	return &topNSource{source: source, metric: metric, series: map[string][]string{}}
+}
+
+var (
+	esIpSource      = newTopNSource("esIp", Metrics.ClientIpRequestCountMetric)
+	esCountrySource = newTopNSource("esCountry", Metrics.CountryRequestCountMetric)
+	esUrlSource     = newTopNSource("esUrl", Metrics.UrlRequestCountMetric)
+)
+
+// update 用本次排行替换项目的全部时间序列，返回本次排行的采样时间，被丢弃时返回 false
+// agentTs 为本批数据中最新的 agent 时间戳
+func (s *topNSource) update(project string, counts map[string]float64, agentTs float64, receivedAt time.Time) (time.Time, bool) {
+	if !acceptSample(s.source, project, project, agentTs, receivedAt) {
+		return time.Time{}, false
+	}
+	ts := sampleTime(agentTs, receivedAt)
+
+	type entry struct {
+		key   string
+		count float64
+	}
+	entries := make([]entry, 0, len(counts))
+	for key, count := range counts {
+		if key == otherLabel {
+			continue
+		}
+		entries = append(entries, entry{key, count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].key < entries[j].key
+	})
+
+	values := map[string]float64{}
+	limit := getTopN()
+	for i, e := range entries {
+		if i < limit {
+			values[e.key] = e.count
+		} else {
+			values[otherLabel] += e.count
+		}
+	}
+	if count, ok := counts[otherLabel]; ok {
+		values[otherLabel] += count
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range s.series[project] {
+		if _, ok := values[key]; !ok {
+			s.metric.DeleteLabelValues(key, project)
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for key, value := range values {
+		s.metric.WithLabelValues(key, project).Set(value)
+		keys = append(keys, key)
+	}
+	s.series[project] = keys
+	s.Timestamp.Store(project, ts)
+	return ts, true
+}
+
+// expire 删除超时未上报项目的全部时间序列
+func (s *topNSource) expire(currentTime time.Time) {
+	s.Timestamp.Range(func(key, value interface{}) bool {
+		project, ok := key.(string)
+		if !ok {
+			log.Printf("[%s] 标签格式不正确，跳过", s.source)
+			return true
+		}
+		timestamp, ok := value.(time.Time)
+		if !ok {
+			log.Printf("[%s] 时间戳格式不正确，跳过", s.source)
+			return true
+		}
+		if isExpired(project, s.source, currentTime, timestamp) {
+			s.mu.Lock()
+			for _, k := range s.series[project] {
+				s.metric.DeleteLabelValues(k, project)
+			}
+			delete(s.series, project)
+			s.mu.Unlock()
+			s.Timestamp.Delete(project)
+		}
+		return true
+	})
+}
+
+// CheckTopNHeartbeats 定期清理超时的日志统计数据
+func CheckTopNHeartbeats() {
+	currentTime := time.Now()
+	esIpSource.expire(currentTime)
+	esCountrySource.expire(currentTime)
+	esUrlSource.expire(currentTime)
+}
+
+// latestTimestamp 取本批数据中最新的 agent 时间戳（兼容秒和毫秒混用）
+func latestTimestamp(ts, latest float64, receivedAt time.Time) float64 {
+	if latest <= 0 || agentTime(ts, receivedAt).After(agentTime(latest, receivedAt)) {
+		return ts
+	}
+	return latest
+}
+
+// batchSamples 本批排行整体被接受，全部数据项使用同一采样时间
+func batchSamples(items []interface{}, t time.Time) []Modles.Sample {
+	var samples []Modles.Sample
+	for _, item := range items {
+		samples = appendSample(samples, item, t)
+	}
+	return samples
+}
+
+// labelOrOther 空值计入 other
+func labelOrOther(value string) string {
+	if value == "" {
+		return otherLabel
+	}
+	return value
+}
+
+// 处理客户端 IP 排行
+func HandleEsIpData(data []interface{}, project string, receivedAt time.Time) []Modles.Sample {
+	counts := map[string]float64{}
+	var latest float64
+	var items []interface{}
+	for _, item := range data {
+		var ipData Modles.EsIpSource
+		if err := mapstructure.Decode(item, &ipData); err != nil {
+			log.Printf("解析客户端 IP 数据失败: %v", err)
+			continue
+		}
+		counts[labelOrOther(ipData.ClientIp)] += float64(ipData.IpCount)
+		latest = latestTimestamp(ipData.Timestamp, latest, receivedAt)
+		items = append(items, item)
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	ts, ok := esIpSource.update(project, counts, latest, receivedAt)
+	if !ok {
+		return nil
+	}
+	return batchSamples(items, ts)
+}
+
+// 处理国家/地区排行
+func HandleEsCountryData(data []interface{}, project string, receivedAt time.Time) []Modles.Sample {
+	counts := map[string]float64{}
+	var latest float64
+	var items []interface{}
+	for _, item := range data {
+		var countryData Modles.EsCountrySource
+		if err := mapstructure.Decode(item, &countryData); err != nil {
+			log.Printf("解析国家/地区数据失败: %v", err)
+			continue
+		}
+		counts[labelOrOther(countryData.CountryName)] += float64(countryData.CountryCount)
+		latest = latestTimestamp(countryData.Timestamp, latest, receivedAt)
+		items = append(items, item)
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	ts, ok := esCountrySource.update(project, counts, latest, receivedAt)
+	if !ok {
+		return nil
+	}
+	return batchSamples(items, ts)
+}
+
+// 处理 URL 排行，去掉查询参数后合并
+func HandleEsUrlData(data []interface{}, project string, receivedAt time.Time) []Modles.Sample {
+	counts := map[string]float64{}
+	var latest float64
+	var items []interface{}
+	for _, item := range data {
+		var urlData Modles.EsUrlSource
+		if err := mapstructure.Decode(item, &urlData); err != nil {
+			log.Printf("解析 URL 数据失败: %v", err)
+			continue
+		}
+		url := urlData.RequestUrl
+		if i := strings.IndexByte(url, '?'); i >= 0 {
+			url = url[:i]
+		}
+		counts[labelOrOther(url)] += float64(urlData.UrlCount)
+		latest = latestTimestamp(urlData.Timestamp, latest, receivedAt)
+		items = append(items, item)
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	ts, ok := esUrlSource.update(project, counts, latest, receivedAt)
+	if !ok {
+		return nil
+	}
+	return batchSamples(items, ts)
+}
@@ -0,0 +1,111 @@
+package Handers
+
+import (
+	"testing"
+	"time"
+
+	"monitor-server/Metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTopNSourceUpdate(t *testing.T) {
+	SetTopN(2)
+	defer SetTopN(0)
+
+	tests := []struct {
+		name   string
+		counts map[string]float64
+		want   map[string]float64
+	}{
+		{
+			name:   "within limit",
+			counts: map[string]float64{"a": 5, "b": 3},
+			want:   map[string]float64{"a": 5, "b": 3},
+		},
+		{
+			name:   "rest merged into other",
+			counts: map[string]float64{"a": 5, "b": 3, "c": 2, "d": 1},
+			want:   map[string]float64{"a": 5, "b": 3, "other": 3},
+		},
+		{
+			name:   "reported other is added",
+			counts: map[string]float64{"a": 5, "b": 3, "c": 2, "other": 10},
+			want:   map[string]float64{"a": 5, "b": 3, "other": 12},
+		},
+		{
+			name:   "reported other does not take a rank",
+			counts: map[string]float64{"other": 100, "a": 1},
+			want:   map[string]float64{"a": 1, "other": 100},
+		},
+		{
+			name:   "ties ordered by key",
+			counts: map[string]float64{"c": 1, "b": 1, "a": 1},
+			want:   map[string]float64{"a": 1, "b": 1, "other": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metric := Metrics.NewGaugeVec(prometheus.GaugeOpts{Name: "test_top"}, []string{"key", "project"})
+			s := newTopNSource("test", metric)
+			s.update("p", tt.counts, 0, time.Now())
+
+			got := map[string]float64{}
+			for key, value := range collectSeries(t, metric) {
+				got[key] = value
+			}
+			want := map[string]float64{}
+			for key, value := range tt.want {
+				want["key="+key+",project=p"] = value
+			}
+			if !equalSeries(got, want) {
+				t.Errorf("series = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestTopNSourceReplacesRanking(t *testing.T) {
+	SetTopN(2)
+	defer SetTopN(0)
+
+	metric := Metrics.NewGaugeVec(prometheus.GaugeOpts{Name: "test_top"}, []string{"key", "project"})
+	s := newTopNSource("test", metric)
+	now := time.Now()
+
+	s.update("p", map[string]float64{"a": 5, "b": 3, "c": 1}, 0, now)
+	s.update("q", map[string]float64{"a": 1}, 0, now)
+	// 新的排行中没有 b 和 other，旧序列被删除；其他项目不受影响
+	s.update("p", map[string]float64{"a": 6, "d": 4}, 0, now.Add(time.Second))
+
+	want := map[string]float64{"key=a,project=p": 6, "key=d,project=p": 4, "key=a,project=q": 1}
+	if got := collectSeries(t, metric); !equalSeries(got, want) {
+		t.Errorf("series = %v, want %v", got, want)
+	}
+
+	// 项目超时后删除全部序列
+	s.Timestamp.Store("p", now.Add(-24*time.Hour))
+	s.expire(now)
+	want = map[string]float64{"key=a,project=q": 1}
+	if got := collectSeries(t, metric); !equalSeries(got, want) {
+		t.Errorf("series after expiry = %v, want %v", got, want)
+	}
+}
+
+func TestHandleEsUrlDataMergesQuery(t *testing.T) {
+	const project = "test-top-url"
+	HandleEsUrlData([]interface{}{
+		map[string]interface{}{"request_url": "/api/list?page=1", "request_url_count": 3},
+		map[string]interface{}{"request_url": "/api/list?page=2", "request_url_count": 4},
+		map[string]interface{}{"request_url": "", "request_url_count": 2},
+	}, project, time.Now())
+
+	want := map[string]float64{
+		"project=" + project + ",url=/api/list": 7,
+		"project=" + project + ",url=other":     2,
+	}
+	if got := projectSeries(t, Metrics.UrlRequestCountMetric, project); !equalSeries(got, want) {
+		t.Errorf("url_request_count = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+package Handers
+
+import (
+	"monitor-server/Metrics"
+	"monitor-server/Modles"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 每个服务最多输出的后端和路由数量，超出的按累计请求数从小到大丢弃
+const (
+	maxBackendsPerService = 20
+	maxRoutesPerService   = 50
+)
+
+// trafficBreakdown 服务当前输出的后端和路由
+type trafficBreakdown struct {
+	backends [][2]string // target, version
+	routes   []string
+}
+
+// trafficBreakdowns key 与 TrafficSwitchingTimestamp 相同
+var trafficBreakdowns sync.Map
+
+// handleTrafficSwitchingBreakdown 输出按后端、路由拆分的统计
+// 每次上报视为服务的完整列表，不再上报的后端和路由会被删除
+func handleTrafficSwitchingBreakdown(metricLabel, service, project string, backends []Modles.TrafficSwitchingBackend, routes []Modles.TrafficSwitchingRoute, ts time.Time) {
+	current := trafficBreakdown{}
+
+	// 后端
+	sort.SliceStable(backends, func(i, j int) bool { return backends[i].TotalRequests > backends[j].TotalRequests })
+	increases := map[[2]string]float64{}
+	var totalIncrease, totalWeight float64
+	for _, backend := range backends {
+		if backend.Target == "" {
+			continue
+		}
+		if len(current.backends) >= maxBackendsPerService {
+			Metrics.IngestDroppedSamples.WithLabelValues("trafficSwitching", "backend_limit").Inc()
+			continue
+		}
+		key := [2]string{backend.Target, backend.Version}
+		if _, ok := increases[key]; ok {
+			continue
+		}
+		labels := []string{service, project, backend.Target, backend.Version}
+
+		Metrics.TrafficSwitchingBackendWeight.WithLabelValues(labels...).Set(backend.Weight)
+		Metrics.TrafficSwitchingBackendAvgLatencyMs.WithLabelValues(labels...).Set(backend.AvgLatencyMs)
+		requests := backendRequestsDeriver.observe(backend.TotalRequests, ts, labels...)
+		errors := backendErrorsDeriver.observe(backend.TotalErrors, ts, labels...)
+		if requests > 0 {
+			Metrics.TrafficSwitchingBackendSuccessRatio5m.WithLabelValues(labels...).Set(1 - errors/requests)
+		} else {
+			Metrics.TrafficSwitchingBackendSuccessRatio5m.DeleteLabelValues(labels...)
+		}
+
+		increases[key] = requests
+		totalIncrease += requests
+		if backend.Weight > 0 {
+			totalWeight += backend.Weight
+		}
+		current.backends = append(current.backends, key)
+	}
+
+	// 权重偏差：实际流量占比 - 配置权重占比
+	for _, backend := range backends {
+		key := [2]string{backend.Target, backend.Version}
+		requests, ok := increases[key]
+		if !ok {
+			continue
+		}
+		labels := []string{service, project, backend.Target, backend.Version}
+		if totalIncrease > 0 && totalWeight > 0 {
+			weight := backend.Weight
+			if weight < 0 {
+				weight = 0
+			}
+			Metrics.TrafficSwitchingWeightDrift.WithLabelValues(labels...).Set(requests/totalIncrease - weight/totalWeight)
+		} else {
+			Metrics.TrafficSwitchingWeightDrift.DeleteLabelValues(labels...)
+		}
+		delete(increases, key)
+	}
+
+	// 路由，去掉查询参数
+	sort.SliceStable(routes, func(i, j int) bool { return routes[i].TotalRequests > routes[j].TotalRequests })
+	for _, route := range routes {
+		name := route.Route
+		if i := strings.IndexByte(name, '?'); i >= 0 {
+			name = name[:i]
+		}
+		if name == "" || containsString(current.routes, name) {
+			continue
+		}
+		if len(current.routes) >= maxRoutesPerService {
+			Metrics.IngestDroppedSamples.WithLabelValues("trafficSwitching", "route_limit").Inc()
+			continue
+		}
+		routeRequestsDeriver.observe(route.TotalRequests, ts, service, project, name)
+		routeErrorsDeriver.observe(route.TotalErrors, ts, service, project, name)
+		Metrics.TrafficSwitchingRouteAvgLatencyMs.WithLabelValues(service, project, name).Set(route.AvgLatencyMs)
+		current.routes = append(current.routes, name)
+	}
+
+	if previous, ok := trafficBreakdowns.Swap(metricLabel, current); ok {
+		old := previous.(trafficBreakdown)
+		for _, key := range old.backends {
+			if !containsBackend(current.backends, key) {
+				deleteTrafficSwitchingBackend(service, project, key)
+			}
+		}
+		for _, route := range old.routes {
+			if !containsString(current.routes, route) {
+				deleteTrafficSwitchingRoute(service, project, route)
+			}
+		}
+	}
+}
+
+func containsBackend(list [][2]string, key [2]string) bool {
+	for _, item := range list {
+		if item == key {
+			return true
+		}
+	}
+	return false
+}
+
+func deleteTrafficSwitchingBackend(service, project string, key [2]string) {
+	labels := []string{service, project, key[0], key[1]}
+	Metrics.TrafficSwitchingBackendWeight.DeleteLabelValues(labels...)
+	Metrics.TrafficSwitchingBackendAvgLatencyMs.DeleteLabelValues(labels...)
+	Metrics.TrafficSwitchingBackendSuccessRatio5m.DeleteLabelValues(labels...)
+	Metrics.TrafficSwitchingWeightDrift.DeleteLabelValues(labels...)
+	backendRequestsDeriver.forget(labels...)
+	backendErrorsDeriver.forget(labels...)
+}
+
+func deleteTrafficSwitchingRoute(service, project, route string) {
+	Metrics.TrafficSwitchingRouteAvgLatencyMs.DeleteLabelValues(service, project, route)
+	routeRequestsDeriver.forget(service, project, route)
+	routeErrorsDeriver.forget(service, project, route)
+}
+
+// deleteTrafficSwitchingBreakdown 删除服务的全部后端和路由指标（过期时调用）
+func deleteTrafficSwitchingBreakdown(metricLabel, service, project string) {
+	if previous, ok := trafficBreakdowns.LoadAndDelete(metricLabel); ok {
+		old := previous.(trafficBreakdown)
+		for _, key := range old.backends {
+			deleteTrafficSwitchingBackend(service, project, key)
+		}
+		for _, route := range old.routes {
+			deleteTrafficSwitchingRoute(service, project, route)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package Handers
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"monitor-server/Metrics"
+	"monitor-server/Modles"
+)
+
+func TestHandleTrafficSwitchingBreakdownWeightDrift(t *testing.T) {
+	const project = "test-breakdown"
+	metricLabel := JoinLabels("checkout", project)
+	defer deleteTrafficSwitchingBreakdown(metricLabel, "checkout", project)
+	start := time.Now()
+
+	backends := func(v1Requests, v2Requests, v2Errors float64) []Modles.TrafficSwitchingBackend {
+		return []Modles.TrafficSwitchingBackend{
+			{Target: "10.0.0.1", Version: "v1", Weight: 80, TotalRequests: v1Requests},
+			{Target: "10.0.0.2", Version: "v2", Weight: 20, TotalRequests: v2Requests, TotalErrors: v2Errors},
+		}
+	}
+	handleTrafficSwitchingBreakdown(metricLabel, "checkout", project, backends(1000, 200, 0), nil, start)
+
+	// 第一次上报只作为基准，没有增量时不输出偏差
+	if got := projectSeries(t, Metrics.TrafficSwitchingWeightDrift, project); len(got) != 0 {
+		t.Errorf("weight drift on first report = %v, want empty", got)
+	}
+
+	// 两个后端各增加 50 个请求，配置权重为 80/20
+	handleTrafficSwitchingBreakdown(metricLabel, "checkout", project, backends(1050, 250, 5), nil, start.Add(10*time.Second))
+
+	drift := projectSeries(t, Metrics.TrafficSwitchingWeightDrift, project)
+	for key, want := range map[string]float64{
+		"project=" + project + ",service=checkout,target=10.0.0.1,version=v1": -0.3,
+		"project=" + project + ",service=checkout,target=10.0.0.2,version=v2": 0.3,
+	} {
+		if got, ok := drift[key]; !ok || math.Abs(got-want) > 1e-9 {
+			t.Errorf("weight drift %s = %v, want %v (all: %v)", key, got, want, drift)
+		}
+	}
+
+	success := projectSeries(t, Metrics.TrafficSwitchingBackendSuccessRatio5m, project)
+	if got := success["project="+project+",service=checkout,target=10.0.0.2,version=v2"]; math.Abs(got-0.9) > 1e-9 {
+		t.Errorf("v2 success ratio = %v, want 0.9", got)
+	}
+}
+
+func TestHandleTrafficSwitchingBreakdownRoutes(t *testing.T) {
+	const project = "test-breakdown-routes"
+	metricLabel := JoinLabels("checkout", project)
+	defer deleteTrafficSwitchingBreakdown(metricLabel, "checkout", project)
+	now := time.Now()
+
+	handleTrafficSwitchingBreakdown(metricLabel, "checkout", project, []Modles.TrafficSwitchingBackend{
+		{Target: "10.0.0.1", Version: "v1", TotalRequests: 10},
+		{Target: "10.0.0.1", Version: "v1", TotalRequests: 5}, // 重复的后端只取请求数较大的一条
+		{Version: "v2", TotalRequests: 5},                     // 缺少 target，跳过
+	}, []Modles.TrafficSwitchingRoute{
+		{Route: "/api/orders?page=1", TotalRequests: 30, AvgLatencyMs: 12},
+		{Route: "/api/orders?page=2", TotalRequests: 20, AvgLatencyMs: 99}, // 去掉查询参数后重复
+		{Route: "/api/users/:id", TotalRequests: 10, AvgLatencyMs: 8},
+	}, now)
+
+	if got := projectSeries(t, Metrics.TrafficSwitchingBackendWeight, project); len(got) != 1 {
+		t.Errorf("backend weight = %v, want 1 series", got)
+	}
+	wantRoutes := map[string]float64{
+		"project=" + project + ",route=/api/orders,service=checkout":    12,
+		"project=" + project + ",route=/api/users/:id,service=checkout": 8,
+	}
+	if got := projectSeries(t, Metrics.TrafficSwitchingRouteAvgLatencyMs, project); !equalSeries(got, wantRoutes) {
+		t.Errorf("route latency = %v, want %v", got, wantRoutes)
+	}
+
+	// 不再上报的路由和后端被删除
+	handleTrafficSwitchingBreakdown(metricLabel, "checkout", project, nil, []Modles.TrafficSwitchingRoute{
+		{Route: "/api/users/:id", TotalRequests: 11, AvgLatencyMs: 9},
+	}, now.Add(time.Second))
+
+	if got := projectSeries(t, Metrics.TrafficSwitchingBackendWeight, project); len(got) != 0 {
+		t.Errorf("backend weight after removal = %v, want empty", got)
+	}
+	wantRoutes = map[string]float64{"project=" + project + ",route=/api/users/:id,service=checkout": 9}
+	if got := projectSeries(t, Metrics.TrafficSwitchingRouteAvgLatencyMs, project); !equalSeries(got, wantRoutes) {
+		t.Errorf("route latency after removal = %v, want %v", got, wantRoutes)
+	}
+}
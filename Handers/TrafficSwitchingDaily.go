@@ -0,0 +1,163 @@
+package Handers
+
+import (
+	"log"
+	"monitor-server/Daily"
+	"monitor-server/Metrics"
+	"monitor-server/Modles"
+	"sync"
+	"time"
+)
+
+// 今日统计字段，顺序与 todayValues、yesterdayMetrics 一致
+var todayFields = []string{"requests", "success", "errors", "canceled", "status_2xx", "status_3xx", "status_4xx", "status_5xx"}
+
+// 跨过日界后超过该时间仍未归零，视为当天没有请求，直接按日界切换
+const dayRolloverGrace = time.Hour
+
+// 日界前后该时间内的归零视为每日归零，其他时间的归零视为 agent 重启
+const dayResetTolerance = 10 * time.Minute
+
+// 今日统计状态保留时长，超过后（服务已下线）删除
+const todayStateTTL = 48 * time.Hour
+
+func todayValues(ts Modles.TrafficSwitchingSource) []float64 {
+	return []float64{ts.TodayRequests, ts.TodaySuccess, ts.TodayErrors, ts.TodayCanceled,
+		ts.TodayStatus2xx, ts.TodayStatus3xx, ts.TodayStatus4xx, ts.TodayStatus5xx}
+}
+
+func yesterdayMetrics() []*Metrics.GaugeVec {
+	return []*Metrics.GaugeVec{
+		Metrics.TrafficSwitchingYesterdayRequests, Metrics.TrafficSwitchingYesterdaySuccess,
+		Metrics.TrafficSwitchingYesterdayErrors, Metrics.TrafficSwitchingYesterdayCanceled,
+		Metrics.TrafficSwitchingYesterdayStatus2xx, Metrics.TrafficSwitchingYesterdayStatus3xx,
+		Metrics.TrafficSwitchingYesterdayStatus4xx, Metrics.TrafficSwitchingYesterdayStatus5xx,
+	}
+}
+
+// todayState 服务当前未结束的一天
+type todayState struct {
+	day    string    // 当前统计所属日期（项目时区），归零或日界切换后才更新
+	values []float64 // 最近一次上报的今日统计
+	at     time.Time
+}
+
+// todayStates key 与 TrafficSwitchingTimestamp 相同
+// 服务过期时不删除，避免短暂离线期间错过归零
+var todayStates sync.Map
+
+// handleTrafficSwitchingToday 检测今日统计归零，将归零前的最终值写入 yesterday_* 和每日历史
+func handleTrafficSwitchingToday(metricLabel, service, project string, values []float64, ts time.Time) {
+	loc := Daily.Location(project, getProjectName(project))
+	local := ts.In(loc)
+	day := local.Format("2006-01-02")
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	previous, ok := todayStates.Load(metricLabel)
+	if !ok {
+		// 重启后第一次上报
+		// 日界后不久重启时 agent 可能尚未归零，前一天还没有写入每日历史时当前统计仍属于前一天，
+		// 否则归零时会按今天的日期保存，第二天的记录又会覆盖它
+		stateDay := day
+		records, err := queryDailyRecords(project, service, local.AddDate(0, 0, -1))
+		if err == nil && len(records) == 0 && ts.Sub(midnight) < dayRolloverGrace {
+			stateDay = local.AddDate(0, 0, -1).Format("2006-01-02")
+			records, err = queryDailyRecords(project, service, local.AddDate(0, 0, -2))
+		}
+		todayStates.Store(metricLabel, todayState{day: stateDay, values: values, at: ts})
+
+		// 从每日历史恢复 stateDay 前一天的统计
+		if err != nil {
+			log.Printf("[TrafficSwitching] 读取每日历史失败: %v", err)
+		} else if len(records) > 0 {
+			setYesterday(service, project, records[len(records)-1].Values)
+		}
+		return
+	}
+	prev := previous.(todayState)
+
+	reset := false
+	for i := range values {
+		if values[i] < prev.values[i] {
+			reset = true
+			break
+		}
+	}
+
+	nextMidnight := midnight.AddDate(0, 0, 1)
+	nearBoundary := ts.Sub(midnight) <= dayResetTolerance || nextMidnight.Sub(ts) <= dayResetTolerance
+
+	switch {
+	case reset && (day != prev.day || nearBoundary):
+		// 每日归零，日界前略早归零时新的一天按下一天计
+		newDay := day
+		if day == prev.day {
+			newDay = local.Add(dayResetTolerance).Format("2006-01-02")
+		}
+		closeDay(service, project, prev, ts)
+		todayStates.Store(metricLabel, todayState{day: newDay, values: values, at: ts})
+	case reset:
+		log.Printf("[TrafficSwitching] 今日统计在日中归零（agent 重启？）-> service=%s project=%s", service, project)
+		todayStates.Store(metricLabel, todayState{day: prev.day, values: values, at: ts})
+	case day > prev.day && ts.Sub(midnight) >= dayRolloverGrace:
+		// 跨过日界很久仍未归零，按日界切换
+		closeDay(service, project, prev, ts)
+		todayStates.Store(metricLabel, todayState{day: day, values: values, at: ts})
+	default:
+		// 日界后尚未归零的数据仍属于前一天
+		todayStates.Store(metricLabel, todayState{day: prev.day, values: values, at: ts})
+	}
+}
+
+// queryDailyRecords 读取某一天的每日历史
+func queryDailyRecords(project, service string, date time.Time) ([]Daily.Record, error) {
+	day := date.Format("2006-01-02")
+	records, err := Daily.Query(project, service, day, day)
+	if name := getProjectName(project); err == nil && len(records) == 0 && name != project {
+		// 旧版本按显示名称保存
+		records, err = Daily.Query(name, service, day, day)
+	}
+	return records, err
+}
+
+// closeDay 将一天的最终值写入 yesterday_* 和每日历史
+func closeDay(service, project string, state todayState, ts time.Time) {
+	final := make(map[string]float64, len(todayFields))
+	for i, field := range todayFields {
+		final[field] = state.values[i]
+	}
+	setYesterday(service, project, final)
+	if err := Daily.Save(Daily.Record{
+		Date:       state.day,
+		Project:    project,
+		Service:    service,
+		Values:     final,
+		RecordedAt: ts,
+	}); err != nil {
+		log.Printf("[TrafficSwitching] 写入每日历史失败: %v", err)
+	}
+	log.Printf("[TrafficSwitching] 今日统计切换 -> service=%s project=%s date=%s requests=%v", service, project, state.day, final["requests"])
+}
+
+func setYesterday(service, project string, values map[string]float64) {
+	for i, metric := range yesterdayMetrics() {
+		metric.WithLabelValues(service, project).Set(values[todayFields[i]])
+	}
+}
+
+// deleteYesterday 删除昨日统计（服务过期时调用）
+func deleteYesterday(service, project string) {
+	for _, metric := range yesterdayMetrics() {
+		metric.DeleteLabelValues(service, project)
+	}
+}
+
+// pruneTodayStates 删除长时间没有上报的服务状态
+func pruneTodayStates(currentTime time.Time) {
+	todayStates.Range(func(key, value interface{}) bool {
+		if state, ok := value.(todayState); ok && currentTime.Sub(state.at) > todayStateTTL {
+			todayStates.Delete(key)
+		}
+		return true
+	})
+}
@@ -0,0 +1,145 @@
+package Handers
+
+import (
+	"testing"
+	"time"
+
+	"monitor-server/Daily"
+	"monitor-server/Metrics"
+)
+
+// startDaily 使用临时目录和 UTC 日界
+func startDaily(t *testing.T) {
+	t.Helper()
+	if err := Daily.Start(Daily.Config{Dir: t.TempDir(), Timezone: "UTC"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func todayRequests(requests float64) []float64 {
+	values := make([]float64, len(todayFields))
+	values[0] = requests
+	return values
+}
+
+func TestHandleTrafficSwitchingToday(t *testing.T) {
+	startDaily(t)
+	day1 := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	type report struct {
+		at       time.Duration // 相对 day1 零点
+		requests float64
+	}
+	tests := []struct {
+		name          string
+		reports       []report
+		wantYesterday float64            // -1 表示没有 yesterday_* 指标
+		wantDaily     map[string]float64 // 日期 -> requests
+	}{
+		{
+			name:          "reset after midnight",
+			reports:       []report{{23*time.Hour + 50*time.Minute, 100}, {24*time.Hour + time.Minute, 2}},
+			wantYesterday: 100,
+			wantDaily:     map[string]float64{"2024-03-01": 100},
+		},
+		{
+			name: "reset shortly before midnight",
+			reports: []report{
+				{23*time.Hour + 40*time.Minute, 100},
+				{23*time.Hour + 55*time.Minute, 1}, // 日界前 5 分钟归零，新的一天从这里开始
+				{24*time.Hour + 30*time.Minute, 5},
+			},
+			wantYesterday: 100,
+			wantDaily:     map[string]float64{"2024-03-01": 100},
+		},
+		{
+			name:          "reset mid-day is an agent restart",
+			reports:       []report{{12 * time.Hour, 100}, {12*time.Hour + time.Minute, 3}, {13 * time.Hour, 50}},
+			wantYesterday: -1,
+			wantDaily:     map[string]float64{},
+		},
+		{
+			name: "late reset within grace belongs to previous day",
+			reports: []report{
+				{23 * time.Hour, 100},
+				{24*time.Hour + 30*time.Minute, 110}, // 日界后尚未归零，仍属于 3 月 1 日
+				{24*time.Hour + 40*time.Minute, 3},
+			},
+			wantYesterday: 110,
+			wantDaily:     map[string]float64{"2024-03-01": 110},
+		},
+		{
+			name:          "no reset past grace rolls over at midnight",
+			reports:       []report{{20 * time.Hour, 100}, {25*time.Hour + 30*time.Minute, 120}},
+			wantYesterday: 100,
+			wantDaily:     map[string]float64{"2024-03-01": 100},
+		},
+		{
+			name: "two rollovers",
+			reports: []report{
+				{23 * time.Hour, 100},
+				{24*time.Hour + time.Minute, 1},
+				{47 * time.Hour, 80},
+				{48*time.Hour + time.Minute, 2},
+			},
+			wantYesterday: 80,
+			wantDaily:     map[string]float64{"2024-03-01": 100, "2024-03-02": 80},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const project = "test-daily"
+			service := tt.name
+			metricLabel := JoinLabels(service, project)
+			defer todayStates.Delete(metricLabel)
+			defer deleteYesterday(service, project)
+
+			for _, r := range tt.reports {
+				handleTrafficSwitchingToday(metricLabel, service, project, todayRequests(r.requests), day1.Add(r.at))
+			}
+
+			yesterday := projectSeries(t, Metrics.TrafficSwitchingYesterdayRequests, project)
+			got, ok := yesterday["project="+project+",service="+service]
+			if tt.wantYesterday < 0 {
+				if ok {
+					t.Errorf("yesterday requests = %v, want none", got)
+				}
+			} else if got != tt.wantYesterday {
+				t.Errorf("yesterday requests = %v, want %v", got, tt.wantYesterday)
+			}
+
+			records, err := Daily.Query(project, service, "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			daily := map[string]float64{}
+			for _, record := range records {
+				daily[record.Date] = record.Values["requests"]
+			}
+			if !equalSeries(daily, tt.wantDaily) {
+				t.Errorf("daily records = %v, want %v", daily, tt.wantDaily)
+			}
+		})
+	}
+}
+
+func TestHandleTrafficSwitchingTodayRestoresYesterday(t *testing.T) {
+	startDaily(t)
+	const project, service = "test-daily-restore", "api"
+	metricLabel := JoinLabels(service, project)
+	defer todayStates.Delete(metricLabel)
+	defer deleteYesterday(service, project)
+
+	if err := Daily.Save(Daily.Record{Date: "2024-03-01", Project: project, Service: service, Values: map[string]float64{"requests": 42}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启后第一次上报，从每日历史恢复昨日统计
+	handleTrafficSwitchingToday(metricLabel, service, project, todayRequests(7), time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC))
+
+	yesterday := projectSeries(t, Metrics.TrafficSwitchingYesterdayRequests, project)
+	if got := yesterday["project="+project+",service="+service]; got != 42 {
+		t.Errorf("yesterday requests = %v, want 42", got)
+	}
+}
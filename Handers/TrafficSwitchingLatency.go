@@ -0,0 +1,82 @@
+package Handers
+
+import (
+	"log"
+	"math"
+	"monitor-server/Metrics"
+	"monitor-server/Modles"
+	"sync"
+)
+
+// trafficLatencyTargets 记录每个服务输出过的上游目标，key 与 TrafficSwitchingTimestamp 相同
+var trafficLatencyTargets sync.Map
+
+// handleTrafficSwitchingLatency 输出延迟直方图和分位数
+// 每次上报视为服务的完整目标列表，不再上报的目标会被删除
+func handleTrafficSwitchingLatency(metricLabel, service, project string, latencies []Modles.TrafficSwitchingLatency) {
+	targets := make([]string, 0, len(latencies))
+	for _, latency := range latencies {
+		if latency.Target == "" {
+			continue
+		}
+		labels := []string{service, project, latency.Target}
+
+		buckets, ok := latencyBuckets(latency)
+		if !ok {
+			log.Printf("[TrafficSwitching] service=%s target=%s 直方图桶不合法，跳过", service, latency.Target)
+			continue
+		}
+		count := uint64(latency.Count)
+		sum := latency.SumMs / 1000
+		Metrics.TrafficSwitchingLatencySeconds.SetHistogram(count, sum, buckets, labels...)
+
+		quantiles := map[float64]float64{}
+		for q, v := range map[float64]*float64{0.5: latency.P50Ms, 0.9: latency.P90Ms, 0.99: latency.P99Ms} {
+			if v != nil {
+				quantiles[q] = *v / 1000
+			}
+		}
+		if len(quantiles) > 0 {
+			Metrics.TrafficSwitchingLatencyQuantileSeconds.SetSummary(count, sum, quantiles, labels...)
+		} else {
+			Metrics.TrafficSwitchingLatencyQuantileSeconds.Delete(labels...)
+		}
+		targets = append(targets, latency.Target)
+	}
+
+	if previous, ok := trafficLatencyTargets.Swap(metricLabel, targets); ok {
+		for _, target := range previous.([]string) {
+			if !containsString(targets, target) {
+				deleteTrafficSwitchingLatency(service, project, target)
+			}
+		}
+	}
+}
+
+// latencyBuckets 将毫秒桶转换为秒，桶必须按 le 升序且计数不递减
+func latencyBuckets(latency Modles.TrafficSwitchingLatency) (map[float64]uint64, bool) {
+	buckets := make(map[float64]uint64, len(latency.Buckets))
+	lastLe, lastCount := math.Inf(-1), 0.0
+	for _, bucket := range latency.Buckets {
+		if bucket.Le <= lastLe || bucket.Count < lastCount || bucket.Count > latency.Count {
+			return nil, false
+		}
+		buckets[bucket.Le/1000] = uint64(bucket.Count)
+		lastLe, lastCount = bucket.Le, bucket.Count
+	}
+	return buckets, true
+}
+
+func deleteTrafficSwitchingLatency(service, project, target string) {
+	Metrics.TrafficSwitchingLatencySeconds.Delete(service, project, target)
+	Metrics.TrafficSwitchingLatencyQuantileSeconds.Delete(service, project, target)
+}
+
+// deleteTrafficSwitchingLatencies 删除服务的全部延迟指标（过期时调用）
+func deleteTrafficSwitchingLatencies(metricLabel, service, project string) {
+	if previous, ok := trafficLatencyTargets.LoadAndDelete(metricLabel); ok {
+		for _, target := range previous.([]string) {
+			deleteTrafficSwitchingLatency(service, project, target)
+		}
+	}
+}
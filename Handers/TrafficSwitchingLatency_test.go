@@ -0,0 +1,102 @@
+package Handers
+
+import (
+	"reflect"
+	"testing"
+
+	"monitor-server/Metrics"
+	"monitor-server/Modles"
+)
+
+func TestLatencyBuckets(t *testing.T) {
+	bucket := func(le, count float64) Modles.TrafficSwitchingBucket {
+		return Modles.TrafficSwitchingBucket{Le: le, Count: count}
+	}
+
+	tests := []struct {
+		name    string
+		latency Modles.TrafficSwitchingLatency
+		want    map[float64]uint64
+		wantOk  bool
+	}{
+		{
+			name:    "milliseconds converted to seconds",
+			latency: Modles.TrafficSwitchingLatency{Count: 10, Buckets: []Modles.TrafficSwitchingBucket{bucket(50, 4), bucket(100, 4), bucket(500, 9)}},
+			want:    map[float64]uint64{0.05: 4, 0.1: 4, 0.5: 9},
+			wantOk:  true,
+		},
+		{
+			name:    "no buckets",
+			latency: Modles.TrafficSwitchingLatency{Count: 3},
+			want:    map[float64]uint64{},
+			wantOk:  true,
+		},
+		{
+			name:    "le not ascending",
+			latency: Modles.TrafficSwitchingLatency{Count: 10, Buckets: []Modles.TrafficSwitchingBucket{bucket(100, 1), bucket(50, 2)}},
+		},
+		{
+			name:    "duplicate le",
+			latency: Modles.TrafficSwitchingLatency{Count: 10, Buckets: []Modles.TrafficSwitchingBucket{bucket(100, 1), bucket(100, 2)}},
+		},
+		{
+			name:    "count decreasing",
+			latency: Modles.TrafficSwitchingLatency{Count: 10, Buckets: []Modles.TrafficSwitchingBucket{bucket(50, 5), bucket(100, 3)}},
+		},
+		{
+			name:    "bucket above total",
+			latency: Modles.TrafficSwitchingLatency{Count: 10, Buckets: []Modles.TrafficSwitchingBucket{bucket(50, 11)}},
+		},
+		{
+			name:    "negative count",
+			latency: Modles.TrafficSwitchingLatency{Count: 10, Buckets: []Modles.TrafficSwitchingBucket{bucket(50, -1)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := latencyBuckets(tt.latency)
+			if ok != tt.wantOk {
+				t.Fatalf("latencyBuckets() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("latencyBuckets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleTrafficSwitchingLatencyTargets(t *testing.T) {
+	const project = "test-latency"
+	metricLabel := JoinLabels("checkout", project)
+	p99 := 250.0
+
+	handleTrafficSwitchingLatency(metricLabel, "checkout", project, []Modles.TrafficSwitchingLatency{
+		{Target: "v1", Count: 2, SumMs: 300, Buckets: []Modles.TrafficSwitchingBucket{{Le: 100, Count: 1}}, P99Ms: &p99},
+		{Target: "v2", Count: 1, SumMs: 50},
+		{Target: "v3", Count: 1, Buckets: []Modles.TrafficSwitchingBucket{{Le: 100, Count: 5}}}, // 桶不合法，跳过
+	})
+	if got := projectSeries(t, Metrics.TrafficSwitchingLatencySeconds, project); len(got) != 2 {
+		t.Errorf("latency histograms = %v, want v1 and v2", got)
+	}
+	// 只有上报了分位数的目标输出 summary
+	if got := projectSeries(t, Metrics.TrafficSwitchingLatencyQuantileSeconds, project); len(got) != 1 {
+		t.Errorf("latency summaries = %v, want v1 only", got)
+	}
+
+	// v1 不再上报后删除
+	handleTrafficSwitchingLatency(metricLabel, "checkout", project, []Modles.TrafficSwitchingLatency{
+		{Target: "v2", Count: 3, SumMs: 90},
+	})
+	if got := projectSeries(t, Metrics.TrafficSwitchingLatencySeconds, project); len(got) != 1 {
+		t.Errorf("latency histograms after v1 removed = %v, want v2 only", got)
+	}
+	if got := projectSeries(t, Metrics.TrafficSwitchingLatencyQuantileSeconds, project); len(got) != 0 {
+		t.Errorf("latency summaries after v1 removed = %v, want empty", got)
+	}
+
+	deleteTrafficSwitchingLatencies(metricLabel, "checkout", project)
+	if got := projectSeries(t, Metrics.TrafficSwitchingLatencySeconds, project); len(got) != 0 {
+		t.Errorf("latency histograms after expiry = %v, want empty", got)
+	}
+}
@@ -8,16 +8,55 @@ import (
 )
 
 // 用来存储时间戳和指标名称的 sync.Map
+// key 格式: namespace|:|namespace_raw|:|slot|:|podName|:|container|:|controllerName|:|project
 var ContainerTimestamp sync.Map
 
+// containerStatus 容器状态类标签，值变化时需要删除旧的时间序列
+type containerStatus struct {
+	waitingReason     string
+	terminationReason string
+	phase             string
+	node              string
+}
+
+// containerStatuses 记录每个容器当前输出的状态，key 与 ContainerTimestamp 相同
+var containerStatuses sync.Map
+
+// updateContainerStatus 输出容器状态，状态变化时先删除旧的时间序列
+func updateContainerStatus(metricLabel string, labels []string, status containerStatus) {
+	if previous, loaded := containerStatuses.Swap(metricLabel, status); loaded {
+		if old, ok := previous.(containerStatus); ok && old != status {
+			deleteContainerStatus(labels, old)
+		}
+	}
+
+	if status.waitingReason != "" {
+		Metrics.ContainerWaitingReasonMetric.WithLabelValues(append(labels, status.waitingReason)...).Set(1)
+	}
+	if status.terminationReason != "" {
+		Metrics.ContainerLastTerminationReasonMetric.WithLabelValues(append(labels, status.terminationReason)...).Set(1)
+	}
+	if status.phase != "" || status.node != "" {
+		Metrics.ContainerPodInfoMetric.WithLabelValues(append(labels, status.phase, status.node)...).Set(1)
+	}
+}
+
+// deleteContainerStatus 删除指定状态的时间序列
+func deleteContainerStatus(labels []string, status containerStatus) {
+	Metrics.ContainerWaitingReasonMetric.DeleteLabelValues(append(labels, status.waitingReason)...)
+	Metrics.ContainerLastTerminationReasonMetric.DeleteLabelValues(append(labels, status.terminationReason)...)
+	Metrics.ContainerPodInfoMetric.DeleteLabelValues(append(labels, status.phase, status.node)...)
+}
+
 // 反解析 label 字符串并更新数据
-func parseContainerLabel(metricLabel string) (string, string, string, string, string) {
+// 返回 namespace, namespace_raw, slot, podName, container, controllerName, project
+func parseContainerLabel(metricLabel string) (string, string, string, string, string, string, string) {
 	parts := SplitLabels(metricLabel)
-	if len(parts) < 5 {
+	if len(parts) < 7 {
 		log.Printf("标签 %s 无法解析，格式不正确", metricLabel)
-		return "", "", "", "", ""
+		return "", "", "", "", "", "", ""
 	}
-	return parts[0], parts[1], parts[2], parts[3], parts[4]
+	return parts[0], parts[1], parts[2], parts[3], parts[4], parts[5], parts[6]
 }
 
 // 定期检查超时的心跳数据
@@ -39,20 +78,35 @@ func CheckContainerHeartbeats() {
 			return true
 		}
 
-		// 如果超过 10 秒没有更新
-		if currentTime.Sub(timestamp) > 20*time.Second {
-			// 反解析 metricLabel 获取各个标签的值
-			namespace, podName, container, controllerName, project := parseContainerLabel(metricLabel)
+		// 反解析 metricLabel 获取各个标签的值
+		namespace, namespaceRaw, slot, podName, container, controllerName, project := parseContainerLabel(metricLabel)
 
+		// 如果超过 10 秒没有更新
+		if isExpired(project, "k8s", currentTime, timestamp) {
 			// 如果标签解析成功，且字段不为空，则删除相应的指标
 			if namespace != "" && podName != "" && container != "" && controllerName != "" && project != "" {
 				// 删除对应的指标
-				Metrics.ContainerCpuUsageMetric.DeleteLabelValues(namespace, podName, container, controllerName, project)
-				Metrics.ContainerMemoryUsageMetric.DeleteLabelValues(namespace, podName, container, controllerName, project)
-				Metrics.ContainerCpuLimitMetric.DeleteLabelValues(namespace, podName, container, controllerName, project)
-				Metrics.ContainerMemoryLimitMetric.DeleteLabelValues(namespace, podName, container, controllerName, project)
-				Metrics.ContainerRestartCountMetric.DeleteLabelValues(namespace, podName, container, controllerName, project)
-				Metrics.ContainerLastTerminationTimeMetric.DeleteLabelValues(namespace, podName, container, controllerName, project)
+				Metrics.ContainerCpuUsageMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				Metrics.ContainerMemoryUsageMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				Metrics.ContainerCpuLimitMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				Metrics.ContainerMemoryLimitMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				Metrics.ContainerRestartCountMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				Metrics.ContainerLastTerminationTimeMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				Metrics.ContainerCpuRequestMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				Metrics.ContainerMemoryRequestMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				Metrics.ContainerCpuThrottledRatioMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				Metrics.ContainerCpuLimitUtilizationMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				Metrics.ContainerMemoryLimitUtilizationMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				Metrics.ContainerCpuRequestUtilizationMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				Metrics.ContainerMemoryRequestUtilizationMetric.DeleteLabelValues(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				containerRestartsDeriver.forget(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				containerThrottleDeriver.forget(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+				containerOomKillsDeriver.forget(namespace, namespaceRaw, slot, podName, container, controllerName, project)
+
+				// 删除状态类指标
+				if status, ok := containerStatuses.LoadAndDelete(metricLabel); ok {
+					deleteContainerStatus([]string{namespace, namespaceRaw, slot, podName, container, controllerName, project}, status.(containerStatus))
+				}
 			} else {
 				log.Printf("标签 %s 格式不正确，跳过注销", metricLabel)
 			}
@@ -64,10 +118,8 @@ func CheckContainerHeartbeats() {
 	})
 }
 
-// 更新指标并记录时间戳
-func UpdateContainerMetricWithTimestamp(metricLabel string) {
-	// 获取当前时间
-	currentTime := time.Now()
+// 更新指标并记录采样时间
+func UpdateContainerMetricWithTimestamp(metricLabel string, timestamp time.Time) {
 	// 存储时间戳
-	ContainerTimestamp.Store(metricLabel, currentTime)
+	ContainerTimestamp.Store(metricLabel, timestamp)
 }
@@ -11,13 +11,14 @@ import (
 var ControllerTimestamp = sync.Map{}
 
 // 反解析 label 字符串并更新数据
-func parseControllerLabel(metricLabel string) (string, string, string, string) {
+// 返回 namespace, namespace_raw, slot, controllerName, controllerType, project
+func parseControllerLabel(metricLabel string) (string, string, string, string, string, string) {
 	parts := SplitLabels(metricLabel)
-	if len(parts) < 4 {
+	if len(parts) < 6 {
 		log.Printf("标签 %s 无法解析，格式不正确", metricLabel)
-		return "", "", "", ""
+		return "", "", "", "", "", ""
 	}
-	return parts[0], parts[1], parts[2], parts[3]
+	return parts[0], parts[1], parts[2], parts[3], parts[4], parts[5]
 }
 
 // 定期检查超时的心跳数据
@@ -38,16 +39,27 @@ func CheckControllerHeartbeats() {
 			log.Printf("时间戳格式不正确，跳过")
 			return true
 		}
-		if currentTime.Sub(timestamp) > 20*time.Second {
-			// 反解析 metricLabel 获取各个标签的值
-			namespace, continer, ControllerType, project := parseControllerLabel(metricLabel)
+		// 反解析 metricLabel 获取各个标签的值
+		namespace, namespaceRaw, slot, continer, ControllerType, project := parseControllerLabel(metricLabel)
 
+		if isExpired(project, "k8sController", currentTime, timestamp) {
 			// 如果标签解析成功，且字段不为空，则删除相应的指标
 			if namespace != "" && continer != "" && ControllerType != "" && project != "" {
 				// 删除对应的指标
-				Metrics.ControllerReplicasMetric.DeleteLabelValues(namespace, continer, ControllerType, project)
-				Metrics.ControllerReplicasAvailableMetric.DeleteLabelValues(namespace, continer, ControllerType, project)
-				Metrics.ControllerReplicasUnavailableMetric.DeleteLabelValues(namespace, continer, ControllerType, project)
+				Metrics.ControllerReplicasMetric.DeleteLabelValues(namespace, namespaceRaw, slot, continer, ControllerType, project)
+				Metrics.ControllerReplicasAvailableMetric.DeleteLabelValues(namespace, namespaceRaw, slot, continer, ControllerType, project)
+				Metrics.ControllerReplicasUnavailableMetric.DeleteLabelValues(namespace, namespaceRaw, slot, continer, ControllerType, project)
+				Metrics.ControllerReplicasReadyMetric.DeleteLabelValues(namespace, namespaceRaw, slot, continer, ControllerType, project)
+				Metrics.ControllerReplicasUpdatedMetric.DeleteLabelValues(namespace, namespaceRaw, slot, continer, ControllerType, project)
+				Metrics.ControllerGenerationMetric.DeleteLabelValues(namespace, namespaceRaw, slot, continer, ControllerType, project)
+				Metrics.ControllerObservedGenerationMetric.DeleteLabelValues(namespace, namespaceRaw, slot, continer, ControllerType, project)
+				Metrics.ControllerHpaMinReplicasMetric.DeleteLabelValues(namespace, namespaceRaw, slot, continer, ControllerType, project)
+				Metrics.ControllerHpaMaxReplicasMetric.DeleteLabelValues(namespace, namespaceRaw, slot, continer, ControllerType, project)
+				Metrics.ControllerHpaCurrentReplicasMetric.DeleteLabelValues(namespace, namespaceRaw, slot, continer, ControllerType, project)
+				Metrics.ControllerLastSuccessTimeMetric.DeleteLabelValues(namespace, namespaceRaw, slot, continer, ControllerType, project)
+				Metrics.RolloutStuckMetric.DeleteLabelValues(namespace, namespaceRaw, slot, continer, ControllerType, project)
+				deleteControllerConditions(metricLabel, []string{namespace, namespaceRaw, slot, continer, ControllerType, project})
+				controllerRollouts.Delete(metricLabel)
 			} else {
 				log.Printf("标签 %s 格式不正确，跳过注销", metricLabel)
 			}
@@ -59,10 +71,8 @@ func CheckControllerHeartbeats() {
 	})
 }
 
-// 更新指标并记录时间戳
-func UpdateControllerMetricWithTimestamp(metricLabel string) {
-	// 获取当前时间
-	currentTime := time.Now()
+// 更新指标并记录采样时间
+func UpdateControllerMetricWithTimestamp(metricLabel string, timestamp time.Time) {
 	// 存储时间戳
-	ControllerTimestamp.Store(metricLabel, currentTime)
+	ControllerTimestamp.Store(metricLabel, timestamp)
 }
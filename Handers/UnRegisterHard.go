@@ -10,14 +10,50 @@ import (
 // 用来存储时间戳和指标名称的 map
 var HardTimestamp = sync.Map{}
 
+// hostInfoLabels 每台主机当前的属性（cpu_model, os_version, kernel_version），key 格式: hostName|:|project
+var hostInfoLabels = sync.Map{}
+
+// SetHardLegacyLabels 设置是否同时输出带主机属性标签的旧版硬件指标（迁移期间使用），关闭时立即清空旧版指标
+func SetHardLegacyLabels(enabled bool) {
+	updateRuntime(func(s *runtimeSettings) {
+		s.hardLegacyLabels = enabled
+	})
+}
+
+func hardLegacyLabelsEnabled() bool {
+	return currentRuntime().hardLegacyLabels
+}
+
 // 反解析 label 字符串并更新数据
-func parseHardLabel(metricLabel string) (string, string, string, string, string) {
+func parseHardLabel(metricLabel string) (string, string) {
 	parts := SplitLabels(metricLabel)
-	if len(parts) < 5 {
+	if len(parts) < 2 {
 		log.Printf("标签 %s 无法解析，格式不正确", metricLabel)
-		return "", "", "", "", ""
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// updateHostInfo 记录主机属性，属性变化时删除旧的 host_info 和旧版指标
+func updateHostInfo(metricLabel string, info []string) {
+	previous, loaded := hostInfoLabels.Swap(metricLabel, info)
+	if !loaded {
+		return
+	}
+	old, ok := previous.([]string)
+	if !ok || (old[0] == info[0] && old[1] == info[1] && old[2] == info[2]) {
+		return
+	}
+	hostName, project := parseHardLabel(metricLabel)
+	deleteHostInfo(hostName, project, old)
+}
+
+// deleteHostInfo 删除指定属性的 host_info 和旧版指标
+func deleteHostInfo(hostName, project string, info []string) {
+	Metrics.HostInfoMetric.DeleteLabelValues(hostName, project, info[0], info[1], info[2])
+	for _, legacy := range Metrics.HardLegacyMetrics {
+		legacy.DeleteLabelValues(hostName, project, info[0], info[1], info[2])
 	}
-	return parts[0], parts[1], parts[2], parts[3], parts[4]
 }
 
 // 定期检查超时的心跳数据
@@ -38,27 +74,32 @@ func CheckHardHeartbeats() {
 			log.Printf("时间戳格式不正确，跳过")
 			return true
 		}
-		// 如果超过 10 秒没有更新
-		if currentTime.Sub(timestamp) > 20*time.Second {
-			// 反解析 metricLabel 获取各个标签的值
-			hostName, project, cpuModel, OSVersion, KernelVersion := parseHardLabel(metricLabel)
+		// 反解析 metricLabel 获取各个标签的值
+		hostName, project := parseHardLabel(metricLabel)
 
+		// 如果超过 10 秒没有更新
+		if isExpired(project, "hard", currentTime, timestamp) {
 			// 如果标签解析成功，且字段不为空，则删除相应的指标
 			if hostName != "" && project != "" {
 				// 删除对应的指标
-				Metrics.CpuPercentMetric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
-				Metrics.DiskTotalMetric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
-				Metrics.DiskUsedMetric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
-				Metrics.DiskFreeMetric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
-				Metrics.DiskUsedPercentMetric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
-				Metrics.MemoryTotalMetric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
-				Metrics.MemoryUsedMetric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
-				Metrics.MemoryFreeMetric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
-				Metrics.MemoryUsedPercentMetric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
-				Metrics.CpuLoad1Metric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
-				Metrics.CpuLoad5Metric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
-				Metrics.CpuLoad15Metric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
-				Metrics.CpuTotalMetric.DeleteLabelValues(hostName, project, cpuModel, OSVersion, KernelVersion)
+				Metrics.CpuPercentMetric.DeleteLabelValues(hostName, project)
+				Metrics.DiskTotalMetric.DeleteLabelValues(hostName, project)
+				Metrics.DiskUsedMetric.DeleteLabelValues(hostName, project)
+				Metrics.DiskFreeMetric.DeleteLabelValues(hostName, project)
+				Metrics.DiskUsedPercentMetric.DeleteLabelValues(hostName, project)
+				Metrics.MemoryTotalMetric.DeleteLabelValues(hostName, project)
+				Metrics.MemoryUsedMetric.DeleteLabelValues(hostName, project)
+				Metrics.MemoryFreeMetric.DeleteLabelValues(hostName, project)
+				Metrics.MemoryUsedPercentMetric.DeleteLabelValues(hostName, project)
+				Metrics.CpuLoad1Metric.DeleteLabelValues(hostName, project)
+				Metrics.CpuLoad5Metric.DeleteLabelValues(hostName, project)
+				Metrics.CpuLoad15Metric.DeleteLabelValues(hostName, project)
+				Metrics.CpuTotalMetric.DeleteLabelValues(hostName, project)
+
+				// 删除主机信息和旧版指标
+				if info, ok := hostInfoLabels.LoadAndDelete(metricLabel); ok {
+					deleteHostInfo(hostName, project, info.([]string))
+				}
 			} else {
 				log.Printf("标签 %s 格式不正确，跳过注销", metricLabel)
 			}
@@ -71,11 +112,8 @@ func CheckHardHeartbeats() {
 
 }
 
-// 更新指标并记录时间戳
-func UpdateHardMetricWithTimestamp(metricLabel string) {
-	// 获取当前时间
-	currentTime := time.Now()
+// 更新指标并记录采样时间
+func UpdateHardMetricWithTimestamp(metricLabel string, timestamp time.Time) {
 	// 存储时间戳
-	HardTimestamp.Store(metricLabel, currentTime)
-
+	HardTimestamp.Store(metricLabel, timestamp)
 }
@@ -0,0 +1,86 @@
+package Handers
+
+import (
+	"log"
+	"monitor-server/Metrics"
+	"sync"
+	"time"
+)
+
+// 挂载点、块设备、网卡的时间戳，各自独立过期
+// key 格式分别为: hostName|:|project|:|mountpoint|:|device|:|fs_type、hostName|:|project|:|device、hostName|:|project|:|interface
+var (
+	MountpointTimestamp = sync.Map{}
+	DiskIOTimestamp     = sync.Map{}
+	InterfaceTimestamp  = sync.Map{}
+)
+
+// expireTimestamps 遍历时间戳，对过期的标签调用 remove 删除指标
+// 标签的第 2 个值为 project
+func expireTimestamps(store *sync.Map, source string, labelCount int, remove func(parts []string)) {
+	currentTime := time.Now()
+	store.Range(func(key, value interface{}) bool {
+		metricLabel, ok := key.(string)
+		if !ok {
+			log.Printf("标签格式不正确，跳过")
+			return true
+		}
+
+		timestamp, ok := value.(time.Time)
+		if !ok {
+			log.Printf("时间戳格式不正确，跳过")
+			return true
+		}
+
+		parts := SplitLabels(metricLabel)
+		if len(parts) < 2 {
+			log.Printf("标签 %s 格式不正确，跳过", metricLabel)
+			return true
+		}
+
+		if isExpired(parts[1], source, currentTime, timestamp) {
+			if len(parts) == labelCount {
+				remove(parts)
+			} else {
+				log.Printf("标签 %s 格式不正确，跳过注销", metricLabel)
+			}
+			store.Delete(metricLabel)
+		}
+		return true
+	})
+}
+
+// CheckHardDeviceHeartbeats 定期清理超时的挂载点、块设备、网卡指标
+func CheckHardDeviceHeartbeats() {
+	expireTimestamps(&MountpointTimestamp, "hard", 5, func(l []string) {
+		Metrics.MountpointTotalMetric.DeleteLabelValues(l...)
+		Metrics.MountpointUsedMetric.DeleteLabelValues(l...)
+		Metrics.MountpointFreeMetric.DeleteLabelValues(l...)
+		Metrics.MountpointUsedPercentMetric.DeleteLabelValues(l...)
+		Metrics.MountpointInodesTotalMetric.DeleteLabelValues(l...)
+		Metrics.MountpointInodesUsedMetric.DeleteLabelValues(l...)
+		Metrics.MountpointInodesFreeMetric.DeleteLabelValues(l...)
+		Metrics.MountpointInodesUsedPercentMetric.DeleteLabelValues(l...)
+	})
+
+	expireTimestamps(&DiskIOTimestamp, "hard", 3, func(l []string) {
+		Metrics.DiskReadIopsMetric.DeleteLabelValues(l...)
+		Metrics.DiskWriteIopsMetric.DeleteLabelValues(l...)
+		Metrics.DiskReadBytesPerSecMetric.DeleteLabelValues(l...)
+		Metrics.DiskWriteBytesPerSecMetric.DeleteLabelValues(l...)
+		Metrics.DiskReadAwaitMsMetric.DeleteLabelValues(l...)
+		Metrics.DiskWriteAwaitMsMetric.DeleteLabelValues(l...)
+		Metrics.DiskIoUtilPercentMetric.DeleteLabelValues(l...)
+	})
+
+	expireTimestamps(&InterfaceTimestamp, "hard", 3, func(l []string) {
+		Metrics.NetworkRxBytesMetric.DeleteLabelValues(l...)
+		Metrics.NetworkTxBytesMetric.DeleteLabelValues(l...)
+		Metrics.NetworkRxPacketsMetric.DeleteLabelValues(l...)
+		Metrics.NetworkTxPacketsMetric.DeleteLabelValues(l...)
+		Metrics.NetworkRxErrorsMetric.DeleteLabelValues(l...)
+		Metrics.NetworkTxErrorsMetric.DeleteLabelValues(l...)
+		Metrics.NetworkRxDroppedMetric.DeleteLabelValues(l...)
+		Metrics.NetworkTxDroppedMetric.DeleteLabelValues(l...)
+	})
+}
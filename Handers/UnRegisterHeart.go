@@ -19,6 +19,37 @@ func parseHeartbeatLabel(metricLabel string) (string, string) {
 	return parts[0], parts[1]
 }
 
+// AgentLastSeen 返回每台主机最后一次上报心跳或硬件数据的时间，project -> hostName -> 时间
+func AgentLastSeen() map[string]map[string]time.Time {
+	result := map[string]map[string]time.Time{}
+	collect := func(key, value interface{}) bool {
+		metricLabel, ok := key.(string)
+		if !ok {
+			return true
+		}
+		timestamp, ok := value.(time.Time)
+		if !ok {
+			return true
+		}
+		hostname, project := parseHeartbeatLabel(metricLabel)
+		if hostname == "" {
+			return true
+		}
+		hosts, ok := result[project]
+		if !ok {
+			hosts = map[string]time.Time{}
+			result[project] = hosts
+		}
+		if timestamp.After(hosts[hostname]) {
+			hosts[hostname] = timestamp
+		}
+		return true
+	}
+	agentHeartbeatTimes.Range(collect)
+	HardTimestamp.Range(collect)
+	return result
+}
+
 // 定期检查超时的心跳数据
 func CheckHeartbeats() {
 	currentTime := time.Now()
@@ -41,7 +72,7 @@ func CheckHeartbeats() {
 		hostname, project := parseHeartbeatLabel(metricLabel)
 
 		// 如果超过 10 秒没有接收到心跳
-		if currentTime.Sub(timestamp) > 20*time.Second {
+		if isExpired(project, "heart", currentTime, timestamp) {
 			// 设置 IsActive 为 0，表示该 agent 已经不活跃
 			Metrics.IsActiveMetric.WithLabelValues(hostname, project).Set(0)
 		}
@@ -10,6 +10,13 @@ import (
 // 用来存储时间戳和指标名称的 map
 var NginxTimestamp = sync.Map{}
 
+// upstream 后端和 server 块的时间戳，各自独立过期
+// key 格式分别为: hostName|:|project|:|upstream|:|peer、hostName|:|project|:|server_name
+var (
+	NginxUpstreamTimestamp = sync.Map{}
+	NginxServerTimestamp   = sync.Map{}
+)
+
 // 反解析 label 字符串并更新数据
 func parseNginxLabel(metricLabel string) (string, string) {
 	parts := SplitLabels(metricLabel)
@@ -38,11 +45,11 @@ func CheckNginxHeartbeats() {
 			log.Printf("时间戳格式不正确，跳过")
 			return true
 		}
-		// 如果超过 10 秒没有更新
-		if currentTime.Sub(timestamp) > 20*time.Second {
-			// 反解析 metricLabel 获取各个标签的值
-			hostName, project := parseNginxLabel(metricLabel)
+		// 反解析 metricLabel 获取各个标签的值
+		hostName, project := parseNginxLabel(metricLabel)
 
+		// 如果超过 10 秒没有更新
+		if isExpired(project, "nginx", currentTime, timestamp) {
 			// 如果标签解析成功，且字段不为空，则删除相应的指标
 			if hostName != "" && project != "" {
 				// 删除对应的指标
@@ -59,6 +66,16 @@ func CheckNginxHeartbeats() {
 				Metrics.NginxTcpClosedMetric.DeleteLabelValues(hostName, project)
 				Metrics.NginxTcpOrphanedMetric.DeleteLabelValues(hostName, project)
 				Metrics.NginxTcpTimewaitMetric.DeleteLabelValues(hostName, project)
+				nginxReTotalDeriver.forget(hostName, project)
+
+				// stub_status
+				Metrics.NginxConnectionsActiveMetric.DeleteLabelValues(hostName, project)
+				Metrics.NginxConnectionsReadingMetric.DeleteLabelValues(hostName, project)
+				Metrics.NginxConnectionsWritingMetric.DeleteLabelValues(hostName, project)
+				Metrics.NginxConnectionsWaitingMetric.DeleteLabelValues(hostName, project)
+				nginxAcceptsDeriver.forget(hostName, project)
+				nginxHandledDeriver.forget(hostName, project)
+				nginxRequestsDeriver.forget(hostName, project)
 			} else {
 				log.Printf("标签 %s 格式不正确，跳过注销", metricLabel)
 			}
@@ -71,12 +88,23 @@ func CheckNginxHeartbeats() {
 
 }
 
-// 更新指标并记录时间戳
-func UpdateNginxMetricWithTimestamp(metricLabel string) {
-	// 获取当前时间
-	currentTime := time.Now()
-
+// 更新指标并记录采样时间
+func UpdateNginxMetricWithTimestamp(metricLabel string, timestamp time.Time) {
 	// 存储时间戳
-	NginxTimestamp.Store(metricLabel, currentTime)
+	NginxTimestamp.Store(metricLabel, timestamp)
+}
+
+// CheckNginxStatusHeartbeats 定期清理超时的 upstream 后端和 server 块指标
+func CheckNginxStatusHeartbeats() {
+	expireTimestamps(&NginxUpstreamTimestamp, "nginx", 4, func(l []string) {
+		Metrics.NginxUpstreamPeerUpMetric.DeleteLabelValues(l...)
+		Metrics.NginxUpstreamPeerFailsMetric.DeleteLabelValues(l...)
+		Metrics.NginxUpstreamPeerResponseTimeMsMetric.DeleteLabelValues(l...)
+	})
 
+	expireTimestamps(&NginxServerTimestamp, "nginx", 3, func(l []string) {
+		for _, code := range []string{"1xx", "2xx", "3xx", "4xx", "5xx"} {
+			nginxResponsesDeriver.forget(append(l, code)...)
+		}
+	})
 }
@@ -0,0 +1,52 @@
+package Handers
+
+import (
+	"log"
+	"monitor-server/Metrics"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OtlpTimestamp 存储 OTLP 时间序列的最后更新时间，key 为时间序列唯一键
+var OtlpTimestamp = sync.Map{}
+
+// 从时间序列唯一键中解析项目编码（otlpSeriesKey 的第二段为 project=编码）
+func parseOtlpProject(metricLabel string) string {
+	parts := SplitLabels(metricLabel)
+	if len(parts) < 2 {
+		return ""
+	}
+	return strings.TrimPrefix(parts[1], "project=")
+}
+
+// CheckOtlpHeartbeats 定期清理超时的 OTLP 时间序列
+func CheckOtlpHeartbeats() {
+	currentTime := time.Now()
+
+	OtlpTimestamp.Range(func(key, value interface{}) bool {
+		metricLabel, ok := key.(string)
+		if !ok {
+			log.Printf("[OTLP] 标签格式不正确，跳过")
+			return true
+		}
+
+		timestamp, ok := value.(time.Time)
+		if !ok {
+			log.Printf("[OTLP] 时间戳格式不正确，跳过")
+			return true
+		}
+
+		// 默认 3 分钟过期（大于 collector 默认 60 秒的导出间隔），可在 projects.json 中用 sourceTtl.otlp 覆盖
+		if isExpired(parseOtlpProject(metricLabel), "otlp", currentTime, timestamp) {
+			Metrics.OtlpMetrics.Delete(metricLabel)
+			OtlpTimestamp.Delete(metricLabel)
+		}
+		return true
+	})
+}
+
+// UpdateOtlpMetricWithTimestamp 记录时间序列的更新时间
+func UpdateOtlpMetricWithTimestamp(metricLabel string) {
+	OtlpTimestamp.Store(metricLabel, time.Now())
+}
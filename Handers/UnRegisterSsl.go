@@ -36,15 +36,15 @@ func CheckSSLHeartbeats() {
 			log.Printf("时间戳格式不正确，跳过")
 			return true
 		}
-		// 如果超过 10 秒没有更新
-		if currentTime.Sub(timestamp) > 20*time.Second {
-			// 反解析 metricLabel 获取各个标签的值
-			domain, comment, status, resolve, projectName := parseSSLLabel(metricLabel)
+		// 反解析 metricLabel 获取各个标签的值
+		domain, comment, status, resolve, project := parseSSLLabel(metricLabel)
 
+		// 如果超过 10 秒没有更新
+		if isExpired(project, "ssl", currentTime, timestamp) {
 			if domain != "" && comment != "" && status != "" && resolve != "" {
 
 				// 删除对应的 SSL 指标
-				Metrics.SslDaysLeftMetric.DeleteLabelValues(domain, comment, status, resolve, projectName)
+				Metrics.SslDaysLeftMetric.DeleteLabelValues(domain, comment, status, resolve, project)
 
 				// 删除时间戳
 				sslTimestamp.Delete(metricLabel) // 删除时间戳
@@ -23,6 +23,7 @@ func parseTrafficSwitchingLabel(metricLabel string) (string, string) {
 // CheckTrafficSwitchingHeartbeats 定期检查超时的 TrafficSwitching 数据
 func CheckTrafficSwitchingHeartbeats() {
 	currentTime := time.Now()
+	pruneTodayStates(currentTime)
 
 	TrafficSwitchingTimestamp.Range(func(key, value interface{}) bool {
 		metricLabel, ok := key.(string)
@@ -37,10 +38,10 @@ func CheckTrafficSwitchingHeartbeats() {
 			return true
 		}
 
-		// 如果超过 10 秒没有更新
-		if currentTime.Sub(timestamp) > 20*time.Second {
-			service, project := parseTrafficSwitchingLabel(metricLabel)
+		service, project := parseTrafficSwitchingLabel(metricLabel)
 
+		// 如果超过 10 秒没有更新
+		if isExpired(project, "trafficSwitching", currentTime, timestamp) {
 			if service != "" && project != "" {
 				log.Printf("[TrafficSwitching] 实例失活，清理指标 -> service=%s project=%s", service, project)
 
@@ -49,6 +50,9 @@ func CheckTrafficSwitchingHeartbeats() {
 				Metrics.TrafficSwitchingTotalSuccess.DeleteLabelValues(service, project)
 				Metrics.TrafficSwitchingTotalErrors.DeleteLabelValues(service, project)
 				Metrics.TrafficSwitchingTotalSuccessRate.DeleteLabelValues(service, project)
+				trafficRequestsDeriver.forget(service, project)
+				trafficSuccessDeriver.forget(service, project)
+				trafficErrorsDeriver.forget(service, project)
 
 				// 今日统计
 				Metrics.TrafficSwitchingTodayRequests.DeleteLabelValues(service, project)
@@ -60,6 +64,9 @@ func CheckTrafficSwitchingHeartbeats() {
 				Metrics.TrafficSwitchingTodayStatus4xx.DeleteLabelValues(service, project)
 				Metrics.TrafficSwitchingTodayStatus5xx.DeleteLabelValues(service, project)
 
+				// 昨日统计
+				deleteYesterday(service, project)
+
 				// 实时统计
 				Metrics.TrafficSwitchingRealtimeQPS.DeleteLabelValues(service, project)
 				Metrics.TrafficSwitchingRealtimeSuccessQPS.DeleteLabelValues(service, project)
@@ -68,6 +75,12 @@ func CheckTrafficSwitchingHeartbeats() {
 				Metrics.TrafficSwitchingRealtimeAvgLatencyMs.DeleteLabelValues(service, project)
 				Metrics.TrafficSwitchingRealtimeMaxLatencyMs.DeleteLabelValues(service, project)
 
+				// 延迟分布
+				deleteTrafficSwitchingLatencies(metricLabel, service, project)
+
+				// 按后端、路由拆分
+				deleteTrafficSwitchingBreakdown(metricLabel, service, project)
+
 				// 错误类型
 				Metrics.TrafficSwitchingErrorBackendError.DeleteLabelValues(service, project)
 				Metrics.TrafficSwitchingErrorBrokenPipe.DeleteLabelValues(service, project)
@@ -106,7 +119,7 @@ func CheckTrafficSwitchingHeartbeats() {
 	})
 }
 
-// UpdateTrafficSwitchingTimestamp 更新指标并记录时间戳
-func UpdateTrafficSwitchingTimestamp(metricLabel string) {
-	TrafficSwitchingTimestamp.Store(metricLabel, time.Now())
+// UpdateTrafficSwitchingTimestamp 更新指标并记录采样时间
+func UpdateTrafficSwitchingTimestamp(metricLabel string, timestamp time.Time) {
+	TrafficSwitchingTimestamp.Store(metricLabel, timestamp)
 }
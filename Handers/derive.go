@@ -0,0 +1,141 @@
+package Handers
+
+import (
+	"monitor-server/Metrics"
+	"sync"
+	"time"
+)
+
+// 增量统计窗口
+const deriveWindow = 5 * time.Minute
+
+// counterPoint 修正后的累计值及其采样时间
+type counterPoint struct {
+	at    time.Time
+	total float64
+}
+
+// counterState 单个标签组合的派生状态
+type counterState struct {
+	base      float64        // 第一次上报的原始值，counter 从该值开始
+	lastValue float64        // agent 上报的原始值
+	lastAt    time.Time      // 上次采样时间
+	total     float64        // 修正归零后的累计值
+	points    []counterPoint // 窗口内的历史点，用于计算增量
+}
+
+// counterDeriver 根据上报的累计值输出 counter，并计算每秒速率和窗口增量
+type counterDeriver struct {
+	name     string                    // 原始指标名，用于归零计数
+	counter  *Metrics.CounterCollector // 修正归零后的 counter
+	rate     *Metrics.GaugeVec         // 每秒速率，为 nil 时不输出
+	increase *Metrics.GaugeVec         // 窗口增量，为 nil 时不输出
+
+	mu     sync.Mutex
+	series map[string]*counterState
+}
+
+func newCounterDeriver(name string, counter *Metrics.CounterCollector, rate, increase *Metrics.GaugeVec) *counterDeriver {
+	return &counterDeriver{name: name, counter: counter, rate: rate, increase: increase, series: map[string]*counterState{}}
+}
+
+// 各累计字段的派生器
+var (
+	trafficRequestsDeriver   = newCounterDeriver("trafficswitching_total_requests", Metrics.TrafficSwitchingRequestsCounter, Metrics.TrafficSwitchingRequestsPerSecond, Metrics.TrafficSwitchingRequestsIncrease5m)
+	trafficSuccessDeriver    = newCounterDeriver("trafficswitching_total_success", Metrics.TrafficSwitchingSuccessCounter, nil, nil)
+	trafficErrorsDeriver     = newCounterDeriver("trafficswitching_total_errors", Metrics.TrafficSwitchingErrorsCounter, Metrics.TrafficSwitchingErrorsPerSecond, Metrics.TrafficSwitchingErrorsIncrease5m)
+	backendRequestsDeriver   = newCounterDeriver("trafficswitching_backend_requests", Metrics.TrafficSwitchingBackendRequestsCounter, nil, Metrics.TrafficSwitchingBackendRequestsIncrease5m)
+	backendErrorsDeriver     = newCounterDeriver("trafficswitching_backend_errors", Metrics.TrafficSwitchingBackendErrorsCounter, nil, Metrics.TrafficSwitchingBackendErrorsIncrease5m)
+	routeRequestsDeriver     = newCounterDeriver("trafficswitching_route_requests", Metrics.TrafficSwitchingRouteRequestsCounter, nil, nil)
+	routeErrorsDeriver       = newCounterDeriver("trafficswitching_route_errors", Metrics.TrafficSwitchingRouteErrorsCounter, nil, nil)
+	nginxReTotalDeriver      = newCounterDeriver("nginx_re_total", Metrics.NginxRequestsCounter, Metrics.NginxReTotalPerSecond, Metrics.NginxReTotalIncrease5m)
+	nginxAcceptsDeriver      = newCounterDeriver("nginx_stub_accepts", Metrics.NginxConnectionsAcceptedCounter, nil, nil)
+	nginxHandledDeriver      = newCounterDeriver("nginx_stub_handled", Metrics.NginxConnectionsHandledCounter, nil, nil)
+	nginxRequestsDeriver     = newCounterDeriver("nginx_stub_requests", Metrics.NginxHttpRequestsCounter, nil, nil)
+	nginxResponsesDeriver    = newCounterDeriver("nginx_server_responses", Metrics.NginxServerResponsesCounter, nil, nil)
+	containerRestartsDeriver = newCounterDeriver("container_restart_count", Metrics.ContainerRestartsCounter, nil, Metrics.ContainerRestartsIncrease5m)
+	containerThrottleDeriver = newCounterDeriver("container_cpu_throttled_seconds", Metrics.ContainerCpuThrottledSecondsCounter, nil, nil)
+	containerOomKillsDeriver = newCounterDeriver("container_oom_kills", Metrics.ContainerOomKillsCounter, nil, Metrics.ContainerOomKillsIncrease5m)
+)
+
+// SetLegacyCounterGauges 设置是否同时以 gauge 类型输出旧版累计指标（trafficswitching_total_requests 等），关闭时立即清空
+func SetLegacyCounterGauges(enabled bool) {
+	updateRuntime(func(s *runtimeSettings) {
+		s.legacyCounterGauges = enabled
+	})
+}
+
+func legacyCounterGaugesEnabled() bool {
+	return currentRuntime().legacyCounterGauges
+}
+
+// observe 记录一次累计值并更新派生指标，返回窗口内的增量
+// 上报值小于上次值视为计数归零，本次增量按上报值计算
+func (d *counterDeriver) observe(value float64, at time.Time, labels ...string) float64 {
+	key := JoinLabels(labels...)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, ok := d.series[key]
+	if !ok {
+		// 第一个点只作为基准，不输出速率
+		d.series[key] = &counterState{
+			base:      value,
+			lastValue: value,
+			lastAt:    at,
+			points:    []counterPoint{{at: at, total: 0}},
+		}
+		d.counter.Set(value, labels...)
+		if d.increase != nil {
+			d.increase.WithLabelValues(labels...).Set(0)
+		}
+		return 0
+	}
+
+	delta := value - state.lastValue
+	if delta < 0 {
+		Metrics.DerivedCounterResets.WithLabelValues(d.name).Inc()
+		delta = value
+	}
+	elapsed := at.Sub(state.lastAt).Seconds()
+
+	state.total += delta
+	state.lastValue = value
+	state.lastAt = at
+	d.counter.Set(state.base+state.total, labels...)
+
+	if d.rate != nil && elapsed > 0 {
+		d.rate.WithLabelValues(labels...).Set(delta / elapsed)
+	}
+
+	// 丢弃窗口外的历史点，至少保留一个作为基准
+	state.points = append(state.points, counterPoint{at: at, total: state.total})
+	cutoff := at.Add(-deriveWindow)
+	drop := 0
+	for drop < len(state.points)-1 && state.points[drop].at.Before(cutoff) {
+		drop++
+	}
+	state.points = state.points[drop:]
+
+	increase := state.total - state.points[0].total
+	if d.increase != nil {
+		d.increase.WithLabelValues(labels...).Set(increase)
+	}
+	return increase
+}
+
+// forget 删除标签组合的派生状态和指标，在原始指标过期时调用
+func (d *counterDeriver) forget(labels ...string) {
+	d.mu.Lock()
+	delete(d.series, JoinLabels(labels...))
+	d.mu.Unlock()
+
+	d.counter.Delete(labels...)
+	if d.rate != nil {
+		d.rate.DeleteLabelValues(labels...)
+	}
+	if d.increase != nil {
+		d.increase.DeleteLabelValues(labels...)
+	}
+}
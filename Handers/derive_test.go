@@ -0,0 +1,108 @@
+package Handers
+
+import (
+	"testing"
+	"time"
+
+	"monitor-server/Metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// counterValue 读取 CounterCollector 中唯一序列的值
+func counterValue(t *testing.T, c *Metrics.CounterCollector) float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 1)
+	c.Collect(ch)
+	close(ch)
+	m := &dto.Metric{}
+	if err := (<-ch).Write(m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestCounterDeriverObserve(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type point struct {
+		value float64
+		after time.Duration // 相对 start 的时间
+	}
+	tests := []struct {
+		name         string
+		points       []point
+		wantCounter  float64 // 从第一次上报值开始，归零后继续累加
+		wantRate     float64
+		wantIncrease float64
+		wantResets   float64
+	}{
+		{"first point is baseline", []point{{100, 0}}, 100, 0, 0, 0},
+		{"monotonic", []point{{100, 0}, {130, 10 * time.Second}, {160, 20 * time.Second}}, 160, 3, 60, 0},
+		{"reset counts reported value", []point{{100, 0}, {130, 10 * time.Second}, {20, 20 * time.Second}}, 150, 2, 50, 1},
+		{"reset to zero", []point{{100, 0}, {0, 10 * time.Second}}, 100, 0, 0, 1},
+		// 4 分钟的点仍在 7 分钟时的 5 分钟窗口内，作为增量基准
+		{"window drops old points", []point{{0, 0}, {10, 4 * time.Minute}, {40, 7 * time.Minute}}, 40, 30.0 / 180, 30, 0},
+		{"same timestamp skips rate", []point{{100, 0}, {110, 0}}, 110, 0, 10, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := "test_" + tt.name
+			rate := Metrics.NewGaugeVec(prometheus.GaugeOpts{Name: "test_per_second"}, []string{"project"})
+			increase := Metrics.NewGaugeVec(prometheus.GaugeOpts{Name: "test_increase_5m"}, []string{"project"})
+			counter := Metrics.NewCounterCollector("test_total", "test", []string{"project"})
+			d := newCounterDeriver(name, counter, rate, increase)
+
+			var got float64
+			for _, p := range tt.points {
+				got = d.observe(p.value, start.Add(p.after), "p")
+			}
+
+			if got != tt.wantIncrease {
+				t.Errorf("observe() = %v, want %v", got, tt.wantIncrease)
+			}
+			if got := counterValue(t, counter); got != tt.wantCounter {
+				t.Errorf("counter = %v, want %v", got, tt.wantCounter)
+			}
+
+			if got := testutil.ToFloat64(rate.WithLabelValues("p")); got != tt.wantRate {
+				t.Errorf("rate = %v, want %v", got, tt.wantRate)
+			}
+			if got := testutil.ToFloat64(increase.WithLabelValues("p")); got != tt.wantIncrease {
+				t.Errorf("increase = %v, want %v", got, tt.wantIncrease)
+			}
+			if got := testutil.ToFloat64(Metrics.DerivedCounterResets.WithLabelValues(name)); got != tt.wantResets {
+				t.Errorf("resets = %v, want %v", got, tt.wantResets)
+			}
+		})
+	}
+}
+
+func TestCounterDeriverForget(t *testing.T) {
+	increase := Metrics.NewGaugeVec(prometheus.GaugeOpts{Name: "test_increase_5m"}, []string{"project"})
+	counter := Metrics.NewCounterCollector("test_total", "test", []string{"project"})
+	d := newCounterDeriver("test_forget", counter, nil, increase)
+	start := time.Now()
+
+	d.observe(100, start, "p")
+	d.observe(150, start.Add(time.Second), "p")
+	d.forget("p")
+	if n := testutil.CollectAndCount(increase) + testutil.CollectAndCount(counter); n != 0 {
+		t.Fatalf("series after forget = %d, want 0", n)
+	}
+
+	// 删除后重新上报的较小值作为新的基准，不算归零
+	d.observe(10, start.Add(2*time.Second), "p")
+	if got := testutil.ToFloat64(increase.WithLabelValues("p")); got != 0 {
+		t.Errorf("increase after forget = %v, want 0", got)
+	}
+	if got := counterValue(t, counter); got != 10 {
+		t.Errorf("counter after forget = %v, want 10", got)
+	}
+	if got := testutil.ToFloat64(Metrics.DerivedCounterResets.WithLabelValues("test_forget")); got != 0 {
+		t.Errorf("resets = %v, want 0", got)
+	}
+}
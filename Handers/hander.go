@@ -10,14 +10,16 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"io"
 	"log"
+	"monitor-server/Archive"
+	"monitor-server/Influx"
+	"monitor-server/IpPass"
+	"monitor-server/Metrics"
+	"monitor-server/Modles"
 	"net/http"
 	"sync"
+	"time"
 )
 
-// 全局变量存储加密盐
-var encryptionKey []byte
-var encryptionKeyMu sync.RWMutex // 使用读写锁，允许并发读取
-
 // 分片锁：按 source+project 组合分锁，大幅提升并发能力
 const shardCount = 256 // 分片数量，2的幂次方便取模
 
@@ -43,24 +45,52 @@ var (
 	heartShards            shardedMutex
 	controllerShards       shardedMutex
 	trafficSwitchingShards shardedMutex
+	k8sEventShards         shardedMutex
+	topNShards             shardedMutex
 )
 
-// Worker Pool 配置
-const workerPoolSize = 500  // 并发 worker 数量
-const taskQueueSize = 10000 // 任务队列缓冲大小
-
 var taskQueue chan func()
 
-func init() {
-	taskQueue = make(chan func(), taskQueueSize)
-	// 启动 worker pool
-	for i := 0; i < workerPoolSize; i++ {
+// StartWorkers 启动 worker pool，workers 为并发 worker 数量，queueSize 为任务队列缓冲大小
+// 需要在注册上报接口之前调用
+func StartWorkers(workers, queueSize int) {
+	taskQueue = make(chan func(), queueSize)
+	for i := 0; i < workers; i++ {
 		go func() {
 			for task := range taskQueue {
 				safeExecute(task)
 			}
 		}()
 	}
+	log.Printf("上报处理 worker: %d，队列: %d", workers, queueSize)
+}
+
+// SetRejectWhenQueueFull 设置队列满时是否拒绝上报（返回 503），默认与旧版本一样另起 goroutine 处理
+// 旧版 agent 把 503 当作失败直接丢弃数据，只有 agent 会重试时才应启用
+func SetRejectWhenQueueFull(reject bool) {
+	updateRuntime(func(s *runtimeSettings) {
+		s.rejectWhenQueueFull = reject
+	})
+}
+
+func rejectWhenQueueFullEnabled() bool {
+	return currentRuntime().rejectWhenQueueFull
+}
+
+// submitTask 非阻塞提交任务
+// 队列满时默认另起 goroutine 处理，避免请求堆积；启用 rejectWhenFull 时返回 false，由调用方返回 503 让 agent 稍后重试
+func submitTask(source string, task func()) bool {
+	select {
+	case taskQueue <- task:
+		return true
+	default:
+	}
+	if rejectWhenQueueFullEnabled() {
+		return false
+	}
+	Metrics.IngestQueueFullInline.WithLabelValues(source).Inc()
+	go safeExecute(task)
+	return true
 }
 
 // safeExecute 安全执行任务，捕获 panic 防止 worker 退出
@@ -82,6 +112,10 @@ var allowedSources = map[string]bool{
 	"heart":            true,
 	"k8sController":    true,
 	"trafficSwitching": true,
+	"k8sEvent":         true,
+	"esIp":             true,
+	"esCountry":        true,
+	"esUrl":            true,
 }
 
 // 验证 project 名称是否合法
@@ -108,30 +142,71 @@ func isValidSource(source string) bool {
 	return allowedSources[source]
 }
 
-// 请求体大小限制（10MB）
-const MaxRequestBodySize = 10 * 1024 * 1024
+// 请求体大小限制，默认 10MB
+const defaultMaxRequestBodySize int64 = 10 * 1024 * 1024
 
-// SetEncryptionKey 设置加密盐（验证密钥长度）
-func SetEncryptionKey(key string) {
+// SetMaxRequestBodySize 设置请求体大小上限（MB），<= 0 时使用默认值
+func SetMaxRequestBodySize(mb int) {
+	size := int64(mb) * 1024 * 1024
+	if size <= 0 {
+		size = defaultMaxRequestBodySize
+	}
+	updateRuntime(func(s *runtimeSettings) {
+		s.maxRequestBodySize = size
+	})
+}
+
+func getMaxRequestBodySize() int64 {
+	return currentRuntime().maxRequestBodySize
+}
+
+// checkEncryptionKey 校验加密盐长度（16/24/32 字节）
+func checkEncryptionKey(key string) ([]byte, error) {
 	keyLen := len(key)
 	if keyLen != 16 && keyLen != 24 && keyLen != 32 {
-		log.Printf("警告: AES 密钥长度应为 16/24/32 字节，当前: %d 字节", keyLen)
+		return nil, fmt.Errorf("AES 密钥长度应为 16/24/32 字节，当前: %d 字节", keyLen)
+	}
+	return []byte(key), nil
+}
+
+// SetEncryptionKey 设置加密盐，长度不是 16/24/32 字节时返回错误并保留原密钥
+func SetEncryptionKey(key string) error {
+	k, err := checkEncryptionKey(key)
+	if err != nil {
+		return err
 	}
-	encryptionKeyMu.Lock()
-	defer encryptionKeyMu.Unlock()
-	encryptionKey = []byte(key)
+	updateRuntime(func(s *runtimeSettings) {
+		s.encryptionKey = k
+	})
+	return nil
 }
 
 // GetEncryptionKey 获取当前加密盐（线程安全）
 func GetEncryptionKey() []byte {
-	encryptionKeyMu.RLock()
-	defer encryptionKeyMu.RUnlock()
-	return encryptionKey
+	return currentRuntime().encryptionKey
 }
 
 // 解密数据
 func Decrypt(ciphertext []byte) ([]byte, error) {
-	key := GetEncryptionKey() // 获取加密盐
+	return decryptWithKey(GetEncryptionKey(), ciphertext) // 使用加密盐
+}
+
+// decryptPayload 先用加密盐解密，失败时依次尝试项目独立密钥
+// 返回解密使用的项目编码，使用加密盐时为空
+func decryptPayload(ciphertext []byte) ([]byte, string, error) {
+	plaintext, err := Decrypt(ciphertext)
+	if err == nil {
+		return plaintext, "", nil
+	}
+	for code, key := range projectKeys() {
+		if plaintext, keyErr := decryptWithKey(key, ciphertext); keyErr == nil {
+			return plaintext, code, nil
+		}
+	}
+	return nil, "", err
+}
+
+func decryptWithKey(key, ciphertext []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
@@ -182,6 +257,67 @@ func writeJSONError(w http.ResponseWriter, statusCode int, msg string) {
 	}
 }
 
+// HandlePayload 按 source 分发数据到对应的处理函数，按 project 分片锁，同项目同类型串行
+// 返回通过时间戳校验的数据项
+func HandlePayload(source, project string, data []interface{}, receivedAt time.Time) []Modles.Sample {
+	var samples []Modles.Sample
+	switch source {
+	case "nginx":
+		mu := nginxShards.getShard(project)
+		mu.Lock()
+		samples = HandleNginxData(data, project, receivedAt)
+		mu.Unlock()
+	case "hard":
+		mu := hardShards.getShard(project)
+		mu.Lock()
+		samples = HandleHardData(data, project, receivedAt)
+		mu.Unlock()
+	case "ssl":
+		mu := sslShards.getShard(project)
+		mu.Lock()
+		samples = HandleSSLData(data, project, receivedAt)
+		mu.Unlock()
+	case "k8s":
+		mu := containerShards.getShard(project)
+		mu.Lock()
+		samples = HandleContainerResourceData(data, project, receivedAt)
+		mu.Unlock()
+	case "heart":
+		mu := heartShards.getShard(project)
+		mu.Lock()
+		samples = HandleHeartData(data, project, receivedAt)
+		mu.Unlock()
+	case "k8sController":
+		mu := controllerShards.getShard(project)
+		mu.Lock()
+		samples = HandleControllertResourceData(data, project, receivedAt)
+		mu.Unlock()
+	case "trafficSwitching":
+		mu := trafficSwitchingShards.getShard(project)
+		mu.Lock()
+		samples = HandleTrafficSwitchingData(data, project, receivedAt)
+		mu.Unlock()
+	case "k8sEvent":
+		mu := k8sEventShards.getShard(project)
+		mu.Lock()
+		samples = HandleK8sEventData(data, project, receivedAt)
+		mu.Unlock()
+	case "esIp", "esCountry", "esUrl":
+		mu := topNShards.getShard(source + project)
+		mu.Lock()
+		switch source {
+		case "esIp":
+			samples = HandleEsIpData(data, project, receivedAt)
+		case "esCountry":
+			samples = HandleEsCountryData(data, project, receivedAt)
+		case "esUrl":
+			samples = HandleEsUrlData(data, project, receivedAt)
+		}
+		mu.Unlock()
+	}
+	return samples
+}
+
 func MetricsHandler(w http.ResponseWriter, r *http.Request, CustomRegistry *prometheus.Registry) {
 	if r.Method != http.MethodPost {
 		writeJSONError(w, http.StatusMethodNotAllowed, "仅支持 POST 请求")
@@ -189,7 +325,8 @@ func MetricsHandler(w http.ResponseWriter, r *http.Request, CustomRegistry *prom
 	}
 
 	// 读取请求体（限制大小防止 DoS 攻击）
-	body, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestBodySize))
+	maxBodySize := getMaxRequestBodySize()
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
 	if err != nil {
 		writeJSONError(w, http.StatusBadRequest, "读取请求体失败")
 		return
@@ -201,14 +338,14 @@ func MetricsHandler(w http.ResponseWriter, r *http.Request, CustomRegistry *prom
 	}(r.Body)
 
 	// 检查请求体是否超出限制
-	if len(body) >= MaxRequestBodySize {
+	if int64(len(body)) >= maxBodySize {
 		writeJSONError(w, http.StatusRequestEntityTooLarge, "请求体过大")
 		return
 	}
 	//log.Printf("收到请求，数据长度: %d 字节", len(body))
 
 	// 解密数据（错误信息不暴露内部细节）
-	decryptedData, err := Decrypt(body)
+	decryptedData, keyProject, err := decryptPayload(body)
 	if err != nil {
 		log.Printf("解密失败: %v", err) // 日志记录详细错误
 		writeJSONError(w, http.StatusBadRequest, "数据解密失败")
@@ -255,6 +392,29 @@ func MetricsHandler(w http.ResponseWriter, r *http.Request, CustomRegistry *prom
 		return
 	}
 
+	// 项目登记校验：设置了独立密钥的项目只接受该密钥，其他项目只接受加密盐
+	reason := ""
+	if p, ok := lookupProject(project); ok && p.Key != "" {
+		if keyProject != project {
+			reason = "key_mismatch"
+		}
+	} else if keyProject != "" {
+		reason = "key_mismatch"
+	}
+	if reason == "" {
+		_, reason = admitProject(project, source)
+	}
+	if reason != "" {
+		Metrics.IngestRejectedPayloads.WithLabelValues(source, reason).Inc()
+		log.Printf("拒绝上报: project=%s source=%s reason=%s", project, source, reason)
+		status := http.StatusForbidden
+		if reason == "key_mismatch" {
+			status = http.StatusUnauthorized
+		}
+		writeJSONError(w, status, rejectMessages[reason])
+		return
+	}
+
 	// 提取 data 字段
 	data, ok := payload["data"].([]interface{})
 	if !ok || len(data) == 0 {
@@ -262,7 +422,24 @@ func MetricsHandler(w http.ResponseWriter, r *http.Request, CustomRegistry *prom
 		return
 	}
 
-	// 立即返回成功响应
+	// 记录接收时间
+	receivedAt := time.Now()
+
+	// 使用 worker pool 处理，按 project 分片锁，同项目同类型串行，不同项目并发
+	task := func() {
+		samples := HandlePayload(source, project, data, receivedAt)
+
+		// 输出到 InfluxDB（未启用时直接返回），只写入通过时间戳校验的数据项
+		Influx.WriteBatch(source, project, getProjectName(project), samples)
+	}
+	if !submitTask(source, task) {
+		Metrics.IngestRejectedPayloads.WithLabelValues(source, "queue_full").Inc()
+		log.Printf("任务队列已满，拒绝上报: project=%s source=%s", project, source)
+		writeJSONError(w, http.StatusServiceUnavailable, "服务繁忙，请稍后重试")
+		return
+	}
+
+	// 已提交处理，返回成功响应
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	response := map[string]interface{}{"code": 200, "msg": "ok"}
@@ -270,53 +447,7 @@ func MetricsHandler(w http.ResponseWriter, r *http.Request, CustomRegistry *prom
 		log.Printf("响应失败: %v", err)
 	}
 
-	// 使用 worker pool 处理，按 project 分片锁，同项目同类型串行，不同项目并发
-	task := func() {
-		switch source {
-		case "nginx":
-			mu := nginxShards.getShard(project)
-			mu.Lock()
-			HandleNginxData(data, project)
-			mu.Unlock()
-		case "hard":
-			mu := hardShards.getShard(project)
-			mu.Lock()
-			HandleHardData(data, project)
-			mu.Unlock()
-		case "ssl":
-			mu := sslShards.getShard(project)
-			mu.Lock()
-			HandleSSLData(data, project)
-			mu.Unlock()
-		case "k8s":
-			mu := containerShards.getShard(project)
-			mu.Lock()
-			HandleContainerResourceData(data, project)
-			mu.Unlock()
-		case "heart":
-			mu := heartShards.getShard(project)
-			mu.Lock()
-			HandleHeartData(data, project)
-			mu.Unlock()
-		case "k8sController":
-			mu := controllerShards.getShard(project)
-			mu.Lock()
-			HandleControllertResourceData(data, project)
-			mu.Unlock()
-		case "trafficSwitching":
-			mu := trafficSwitchingShards.getShard(project)
-			mu.Lock()
-			HandleTrafficSwitchingData(data, project)
-			mu.Unlock()
-		}
-	}
-
-	// 非阻塞提交任务，队列满时降级为同步处理
-	select {
-	case taskQueue <- task:
-		// 成功提交到 worker pool
-	default:
-		// 队列满，直接执行避免请求堆积
-		go task()
-	}
+	// 归档解密后的原始数据（未启用时直接返回）
+	clientIP, _ := IpPass.GetClientIP(r)
+	Archive.Write(project, source, clientIP, receivedAt, decompressedData)
 }
@@ -0,0 +1,43 @@
+package Handers
+
+import (
+	"testing"
+	"time"
+
+	"monitor-server/Metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSubmitTaskQueueFull(t *testing.T) {
+	old := taskQueue
+	taskQueue = make(chan func(), 1)
+	defer func() { taskQueue = old }()
+	defer SetRejectWhenQueueFull(false)
+
+	// 没有 worker，第一个任务留在队列中，之后队列已满
+	if !submitTask("test-queue", func() {}) {
+		t.Fatal("submit to empty queue failed")
+	}
+
+	// 默认与旧版本一样另起 goroutine 处理
+	done := make(chan struct{})
+	inline := testutil.ToFloat64(Metrics.IngestQueueFullInline.WithLabelValues("test-queue"))
+	if !submitTask("test-queue", func() { close(done) }) {
+		t.Fatal("submit with full queue rejected, want inline fallback")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("inline task did not run")
+	}
+	if got := testutil.ToFloat64(Metrics.IngestQueueFullInline.WithLabelValues("test-queue")); got != inline+1 {
+		t.Errorf("ingest_queue_full_inline_total = %v, want %v", got, inline+1)
+	}
+
+	// 启用 rejectWhenFull 后由调用方返回 503
+	SetRejectWhenQueueFull(true)
+	if submitTask("test-queue", func() { t.Error("rejected task ran") }) {
+		t.Error("submit with full queue accepted, want rejection")
+	}
+}
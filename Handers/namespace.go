@@ -0,0 +1,68 @@
+package Handers
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+)
+
+// NamespaceRule namespace 归一化规则
+// 按顺序匹配，第一条匹配的规则生效；没有规则匹配时 namespace 保持原样、slot 为空
+type NamespaceRule struct {
+	Project string `yaml:"project"` // 生效的项目（项目编码或项目名称），为空时对所有项目生效
+	Match   string `yaml:"match"`   // 匹配原始 namespace 的正则
+	Replace string `yaml:"replace"` // 归一化后的 namespace，支持 $1、${name} 分组引用
+	Slot    string `yaml:"slot"`    // 蓝绿槽位，支持分组引用
+}
+
+type namespaceRule struct {
+	NamespaceRule
+	re *regexp.Regexp
+}
+
+// compileNamespaceRules 编译 namespace 归一化规则
+func compileNamespaceRules(rules []NamespaceRule) ([]namespaceRule, error) {
+	compiled := make([]namespaceRule, 0, len(rules))
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("namespace 规则 %d 的正则 %q 不合法: %v", i, rule.Match, err)
+		}
+		compiled = append(compiled, namespaceRule{NamespaceRule: rule, re: re})
+	}
+	return compiled, nil
+}
+
+// SetNamespaceRules 设置 namespace 归一化规则，正则不合法时返回错误并保留原规则
+func SetNamespaceRules(rules []NamespaceRule) error {
+	compiled, err := compileNamespaceRules(rules)
+	if err != nil {
+		return err
+	}
+	updateRuntime(func(s *runtimeSettings) {
+		s.namespaceRules = compiled
+	})
+	log.Printf("namespace 归一化规则: %d 条", len(compiled))
+	return nil
+}
+
+// normalizeNamespace 返回归一化后的 namespace 和 slot
+func normalizeNamespace(project, namespace string) (string, string) {
+	projectName := getProjectName(project)
+	for _, rule := range currentRuntime().namespaceRules {
+		if rule.Project != "" && rule.Project != project && rule.Project != projectName {
+			continue
+		}
+		match := rule.re.FindStringSubmatchIndex(namespace)
+		if match == nil {
+			continue
+		}
+		normalized := string(rule.re.ExpandString(nil, rule.Replace, namespace, match))
+		if normalized == "" {
+			normalized = namespace
+		}
+		slot := string(rule.re.ExpandString(nil, rule.Slot, namespace, match))
+		return normalized, slot
+	}
+	return namespace, ""
+}
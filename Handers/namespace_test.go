@@ -0,0 +1,60 @@
+package Handers
+
+import "testing"
+
+func TestNormalizeNamespace(t *testing.T) {
+	rules := []NamespaceRule{
+		// 只对 shop 项目生效：shop-blue / shop-green
+		{Project: "shop", Match: `^(?P<base>.+)-(?P<slot>blue|green)$`, Replace: "${base}", Slot: "${slot}"},
+		// 只改名不区分槽位
+		{Match: `^legacy-(.+)$`, Replace: "$1"},
+		// 只提取槽位，namespace 保持原样
+		{Match: `^canary-`, Slot: "canary"},
+		// 默认的 -v1/-v2 规则
+		{Match: `^(?P<base>.+)-(?P<slot>v1|v2)$`, Replace: "${base}", Slot: "${slot}"},
+	}
+	if err := SetNamespaceRules(rules); err != nil {
+		t.Fatal(err)
+	}
+	defer SetNamespaceRules(nil)
+
+	// 规则的 project 可以是编码或显示名称
+	useProjects(t, `{"shop": "商城", "p1": "shop"}`)
+
+	tests := []struct {
+		project, namespace      string
+		wantNamespace, wantSlot string
+	}{
+		{"shop", "order-blue", "order", "blue"},
+		{"p1", "order-green", "order", "green"}, // 按项目名称匹配
+		{"p2", "order-blue", "order-blue", ""},  // 规则限定了项目
+		{"p2", "legacy-pay", "pay", ""},
+		{"p2", "canary-pay", "canary-pay", "canary"},
+		{"p2", "pay-v2", "pay", "v2"},
+		{"p2", "pay-v3", "pay-v3", ""},
+		{"shop", "legacy-a-blue", "legacy-a", "blue"}, // 第一条匹配的规则生效
+		{"p2", "", "", ""},
+	}
+	for _, tt := range tests {
+		namespace, slot := normalizeNamespace(tt.project, tt.namespace)
+		if namespace != tt.wantNamespace || slot != tt.wantSlot {
+			t.Errorf("normalizeNamespace(%q, %q) = %q, %q; want %q, %q",
+				tt.project, tt.namespace, namespace, slot, tt.wantNamespace, tt.wantSlot)
+		}
+	}
+}
+
+func TestSetNamespaceRulesInvalid(t *testing.T) {
+	if err := SetNamespaceRules([]NamespaceRule{{Match: "^ok-(.+)$", Replace: "$1"}}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetNamespaceRules(nil)
+
+	// 正则不合法时返回错误，保留原规则
+	if err := SetNamespaceRules([]NamespaceRule{{Match: "(unclosed"}}); err == nil {
+		t.Fatal("expected error for invalid regexp")
+	}
+	if namespace, _ := normalizeNamespace("p", "ok-x"); namespace != "x" {
+		t.Errorf("rules replaced after invalid update: got %q", namespace)
+	}
+}
@@ -0,0 +1,332 @@
+package Handers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"monitor-server/Metrics"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Project 项目登记信息（projects.json）
+// 兼容旧格式："jxh": "戒享花" 等同于 "jxh": {"name": "戒享花"}
+type Project struct {
+	Code      string                  `json:"-"`
+	Name      string                  `json:"name"`      // 显示名称，为空时使用编码
+	Owner     string                  `json:"owner"`     // 负责人
+	Contact   string                  `json:"contact"`   // 联系方式（群、邮箱等）
+	Env       string                  `json:"env"`       // 环境，如 prod、test
+	Enabled   *bool                   `json:"enabled"`   // 为 false 时拒绝该项目的上报，默认启用
+	Sources   []string                `json:"sources"`   // 允许上报的 source，为空时不限制
+	Key       string                  `json:"key"`       // 项目独立的 AES 密钥，设置后只接受用该密钥加密的数据
+	OtlpToken string                  `json:"otlpToken"` // 项目独立的 OTLP 访问令牌，设置后该项目的 OTLP 数据只接受该令牌
+	TTL       jsonDuration            `json:"ttl"`       // 指标过期时间，为空时使用默认值
+	SourceTTL map[string]jsonDuration `json:"sourceTtl"` // 按 source 覆盖过期时间
+	Labels    map[string]string       `json:"labels"`    // 自定义标签，输出到 project_info
+}
+
+// jsonDuration 支持 "30s"、"5m" 格式的时长
+type jsonDuration time.Duration
+
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("时长需要是字符串，如 \"30s\"")
+	}
+	if s == "" {
+		*d = 0
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil || v < 0 {
+		return fmt.Errorf("时长 %q 不合法", s)
+	}
+	*d = jsonDuration(v)
+	return nil
+}
+
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	if d == 0 {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (p *Project) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &p.Name)
+	}
+	type plain Project
+	return json.Unmarshal(data, (*plain)(p))
+}
+
+// IsEnabled 项目是否启用
+func (p *Project) IsEnabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
+// allowsSource 项目是否允许上报该 source
+func (p *Project) allowsSource(source string) bool {
+	return len(p.Sources) == 0 || containsString(p.Sources, source)
+}
+
+// ttl 项目在该 source 上的过期时间，未覆盖时返回 0
+// 有默认过期时间的 source（如 otlp）只能用 sourceTtl 覆盖，项目的 ttl 不影响
+func (p *Project) ttl(source string) time.Duration {
+	if d, ok := p.SourceTTL[source]; ok && d > 0 {
+		return time.Duration(d)
+	}
+	if _, ok := sourceDefaultTTL[source]; ok {
+		return 0
+	}
+	return time.Duration(p.TTL)
+}
+
+// projectRegistry 按编码索引的项目
+type projectRegistry struct {
+	byCode map[string]*Project
+}
+
+var (
+	projects   = &projectRegistry{byCode: map[string]*Project{}}
+	projectsMu sync.RWMutex
+)
+
+// parseProjectRegistry 解析并校验 projects.json
+func parseProjectRegistry(content []byte) (*projectRegistry, error) {
+	var entries map[string]*Project
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("解析项目配置失败: %v", err)
+	}
+
+	registry := &projectRegistry{byCode: map[string]*Project{}}
+	byName := map[string]*Project{}
+	byKey := map[string]*Project{}
+	byToken := map[string]*Project{}
+	for code, p := range entries {
+		if p == nil {
+			p = &Project{}
+		}
+		if !isValidProject(code) {
+			return nil, fmt.Errorf("项目编码 %q 不合法", code)
+		}
+		p.Code = code
+		if p.Name == "" {
+			p.Name = code
+		}
+		if n := len(p.Key); n != 0 && n != 16 && n != 24 && n != 32 {
+			return nil, fmt.Errorf("项目 %s 的 key 长度应为 16/24/32 字节，当前: %d 字节", code, n)
+		}
+		for _, source := range p.Sources {
+			if !isValidSource(source) && source != "otlp" {
+				return nil, fmt.Errorf("项目 %s 的 sources 包含未知类型 %s", code, source)
+			}
+		}
+		if other, ok := byName[p.Name]; ok {
+			return nil, fmt.Errorf("项目 %s 和 %s 的显示名称重复: %s", other.Code, code, p.Name)
+		}
+		// 密钥和令牌决定数据归属的项目，重复时无法区分
+		if other, ok := byKey[p.Key]; ok && p.Key != "" {
+			return nil, fmt.Errorf("项目 %s 和 %s 的 key 重复", other.Code, code)
+		}
+		if other, ok := byToken[p.OtlpToken]; ok && p.OtlpToken != "" {
+			return nil, fmt.Errorf("项目 %s 和 %s 的 otlpToken 重复", other.Code, code)
+		}
+		registry.byCode[code] = p
+		byName[p.Name] = p
+		byKey[p.Key] = p
+		byToken[p.OtlpToken] = p
+	}
+	return registry, nil
+}
+
+// LoadProjectDict 读取项目登记（projects.json），格式错误时保留原登记并返回错误
+func LoadProjectDict(configPath string) error {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("无法读取项目配置: %v", err)
+	}
+	registry, err := parseProjectRegistry(content)
+	if err != nil {
+		return err
+	}
+	if err := registry.checkGlobalSecrets(string(GetEncryptionKey()), getOtlpToken()); err != nil {
+		return err
+	}
+
+	projectsMu.Lock()
+	old := projects
+	projects = registry
+	projectsMu.Unlock()
+
+	// 改名只影响 project_name，已有序列在下一次采集时使用新名称
+	for code, p := range registry.byCode {
+		if prev, ok := old.byCode[code]; ok && prev.Name != p.Name {
+			log.Printf("项目 %s 显示名称变更: %s -> %s", code, prev.Name, p.Name)
+		}
+	}
+
+	Metrics.ProjectInfo.Set(projectInfoRows(registry))
+	Metrics.SetProjectNames(registry.names())
+	log.Printf("已加载 %d 个项目", len(registry.byCode))
+	return nil
+}
+
+// WatchProjectDict 监听 projects.json 变化并重新加载
+func WatchProjectDict(configPath string) error {
+	return WatchFile(configPath, func() {
+		if err := LoadProjectDict(configPath); err != nil {
+			log.Printf("重新加载项目配置失败，保留原配置: %v", err)
+		} else {
+			log.Printf("项目配置已更新: %s", configPath)
+		}
+	})
+}
+
+// projectInfoRows 生成 project_info 的标签
+func projectInfoRows(registry *projectRegistry) []Metrics.ProjectInfoRow {
+	rows := make([]Metrics.ProjectInfoRow, 0, len(registry.byCode))
+	for _, p := range registry.byCode {
+		rows = append(rows, Metrics.ProjectInfoRow{
+			Code:    p.Code,
+			Owner:   p.Owner,
+			Contact: p.Contact,
+			Env:     p.Env,
+			Enabled: p.IsEnabled(),
+			Labels:  p.Labels,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Code < rows[j].Code })
+	return rows
+}
+
+// SetRejectUnknownProjects 设置是否拒绝未登记项目的上报
+func SetRejectUnknownProjects(reject bool) {
+	updateRuntime(func(s *runtimeSettings) {
+		s.rejectUnknownProjects = reject
+	})
+}
+
+func rejectUnknownProjectsEnabled() bool {
+	return currentRuntime().rejectUnknownProjects
+}
+
+// lookupProject 按编码查找项目
+func lookupProject(code string) (*Project, bool) {
+	projectsMu.RLock()
+	defer projectsMu.RUnlock()
+	p, ok := projects.byCode[code]
+	return p, ok
+}
+
+// checkGlobalSecrets 检查项目的密钥和令牌是否与全局配置冲突
+// 与加密盐或全局令牌相同时请求会被当作全局配置，项目永远无法通过校验
+func (r *projectRegistry) checkGlobalSecrets(encryptionKey, token string) error {
+	for code, p := range r.byCode {
+		if encryptionKey != "" && p.Key == encryptionKey {
+			return fmt.Errorf("项目 %s 的 key 与 encrypted 相同", code)
+		}
+		if token != "" && p.OtlpToken == token {
+			return fmt.Errorf("项目 %s 的 otlpToken 与 otlp.token 相同", code)
+		}
+	}
+	return nil
+}
+
+// CheckProjectSecrets 检查当前项目登记与新的全局配置是否冲突（重新加载配置前校验）
+func CheckProjectSecrets(encryptionKey, otlpToken string) error {
+	projectsMu.RLock()
+	defer projectsMu.RUnlock()
+	return projects.checkGlobalSecrets(encryptionKey, otlpToken)
+}
+
+// projectOtlpTokens 返回设置了 OTLP 令牌的项目，编码 -> 令牌
+func projectOtlpTokens() map[string][]byte {
+	projectsMu.RLock()
+	defer projectsMu.RUnlock()
+	tokens := map[string][]byte{}
+	for code, p := range projects.byCode {
+		if p.OtlpToken != "" {
+			tokens[code] = []byte(p.OtlpToken)
+		}
+	}
+	return tokens
+}
+
+// projectKeys 返回设置了独立密钥的项目，编码 -> 密钥
+func projectKeys() map[string][]byte {
+	projectsMu.RLock()
+	defer projectsMu.RUnlock()
+	keys := map[string][]byte{}
+	for code, p := range projects.byCode {
+		if p.Key != "" {
+			keys[code] = []byte(p.Key)
+		}
+	}
+	return keys
+}
+
+// admitProject 判断项目是否允许上报该 source，不允许时返回原因
+func admitProject(code, source string) (bool, string) {
+	p, ok := lookupProject(code)
+	switch {
+	case !ok && rejectUnknownProjectsEnabled():
+		return false, "unknown_project"
+	case !ok:
+		return true, ""
+	case !p.IsEnabled():
+		return false, "project_disabled"
+	case !p.allowsSource(source):
+		return false, "source_not_allowed"
+	}
+	return true, ""
+}
+
+// rejectMessages 拒绝原因 -> 返回给 agent 的提示
+var rejectMessages = map[string]string{
+	"unknown_project":    "项目未登记",
+	"project_disabled":   "项目已停用",
+	"source_not_allowed": "项目不允许上报该数据类型",
+	"key_mismatch":       "密钥与项目不匹配",
+}
+
+// projectTTL 项目在该 source 上的过期时间，未覆盖时返回 0
+func projectTTL(project, source string) time.Duration {
+	projectsMu.RLock()
+	defer projectsMu.RUnlock()
+	if p, ok := projects.byCode[project]; ok {
+		return p.ttl(source)
+	}
+	return 0
+}
+
+// names 项目编码 -> 显示名称
+func (r *projectRegistry) names() map[string]string {
+	names := make(map[string]string, len(r.byCode))
+	for code, p := range r.byCode {
+		names[code] = p.Name
+	}
+	return names
+}
+
+// ProjectNames 返回项目编码和显示名称的副本
+func ProjectNames() map[string]string {
+	projectsMu.RLock()
+	defer projectsMu.RUnlock()
+	return projects.names()
+}
+
+// 获取项目名称（线程安全）
+func getProjectName(project string) string {
+	projectsMu.RLock()
+	defer projectsMu.RUnlock()
+	if p, ok := projects.byCode[project]; ok {
+		return p.Name
+	}
+	return project
+}
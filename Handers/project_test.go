@@ -0,0 +1,167 @@
+package Handers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useProjects 替换项目登记，测试结束后恢复
+func useProjects(t *testing.T, content string) {
+	t.Helper()
+	registry, err := parseProjectRegistry([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectsMu.Lock()
+	old := projects
+	projects = registry
+	projectsMu.Unlock()
+	t.Cleanup(func() {
+		projectsMu.Lock()
+		projects = old
+		projectsMu.Unlock()
+	})
+}
+
+func encrypt(t *testing.T, key, plaintext []byte) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	return gcm.Seal(nonce, nonce, plaintext, nil)
+}
+
+func TestParseProjectRegistry(t *testing.T) {
+	registry, err := parseProjectRegistry([]byte(`{
+		"jxh": "戒享花",
+		"shop": {"name": "商城", "ttl": "10m", "sourceTtl": {"esIp": "1h"}, "sources": ["esIp", "hard"]},
+		"bare": {}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := registry.byCode["jxh"]; p == nil || p.Name != "戒享花" || !p.IsEnabled() {
+		t.Errorf("legacy entry = %+v", p)
+	}
+	if p := registry.byCode["bare"]; p == nil || p.Name != "bare" {
+		t.Errorf("entry without name = %+v, want name bare", p)
+	}
+	shop := registry.byCode["shop"]
+	if got := shop.ttl("esIp"); got != time.Hour {
+		t.Errorf("shop esIp ttl = %v, want 1h", got)
+	}
+	if got := shop.ttl("hard"); got != 10*time.Minute {
+		t.Errorf("shop hard ttl = %v, want 10m", got)
+	}
+	if shop.allowsSource("k8s") || !shop.allowsSource("esIp") {
+		t.Errorf("shop sources = %v", shop.Sources)
+	}
+
+	invalid := map[string]string{
+		"bad json":       `{"a": [}`,
+		"bad code":       `{"a b": "x"}`,
+		"key length":     `{"a": {"key": "short"}}`,
+		"unknown source": `{"a": {"sources": ["nope"]}}`,
+		"duplicate name": `{"a": "同名", "b": {"name": "同名"}}`,
+		"bad ttl":        `{"a": {"ttl": "soon"}}`,
+		"numeric ttl":    `{"a": {"ttl": 30}}`,
+	}
+	for name, content := range invalid {
+		if _, err := parseProjectRegistry([]byte(content)); err == nil {
+			t.Errorf("%s: parse succeeded, want error", name)
+		}
+	}
+}
+
+func TestAdmitProject(t *testing.T) {
+	useProjects(t, `{
+		"open": {},
+		"off": {"enabled": false},
+		"logs": {"sources": ["esIp"]}
+	}`)
+	defer SetRejectUnknownProjects(false)
+
+	tests := []struct {
+		code, source  string
+		rejectUnknown bool
+		want          string
+	}{
+		{"open", "hard", false, ""},
+		{"off", "hard", false, "project_disabled"},
+		{"logs", "esIp", false, ""},
+		{"logs", "hard", false, "source_not_allowed"},
+		{"missing", "hard", false, ""},
+		{"missing", "hard", true, "unknown_project"},
+		{"open", "hard", true, ""},
+	}
+	for _, tt := range tests {
+		SetRejectUnknownProjects(tt.rejectUnknown)
+		ok, reason := admitProject(tt.code, tt.source)
+		if reason != tt.want || ok != (tt.want == "") {
+			t.Errorf("admitProject(%s, %s) reject=%v = %v %q, want %q", tt.code, tt.source, tt.rejectUnknown, ok, reason, tt.want)
+		}
+		if tt.want != "" && rejectMessages[reason] == "" {
+			t.Errorf("reason %s has no message", reason)
+		}
+	}
+}
+
+func TestDecryptPayloadProjectKey(t *testing.T) {
+	salt := strings.Repeat("s", 16)
+	old := GetEncryptionKey()
+	SetEncryptionKey(salt)
+	defer updateRuntime(func(s *runtimeSettings) { s.encryptionKey = old })
+	useProjects(t, `{
+		"shop": {"key": "`+strings.Repeat("k", 16)+`"},
+		"pay": {"key": "`+strings.Repeat("p", 32)+`"}
+	}`)
+
+	for _, key := range []string{salt, strings.Repeat("k", 16), strings.Repeat("p", 32), strings.Repeat("x", 16)} {
+		plaintext, code, err := decryptPayload(encrypt(t, []byte(key), []byte("hello")))
+		switch key[0] {
+		case 's':
+			if err != nil || code != "" || string(plaintext) != "hello" {
+				t.Errorf("salt: %q %q %v", plaintext, code, err)
+			}
+		case 'k', 'p':
+			want := map[byte]string{'k': "shop", 'p': "pay"}[key[0]]
+			if err != nil || code != want || string(plaintext) != "hello" {
+				t.Errorf("%s key: %q %q %v", want, plaintext, code, err)
+			}
+		default:
+			if err == nil {
+				t.Errorf("unknown key decrypted as project %q", code)
+			}
+		}
+	}
+}
+
+func TestProjectLookup(t *testing.T) {
+	useProjects(t, `{"jxh": {"name": "戒享花", "sourceTtl": {"k8s": "2m"}}}`)
+
+	if got := getProjectName("jxh"); got != "戒享花" {
+		t.Errorf("getProjectName(jxh) = %q", got)
+	}
+	if got := getProjectName("other"); got != "other" {
+		t.Errorf("getProjectName(other) = %q", got)
+	}
+	// 指标的 project 标签是编码，过期时间按编码查找
+	if got := projectTTL("jxh", "k8s"); got != 2*time.Minute {
+		t.Errorf("projectTTL(jxh, k8s) = %v, want 2m", got)
+	}
+	if got := projectTTL("戒享花", "k8s"); got != 0 {
+		t.Errorf("projectTTL(戒享花, k8s) = %v, want 0", got)
+	}
+	if names := ProjectNames(); len(names) != 1 || names["jxh"] != "戒享花" {
+		t.Errorf("ProjectNames() = %v", names)
+	}
+}
@@ -0,0 +1,130 @@
+package Handers
+
+import (
+	"log"
+	"monitor-server/Metrics"
+	"monitor-server/Modles"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认发布卡住阈值
+const defaultRolloutStuckThreshold = 10 * time.Minute
+
+// SetRolloutStuckThreshold 设置发布无进展多久后视为卡住，<= 0 时使用默认值
+func SetRolloutStuckThreshold(threshold time.Duration) {
+	if threshold <= 0 {
+		threshold = defaultRolloutStuckThreshold
+	}
+	updateRuntime(func(s *runtimeSettings) {
+		s.rolloutStuckThreshold = threshold
+	})
+	log.Printf("发布卡住阈值: %v", threshold)
+}
+
+func getRolloutStuckThreshold() time.Duration {
+	return currentRuntime().rolloutStuckThreshold
+}
+
+// rolloutState 控制器的发布进度，进度变化或发布完成时刷新 lastProgress
+type rolloutState struct {
+	progress     [6]int64 // generation、replicas、observedGeneration、updated、ready、available
+	lastProgress time.Time
+}
+
+// controllerRollouts key 与 ControllerTimestamp 相同
+var controllerRollouts sync.Map
+
+// controllerConditionTypes 记录每个控制器输出过的 condition 类型，用于过期删除
+var controllerConditionTypes sync.Map
+
+// condition 状态取值
+var conditionStatuses = []string{"true", "false", "unknown"}
+
+// rolloutComplete 判断发布是否已完成
+func rolloutComplete(c Modles.ControllerResource) bool {
+	return c.ObservedGeneration >= c.Generation &&
+		c.ReplicasUpdated >= c.Replicas &&
+		c.ReplicasAvailable >= c.Replicas
+}
+
+// updateRolloutStuck 计算 rollout_stuck
+// 未上报 generation 的旧版 agent 无法判断发布进度，始终输出 0
+// 新的发布（generation 变化）和扩缩容（replicas 变化）都算作进度变化，发布完成期间持续刷新 lastProgress，
+// 避免空闲很久之后的新发布被立即判断为卡住
+func updateRolloutStuck(metricLabel string, labels []string, c Modles.ControllerResource, ts time.Time) {
+	progress := [6]int64{c.Generation, int64(c.Replicas), c.ObservedGeneration, int64(c.ReplicasUpdated), int64(c.ReplicasReady), int64(c.ReplicasAvailable)}
+	complete := rolloutComplete(c)
+
+	state := rolloutState{progress: progress, lastProgress: ts}
+	if previous, ok := controllerRollouts.Load(metricLabel); ok {
+		old := previous.(rolloutState)
+		if old.progress == progress && !complete {
+			state.lastProgress = old.lastProgress
+		}
+	}
+	controllerRollouts.Store(metricLabel, state)
+
+	stuck := 0.0
+	if c.Generation > 0 && !complete && ts.Sub(state.lastProgress) > getRolloutStuckThreshold() {
+		stuck = 1
+	}
+	for _, condition := range c.Conditions {
+		if condition.Type == "Progressing" && condition.Reason == "ProgressDeadlineExceeded" {
+			stuck = 1
+		}
+	}
+	Metrics.RolloutStuckMetric.WithLabelValues(labels...).Set(stuck)
+}
+
+// updateControllerConditions 输出控制器状态条件，不再上报的 condition 会被删除
+func updateControllerConditions(metricLabel string, labels []string, conditions []Modles.ControllerCondition) {
+	current := make([]string, 0, len(conditions))
+	for _, condition := range conditions {
+		if condition.Type == "" {
+			continue
+		}
+		status := strings.ToLower(condition.Status)
+		for _, s := range conditionStatuses {
+			value := 0.0
+			if s == status {
+				value = 1
+			}
+			Metrics.ControllerConditionMetric.WithLabelValues(append(labels, condition.Type, s)...).Set(value)
+		}
+		current = append(current, condition.Type)
+	}
+
+	if previous, ok := controllerConditionTypes.Swap(metricLabel, current); ok {
+		for _, conditionType := range previous.([]string) {
+			if !containsString(current, conditionType) {
+				deleteCondition(labels, conditionType)
+			}
+		}
+	}
+}
+
+// deleteControllerConditions 删除控制器全部 condition（过期时调用）
+func deleteControllerConditions(metricLabel string, labels []string) {
+	if previous, ok := controllerConditionTypes.LoadAndDelete(metricLabel); ok {
+		for _, conditionType := range previous.([]string) {
+			deleteCondition(labels, conditionType)
+		}
+	}
+}
+
+func deleteCondition(labels []string, conditionType string) {
+	for _, s := range conditionStatuses {
+		Metrics.ControllerConditionMetric.DeleteLabelValues(append(labels, conditionType, s)...)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package Handers
+
+import (
+	"testing"
+	"time"
+
+	"monitor-server/Metrics"
+	"monitor-server/Modles"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestUpdateRolloutStuck(t *testing.T) {
+	SetRolloutStuckThreshold(10 * time.Minute)
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	controller := func(generation, observed int64, replicas, updated, available int32) Modles.ControllerResource {
+		return Modles.ControllerResource{
+			Replicas:           replicas,
+			ReplicasUpdated:    updated,
+			ReplicasReady:      available,
+			ReplicasAvailable:  available,
+			Generation:         generation,
+			ObservedGeneration: observed,
+		}
+	}
+	type report struct {
+		after      time.Duration
+		controller Modles.ControllerResource
+		wantStuck  float64
+	}
+
+	tests := []struct {
+		name    string
+		reports []report
+	}{
+		{"complete rollout", []report{
+			{0, controller(2, 2, 3, 3, 3), 0},
+			{time.Hour, controller(2, 2, 3, 3, 3), 0},
+		}},
+		{"progressing rollout", []report{
+			{0, controller(3, 3, 3, 1, 3), 0},
+			{8 * time.Minute, controller(3, 3, 3, 2, 3), 0},
+			{16 * time.Minute, controller(3, 3, 3, 3, 3), 0},
+		}},
+		{"no progress past threshold", []report{
+			{0, controller(3, 3, 3, 1, 3), 0},
+			{9 * time.Minute, controller(3, 3, 3, 1, 3), 0},
+			{11 * time.Minute, controller(3, 3, 3, 1, 3), 1},
+			{12 * time.Minute, controller(3, 3, 3, 2, 3), 0}, // 恢复进展
+		}},
+		{"generation not observed", []report{
+			{0, controller(4, 3, 3, 3, 3), 0},
+			{11 * time.Minute, controller(4, 3, 3, 3, 3), 1},
+		}},
+		{"new rollout after idle period", []report{
+			{0, controller(4, 4, 3, 3, 3), 0},
+			{2 * time.Hour, controller(4, 4, 3, 3, 3), 0},
+			{2*time.Hour + time.Minute, controller(5, 4, 3, 3, 3), 0}, // 空闲两小时后开始新的发布
+			{2*time.Hour + 5*time.Minute, controller(5, 5, 3, 1, 3), 0},
+			{2*time.Hour + 16*time.Minute, controller(5, 5, 3, 1, 3), 1},
+		}},
+		{"scale up after idle period", []report{
+			{0, controller(2, 2, 3, 3, 3), 0},
+			{2 * time.Hour, controller(2, 2, 5, 3, 3), 0},
+			{2*time.Hour + 11*time.Minute, controller(2, 2, 5, 3, 3), 1},
+		}},
+		{"legacy agent without generation", []report{
+			{0, controller(0, 0, 3, 0, 1), 0},
+			{time.Hour, controller(0, 0, 3, 0, 1), 0},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := []string{"ns", "ns", "", "api-" + tt.name, "Deployment", "test-rollout"}
+			metricLabel := JoinLabels(labels...)
+			defer controllerRollouts.Delete(metricLabel)
+
+			for i, r := range tt.reports {
+				updateRolloutStuck(metricLabel, labels, r.controller, start.Add(r.after))
+				if got := testutil.ToFloat64(Metrics.RolloutStuckMetric.WithLabelValues(labels...)); got != r.wantStuck {
+					t.Errorf("report %d: rollout_stuck = %v, want %v", i, got, r.wantStuck)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateRolloutStuckProgressDeadline(t *testing.T) {
+	labels := []string{"ns", "ns", "", "api-deadline", "Deployment", "test-rollout"}
+	metricLabel := JoinLabels(labels...)
+	defer controllerRollouts.Delete(metricLabel)
+
+	// 控制器已报告 ProgressDeadlineExceeded 时立即视为卡住
+	c := Modles.ControllerResource{
+		Replicas: 3, ReplicasUpdated: 1, ReplicasAvailable: 2, Generation: 5, ObservedGeneration: 5,
+		Conditions: []Modles.ControllerCondition{{Type: "Progressing", Status: "False", Reason: "ProgressDeadlineExceeded"}},
+	}
+	updateRolloutStuck(metricLabel, labels, c, time.Now())
+	if got := testutil.ToFloat64(Metrics.RolloutStuckMetric.WithLabelValues(labels...)); got != 1 {
+		t.Errorf("rollout_stuck = %v, want 1", got)
+	}
+}
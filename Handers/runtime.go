@@ -0,0 +1,146 @@
+package Handers
+
+import (
+	"errors"
+	"log"
+	"monitor-server/Metrics"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Runtime 可以在运行时修改的上报处理配置
+type Runtime struct {
+	EncryptionKey         string          // 加密盐
+	Otlp                  OtlpConfig      // OTLP 接收
+	Timestamp             TimestampConfig // agent 时间戳
+	MetricTTL             time.Duration   // 指标过期时间，<= 0 时使用默认值
+	MaxBodySizeMB         int             // 请求体大小上限（MB），<= 0 时使用默认值
+	RejectWhenQueueFull   bool            // 队列满时拒绝上报
+	HardLegacyLabels      bool            // 输出旧版硬件指标
+	LegacyCounterGauges   bool            // 输出 gauge 类型的旧版累计指标
+	RolloutStuckAfter     time.Duration   // 发布卡住阈值，<= 0 时使用默认值
+	TopN                  int             // 日志统计排行数量，<= 0 时使用默认值
+	RejectUnknownProjects bool            // 拒绝未登记的项目
+	NamespaceRules        []NamespaceRule // namespace 归一化规则
+}
+
+// runtimeSettings 当前生效的运行时配置快照
+// 快照创建后不再修改，修改配置时复制一份后整体替换，处理中的请求看到的要么全是旧配置，要么全是新配置
+type runtimeSettings struct {
+	encryptionKey         []byte
+	otlpToken             string
+	timestamp             TimestampConfig
+	metricTTL             time.Duration
+	maxRequestBodySize    int64
+	rejectWhenQueueFull   bool
+	hardLegacyLabels      bool
+	legacyCounterGauges   bool
+	rolloutStuckThreshold time.Duration
+	topN                  int
+	rejectUnknownProjects bool
+	namespaceRules        []namespaceRule
+}
+
+var (
+	runtimeSnapshot atomic.Pointer[runtimeSettings]
+	runtimeMu       sync.Mutex // 串行化替换，读取不加锁
+)
+
+func init() {
+	runtimeSnapshot.Store(&runtimeSettings{
+		metricTTL:             defaultMetricTTL,
+		maxRequestBodySize:    defaultMaxRequestBodySize,
+		rolloutStuckThreshold: defaultRolloutStuckThreshold,
+		topN:                  defaultTopN,
+	})
+}
+
+// currentRuntime 返回当前生效的配置快照，调用方不能修改
+func currentRuntime() *runtimeSettings {
+	return runtimeSnapshot.Load()
+}
+
+// updateRuntime 复制当前配置，修改后整体替换
+func updateRuntime(update func(*runtimeSettings)) {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+
+	old := runtimeSnapshot.Load()
+	next := *old
+	update(&next)
+	runtimeSnapshot.Store(&next)
+
+	// 关闭旧版指标时立即清空
+	if old.hardLegacyLabels && !next.hardLegacyLabels {
+		Metrics.ResetHardLegacyMetrics()
+	}
+	if old.legacyCounterGauges && !next.legacyCounterGauges {
+		Metrics.ResetLegacyCounterGauges()
+	}
+}
+
+// PreparedRuntime 已校验、等待应用的运行时配置
+type PreparedRuntime struct {
+	settings runtimeSettings
+}
+
+// PrepareRuntime 校验运行时配置并编译 namespace 规则，不修改当前配置
+// 有任何一项不合法时返回全部错误
+func PrepareRuntime(rt Runtime) (*PreparedRuntime, error) {
+	var problems []string
+
+	key, err := checkEncryptionKey(rt.EncryptionKey)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	rules, err := compileNamespaceRules(rt.NamespaceRules)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+
+	s := runtimeSettings{
+		encryptionKey:         key,
+		otlpToken:             rt.Otlp.Token,
+		timestamp:             rt.Timestamp,
+		metricTTL:             rt.MetricTTL,
+		maxRequestBodySize:    int64(rt.MaxBodySizeMB) * 1024 * 1024,
+		rejectWhenQueueFull:   rt.RejectWhenQueueFull,
+		hardLegacyLabels:      rt.HardLegacyLabels,
+		legacyCounterGauges:   rt.LegacyCounterGauges,
+		rolloutStuckThreshold: rt.RolloutStuckAfter,
+		topN:                  rt.TopN,
+		rejectUnknownProjects: rt.RejectUnknownProjects,
+		namespaceRules:        rules,
+	}
+	if s.timestamp.ClockSkew < 0 {
+		s.timestamp.ClockSkew = 0
+	}
+	if s.metricTTL <= 0 {
+		s.metricTTL = defaultMetricTTL
+	}
+	if s.maxRequestBodySize <= 0 {
+		s.maxRequestBodySize = defaultMaxRequestBodySize
+	}
+	if s.rolloutStuckThreshold <= 0 {
+		s.rolloutStuckThreshold = defaultRolloutStuckThreshold
+	}
+	if s.topN <= 0 {
+		s.topN = defaultTopN
+	}
+	return &PreparedRuntime{settings: s}, nil
+}
+
+// Apply 整体替换当前运行时配置
+func (p *PreparedRuntime) Apply() {
+	updateRuntime(func(s *runtimeSettings) {
+		*s = p.settings
+	})
+	s := p.settings
+	log.Printf("运行时配置: metricTTL=%v, agent 时间戳 enabled=%v clockSkew=%v, 发布卡住阈值=%v, topN=%d, namespace 归一化规则 %d 条",
+		s.metricTTL, s.timestamp.Enabled, s.timestamp.ClockSkew, s.rolloutStuckThreshold, s.topN, len(s.namespaceRules))
+}
@@ -0,0 +1,75 @@
+package Handers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrepareRuntime(t *testing.T) {
+	key := strings.Repeat("k", 16)
+	tests := []struct {
+		name    string
+		rt      Runtime
+		wantErr []string
+		check   func(s runtimeSettings) bool
+	}{
+		{
+			name:    "bad key and rule reported together",
+			rt:      Runtime{EncryptionKey: "short", NamespaceRules: []NamespaceRule{{Match: "("}}},
+			wantErr: []string{"AES", "namespace"},
+		},
+		{
+			name: "defaults for unset values",
+			rt:   Runtime{EncryptionKey: key, Timestamp: TimestampConfig{ClockSkew: -time.Second}},
+			check: func(s runtimeSettings) bool {
+				return s.metricTTL == defaultMetricTTL && s.maxRequestBodySize == defaultMaxRequestBodySize &&
+					s.rolloutStuckThreshold == defaultRolloutStuckThreshold && s.topN == defaultTopN && s.timestamp.ClockSkew == 0
+			},
+		},
+		{
+			name: "explicit values",
+			rt:   Runtime{EncryptionKey: key, MetricTTL: time.Minute, MaxBodySizeMB: 2, TopN: 5, NamespaceRules: []NamespaceRule{{Match: "^a$"}}},
+			check: func(s runtimeSettings) bool {
+				return s.metricTTL == time.Minute && s.maxRequestBodySize == 2<<20 && s.topN == 5 && len(s.namespaceRules) == 1
+			},
+		},
+	}
+	for _, tt := range tests {
+		prepared, err := PrepareRuntime(tt.rt)
+		if len(tt.wantErr) > 0 {
+			for _, want := range tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("%s: error = %v, want containing %q", tt.name, err, want)
+				}
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !tt.check(prepared.settings) {
+			t.Errorf("%s: settings = %+v", tt.name, prepared.settings)
+		}
+	}
+}
+
+func TestApplyRuntimeSwapsSnapshot(t *testing.T) {
+	old := currentRuntime()
+	defer runtimeSnapshot.Store(old)
+
+	prepared, err := PrepareRuntime(Runtime{EncryptionKey: strings.Repeat("k", 16), TopN: 7, RejectUnknownProjects: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prepared.Apply()
+	if getTopN() != 7 || !rejectUnknownProjectsEnabled() || string(GetEncryptionKey()) != strings.Repeat("k", 16) {
+		t.Errorf("after Apply: topN=%d reject=%v", getTopN(), rejectUnknownProjectsEnabled())
+	}
+	// 已发出的快照不受后续修改影响
+	snapshot := currentRuntime()
+	SetTopN(9)
+	if snapshot.topN != 7 || getTopN() != 9 {
+		t.Errorf("snapshot topN=%d current=%d, want 7 and 9", snapshot.topN, getTopN())
+	}
+}
@@ -0,0 +1,152 @@
+package Handers
+
+import (
+	"log"
+	"math"
+	"monitor-server/Metrics"
+	"monitor-server/Modles"
+	"sync"
+	"time"
+)
+
+// 指标过期时间：超过该时间未更新的指标会被删除，默认 20 秒
+const defaultMetricTTL = 20 * time.Second
+
+// SetMetricTTL 设置指标过期时间（项目登记中的 ttl/sourceTtl 优先）
+func SetMetricTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	updateRuntime(func(s *runtimeSettings) {
+		s.metricTTL = ttl
+	})
+}
+
+func getMetricTTL() time.Duration {
+	return currentRuntime().metricTTL
+}
+
+// TimestampConfig agent 时间戳配置
+type TimestampConfig struct {
+	Enabled   bool          `yaml:"enabled"`   // 使用数据项中的 timestamp 字段作为采样时间
+	ClockSkew time.Duration `yaml:"clockSkew"` // 允许的 agent 与服务端时钟偏差
+}
+
+// SetTimestampConfig 设置 agent 时间戳配置（线程安全）
+func SetTimestampConfig(cfg TimestampConfig) {
+	if cfg.ClockSkew < 0 {
+		cfg.ClockSkew = 0
+	}
+	updateRuntime(func(s *runtimeSettings) {
+		s.timestamp = cfg
+	})
+	log.Printf("agent 时间戳: enabled=%v, clockSkew=%v", cfg.Enabled, cfg.ClockSkew)
+}
+
+func getTimestampConfig() TimestampConfig {
+	return currentRuntime().timestamp
+}
+
+// parseAgentTimestamp 解析 agent 时间戳，兼容秒（可带小数）和毫秒
+func parseAgentTimestamp(ts float64) time.Time {
+	if ts > 1e12 {
+		return time.UnixMilli(int64(ts))
+	}
+	sec, frac := math.Modf(ts)
+	return time.Unix(int64(sec), int64(frac*1e9))
+}
+
+// agentTime 数据项的 agent 原始时间，未启用或未携带 timestamp 时为接收时间
+func agentTime(agentTs float64, receivedAt time.Time) time.Time {
+	if !getTimestampConfig().Enabled || agentTs <= 0 {
+		return receivedAt
+	}
+	return parseAgentTimestamp(agentTs)
+}
+
+// sampleTime 计算数据项的采样时间
+// agent 时间超前服务端超过 clockSkew 时视为时钟不准，按接收时间处理；落后的时间保持不变，
+// 由 acceptSample 判断是否已经陈旧
+func sampleTime(agentTs float64, receivedAt time.Time) time.Time {
+	t := agentTime(agentTs, receivedAt)
+	if t.After(receivedAt.Add(getTimestampConfig().ClockSkew)) {
+		return receivedAt
+	}
+	return t
+}
+
+// agentTimeKey 乱序判断按 source、项目和标签组合记录上次接受的 agent 原始时间
+type agentTimeKey struct {
+	source, project, metricLabel string
+}
+
+// lastAgentTimes agentTimeKey -> 上次接受的 agent 原始时间
+var lastAgentTimes sync.Map
+
+// acceptSample 判断数据项是否可以写入，通过时记录其 agent 原始时间
+// 早于 接收时间 - (TTL + clockSkew) 的数据已经过期，写入后会立即被清理，按陈旧丢弃；
+// agent 原始时间早于该标签组合上次接受的时间（乱序）的数据也会被丢弃。
+// 乱序按原始时间而不是 sampleTime 判断，超前的时间被替换为接收时间后仍能识别乱序
+func acceptSample(source, project, metricLabel string, agentTs float64, receivedAt time.Time) bool {
+	t := agentTime(agentTs, receivedAt)
+	if receivedAt.Sub(t) > expireAfter(project, source) {
+		Metrics.IngestDroppedSamples.WithLabelValues(source, "stale").Inc()
+		return false
+	}
+
+	key := agentTimeKey{source: source, project: project, metricLabel: metricLabel}
+	if last, ok := lastAgentTimes.Load(key); ok && t.Before(last.(time.Time)) {
+		Metrics.IngestDroppedSamples.WithLabelValues(source, "out_of_order").Inc()
+		return false
+	}
+	lastAgentTimes.Store(key, t)
+	return true
+}
+
+// CheckAgentTimes 清理已过期标签组合的 agent 原始时间
+// 超过 TTL + clockSkew 的记录不再影响乱序判断（更早的数据会按陈旧丢弃）
+func CheckAgentTimes() {
+	currentTime := time.Now()
+	lastAgentTimes.Range(func(k, v interface{}) bool {
+		key := k.(agentTimeKey)
+		if isExpired(key.project, key.source, currentTime, v.(time.Time)) {
+			lastAgentTimes.Delete(key)
+		}
+		return true
+	})
+}
+
+// appendSample 记录通过校验的数据项，下游输出使用与指标相同的采样时间
+func appendSample(samples []Modles.Sample, item interface{}, t time.Time) []Modles.Sample {
+	if fields, ok := item.(map[string]interface{}); ok {
+		samples = append(samples, Modles.Sample{Item: fields, Time: t})
+	}
+	return samples
+}
+
+// sourceDefaultTTL 上报间隔较长的 source 的默认过期时间，不受 metricTTL 和项目 ttl 影响，
+// 只能用项目登记的 sourceTtl 覆盖
+// OTLP collector 默认每 60 秒导出一次，使用 metricTTL（默认 20s）会在两次导出之间删除全部序列
+var sourceDefaultTTL = map[string]time.Duration{
+	"otlp": 3 * time.Minute,
+}
+
+// expireAfter 指标过期时间（项目登记可按项目和 source 覆盖），启用 agent 时间戳时加上时钟偏差容忍
+func expireAfter(project, source string) time.Duration {
+	ttl := getMetricTTL()
+	if d, ok := sourceDefaultTTL[source]; ok {
+		ttl = d
+	}
+	if override := projectTTL(project, source); override > 0 {
+		ttl = override
+	}
+	if cfg := getTimestampConfig(); cfg.Enabled {
+		return ttl + cfg.ClockSkew
+	}
+	return ttl
+}
+
+// isExpired 判断最后一次采样时间是否已过期
+func isExpired(project, source string, currentTime, timestamp time.Time) bool {
+	return currentTime.Sub(timestamp) > expireAfter(project, source)
+}
@@ -0,0 +1,139 @@
+package Handers
+
+import (
+	"testing"
+	"time"
+
+	"monitor-server/Metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// enableAgentTimestamps 启用 agent 时间戳，测试结束后恢复
+func enableAgentTimestamps(t *testing.T, clockSkew time.Duration) {
+	t.Helper()
+	old := getTimestampConfig()
+	SetTimestampConfig(TimestampConfig{Enabled: true, ClockSkew: clockSkew})
+	t.Cleanup(func() { SetTimestampConfig(old) })
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
+
+func TestSampleTime(t *testing.T) {
+	enableAgentTimestamps(t, 10*time.Second)
+	received := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name    string
+		agentTs float64
+		want    time.Time
+	}{
+		{"no timestamp", 0, received},
+		{"seconds", unixSeconds(received.Add(-3 * time.Second)), received.Add(-3 * time.Second)},
+		{"milliseconds", float64(received.Add(-1500 * time.Millisecond).UnixMilli()), received.Add(-1500 * time.Millisecond)},
+		{"ahead within skew", unixSeconds(received.Add(5 * time.Second)), received.Add(5 * time.Second)},
+		{"ahead beyond skew is clamped", unixSeconds(received.Add(time.Minute)), received},
+		{"behind beyond skew is kept", unixSeconds(received.Add(-time.Minute)), received.Add(-time.Minute)},
+	}
+	for _, tt := range tests {
+		if got := sampleTime(tt.agentTs, received); !got.Equal(tt.want) {
+			t.Errorf("%s: sampleTime = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAcceptSample(t *testing.T) {
+	enableAgentTimestamps(t, 10*time.Second)
+	SetMetricTTL(20 * time.Second)
+	received := time.Unix(1700000000, 0)
+	const source = "test-accept"
+
+	dropped := func(reason string) float64 {
+		return testutil.ToFloat64(Metrics.IngestDroppedSamples.WithLabelValues(source, reason))
+	}
+
+	// 每一步依次上报同一标签组合，agent 时间相对 received
+	steps := []struct {
+		name      string
+		agent     time.Duration
+		want      bool
+		wantStale float64
+		wantOrder float64
+	}{
+		{"behind within ttl+skew", -25 * time.Second, true, 0, 0},
+		{"older than ttl+skew is stale", -31 * time.Second, false, 1, 0},
+		{"newer sample", -5 * time.Second, true, 1, 0},
+		// 两个超前的时间都被替换为接收时间，按原始时间仍能识别乱序
+		{"far ahead", 2 * time.Minute, true, 1, 0},
+		{"ahead but earlier than previous", time.Minute, false, 1, 1},
+		{"behind previous ahead sample", -time.Second, false, 1, 2},
+	}
+	staleBase, orderBase := dropped("stale"), dropped("out_of_order")
+	for _, step := range steps {
+		got := acceptSample(source, "p", "label", unixSeconds(received.Add(step.agent)), received)
+		if got != step.want {
+			t.Errorf("%s: acceptSample = %v, want %v", step.name, got, step.want)
+		}
+		if stale := dropped("stale") - staleBase; stale != step.wantStale {
+			t.Errorf("%s: stale drops = %v, want %v", step.name, stale, step.wantStale)
+		}
+		if order := dropped("out_of_order") - orderBase; order != step.wantOrder {
+			t.Errorf("%s: out_of_order drops = %v, want %v", step.name, order, step.wantOrder)
+		}
+	}
+
+	// 其他标签组合不受影响
+	if !acceptSample(source, "p", "other", unixSeconds(received.Add(-time.Second)), received) {
+		t.Error("other label rejected")
+	}
+}
+
+func TestCheckAgentTimes(t *testing.T) {
+	SetMetricTTL(20 * time.Second)
+	now := time.Now()
+	fresh := agentTimeKey{source: "test-prune", project: "p", metricLabel: "fresh"}
+	old := agentTimeKey{source: "test-prune", project: "p", metricLabel: "old"}
+	lastAgentTimes.Store(fresh, now)
+	lastAgentTimes.Store(old, now.Add(-time.Hour))
+	defer lastAgentTimes.Delete(fresh)
+
+	CheckAgentTimes()
+	if _, ok := lastAgentTimes.Load(old); ok {
+		t.Error("expired agent time kept")
+	}
+	if _, ok := lastAgentTimes.Load(fresh); !ok {
+		t.Error("fresh agent time removed")
+	}
+}
+
+func TestExpireAfter(t *testing.T) {
+	SetMetricTTL(20 * time.Second)
+	useProjects(t, `{
+		"short": {"ttl": "10s"},
+		"slow": {"ttl": "10s", "sourceTtl": {"otlp": "90s"}}
+	}`)
+
+	tests := []struct {
+		project, source string
+		want            time.Duration
+	}{
+		{"none", "hard", 20 * time.Second},
+		{"short", "hard", 10 * time.Second},
+		// otlp 默认过期时间大于 collector 导出间隔，项目 ttl 不影响
+		{"none", "otlp", 3 * time.Minute},
+		{"short", "otlp", 3 * time.Minute},
+		{"slow", "otlp", 90 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := expireAfter(tt.project, tt.source); got != tt.want {
+			t.Errorf("expireAfter(%s, %s) = %v, want %v", tt.project, tt.source, got, tt.want)
+		}
+	}
+
+	enableAgentTimestamps(t, 10*time.Second)
+	if got := expireAfter("none", "otlp"); got != 3*time.Minute+10*time.Second {
+		t.Errorf("expireAfter with clock skew = %v", got)
+	}
+}
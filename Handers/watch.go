@@ -0,0 +1,50 @@
+package Handers
+
+import (
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 合并短时间内的多次文件事件
+const watchDebounce = 500 * time.Millisecond
+
+// WatchFile 监听文件变化，写入完成后调用 onChange
+// 监听所在目录，兼容编辑器先写临时文件再改名的保存方式
+func WatchFile(path string, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	target := filepath.Clean(path)
+	go func() {
+		var changed <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == target && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					changed = time.After(watchDebounce)
+				}
+			case <-changed:
+				changed = nil
+				onChange()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("监听文件 %s 失败: %v", path, err)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,302 @@
+package History
+
+import (
+	"fmt"
+	"log"
+	"monitor-server/Metrics"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config 短期历史配置
+type Config struct {
+	Enabled    bool          `yaml:"enabled"`
+	Retention  time.Duration `yaml:"retention"`  // 每条序列保留时长
+	Resolution time.Duration `yaml:"resolution"` // 点的最小间隔，同一区间内的多次更新只保留最后一次，每条序列最多保留 retention/resolution 个点
+	MaxSeries  int           `yaml:"maxSeries"`  // 序列数上限，超出后新序列不记录
+	Metrics    []string      `yaml:"metrics"`    // 只记录名称匹配这些正则的指标，为空时记录全部
+}
+
+// 默认值
+const (
+	defaultRetention  = time.Hour
+	defaultResolution = 15 * time.Second
+	defaultMaxSeries  = 20000
+)
+
+func (c *Config) applyDefaults() {
+	if c.Retention <= 0 {
+		c.Retention = defaultRetention
+	}
+	if c.Resolution <= 0 {
+		c.Resolution = defaultResolution
+	}
+	if c.MaxSeries <= 0 {
+		c.MaxSeries = defaultMaxSeries
+	}
+}
+
+// Point 一个采样点
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// series 一条序列的环形缓冲区
+type series struct {
+	labels map[string]string // 包含 __name__
+	points []Point           // 未写满前按顺序追加，写满后从 head 开始覆盖
+	head   int
+}
+
+// add 追加一个点，与最新的点在同一 resolution 区间内时替换最新的点
+func (s *series) add(p Point, capacity int, resolution time.Duration) {
+	if n := len(s.points); n > 0 {
+		last := s.head - 1
+		if last < 0 {
+			last = n - 1
+		}
+		if p.Time.Before(s.points[last].Time) {
+			return
+		}
+		if p.Time.Truncate(resolution).Equal(s.points[last].Time.Truncate(resolution)) {
+			s.points[last] = p
+			return
+		}
+	}
+	if len(s.points) < capacity {
+		s.points = append(s.points, p)
+		return
+	}
+	s.points[s.head] = p
+	s.head = (s.head + 1) % capacity
+}
+
+// ordered 按时间顺序返回全部点
+func (s *series) ordered() []Point {
+	result := make([]Point, 0, len(s.points))
+	result = append(result, s.points[s.head:]...)
+	return append(result, s.points[:s.head]...)
+}
+
+func (s *series) newest() time.Time {
+	if len(s.points) == 0 {
+		return time.Time{}
+	}
+	i := s.head - 1
+	if i < 0 {
+		i = len(s.points) - 1
+	}
+	return s.points[i].Time
+}
+
+// store 全部序列，未启用时为 nil
+type store struct {
+	cfg      Config
+	capacity int
+	include  []*regexp.Regexp
+	included sync.Map // 指标名称 -> 是否记录，缓存正则匹配结果
+	mu       sync.RWMutex
+	series   map[string]*series
+}
+
+var (
+	current   *store
+	currentMu sync.RWMutex
+)
+
+// Start 记录每次 gauge/counter 指标更新，写入每条序列的环形缓冲区
+// 点的时间为写入指标的时间，序列超过 retention 没有更新后删除
+func Start(cfg Config) error {
+	cfg.applyDefaults()
+	if cfg.Resolution > cfg.Retention {
+		return fmt.Errorf("history.resolution 不能大于 history.retention")
+	}
+	s := &store{
+		cfg:      cfg,
+		capacity: int(cfg.Retention / cfg.Resolution),
+		series:   map[string]*series{},
+	}
+	for _, pattern := range cfg.Metrics {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("history.metrics 正则 %s 不合法: %v", pattern, err)
+		}
+		s.include = append(s.include, re)
+	}
+
+	currentMu.Lock()
+	current = s
+	currentMu.Unlock()
+	Metrics.SetSeriesRecorder(s.record)
+
+	go func() {
+		ticker := time.NewTicker(cfg.Resolution)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			s.cleanup(now)
+		}
+	}()
+
+	log.Printf("[History] 已启动，保留: %v，间隔: %v，序列上限: %d", cfg.Retention, cfg.Resolution, cfg.MaxSeries)
+	return nil
+}
+
+// shouldRecord 指标名称是否匹配 history.metrics
+func (s *store) shouldRecord(name string) bool {
+	if len(s.include) == 0 {
+		return true
+	}
+	if v, ok := s.included.Load(name); ok {
+		return v.(bool)
+	}
+	matched := false
+	for _, re := range s.include {
+		if re.MatchString(name) {
+			matched = true
+			break
+		}
+	}
+	s.included.Store(name, matched)
+	return matched
+}
+
+func seriesKey(name string, labelNames, labelValues []string) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for i, labelName := range labelNames {
+		b.WriteByte(0xff)
+		b.WriteString(labelName)
+		b.WriteByte('=')
+		if i < len(labelValues) {
+			b.WriteString(labelValues[i])
+		}
+	}
+	return b.String()
+}
+
+// record 记录一次指标更新（Metrics.SeriesRecorder）
+func (s *store) record(name string, labelNames, labelValues []string, value float64, t time.Time) {
+	if !s.shouldRecord(name) {
+		return
+	}
+	key := seriesKey(name, labelNames, labelValues)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sr, ok := s.series[key]
+	if !ok {
+		if len(s.series) >= s.cfg.MaxSeries {
+			Metrics.HistoryDroppedSeries.Inc()
+			return
+		}
+		labels := make(map[string]string, len(labelNames)+1)
+		labels["__name__"] = name
+		for i, labelName := range labelNames {
+			if i < len(labelValues) {
+				labels[labelName] = labelValues[i]
+			}
+		}
+		sr = &series{labels: labels}
+		s.series[key] = sr
+	}
+	sr.add(Point{Time: t, Value: value}, s.capacity, s.cfg.Resolution)
+}
+
+// cleanup 删除超过保留时长没有更新的序列
+func (s *store) cleanup(now time.Time) {
+	s.mu.Lock()
+	points := 0
+	for key, sr := range s.series {
+		if now.Sub(sr.newest()) > s.cfg.Retention {
+			delete(s.series, key)
+			continue
+		}
+		points += len(sr.points)
+	}
+	count := len(s.series)
+	s.mu.Unlock()
+
+	Metrics.HistorySeries.Set(float64(count))
+	Metrics.HistoryPoints.Set(float64(points))
+}
+
+// Series 查询结果中的一条序列
+type Series struct {
+	Labels map[string]string
+	Points []Point
+}
+
+// Query 返回匹配选择器的序列在 [from, to] 内的点，limit 为返回序列数上限（<=0 不限制）
+// 未启用时返回错误
+func Query(selector string, from, to time.Time, limit int) ([]Series, error) {
+	currentMu.RLock()
+	s := current
+	currentMu.RUnlock()
+	if s == nil {
+		return nil, fmt.Errorf("短期历史未启用")
+	}
+
+	matchers, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []Series
+	for _, sr := range s.series {
+		// 内部保存项目编码，按当前项目登记展开为 project_code/project_name，与 /metrics 一致
+		labels := Metrics.ExpandLabels(sr.labels["__name__"], sr.labels)
+		matched := true
+		for _, m := range matchers {
+			if !m.matches(labels[m.name]) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		var points []Point
+		for _, p := range sr.ordered() {
+			if !p.Time.Before(from) && !p.Time.After(to) {
+				points = append(points, p)
+			}
+		}
+		if len(points) == 0 {
+			continue
+		}
+		result = append(result, Series{Labels: labels, Points: points})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return labelString(result[i].Labels) < labelString(result[j].Labels)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func labelString(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(labels["__name__"])
+	for _, name := range names {
+		b.WriteByte(0xff)
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(labels[name])
+	}
+	return b.String()
+}
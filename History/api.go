@@ -0,0 +1,117 @@
+package History
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 查询默认时间范围和返回序列数
+const (
+	defaultQueryRange = 15 * time.Minute
+	defaultQueryLimit = 500
+)
+
+// 返回格式与 Prometheus /api/v1/query_range 的 matrix 相同
+type queryResponse struct {
+	Status string     `json:"status"`
+	Data   *queryData `json:"data,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+type queryData struct {
+	ResultType string        `json:"resultType"`
+	Result     []queryResult `json:"result"`
+}
+
+type queryResult struct {
+	Metric map[string]string `json:"metric"`
+	Values [][2]interface{}  `json:"values"`
+}
+
+// parseTime 支持 Unix 秒（可带小数）和 RFC3339
+func parseTime(value string, fallback time.Time) (time.Time, bool) {
+	if value == "" {
+		return fallback, true
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		sec, frac := math.Modf(seconds)
+		return time.Unix(int64(sec), int64(frac*1e9)), true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func writeQueryResponse(w http.ResponseWriter, status int, resp queryResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("[History] 响应失败: %v", err)
+	}
+}
+
+// QueryHandler 查询短期历史（/api/history）
+// 参数：match（序列选择器，如 cpu_percent{project_code="jxh"}）、start/end（Unix 秒或 RFC3339，默认最近 range）、
+// range（默认 15m）、limit（返回序列数上限，默认 500）
+func QueryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	badRequest := func(msg string) {
+		writeQueryResponse(w, http.StatusBadRequest, queryResponse{Status: "error", Error: msg})
+	}
+
+	window := defaultQueryRange
+	if value := query.Get("range"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			badRequest("range 不合法")
+			return
+		}
+		window = d
+	}
+	end, ok := parseTime(query.Get("end"), time.Now())
+	if !ok {
+		badRequest("end 不合法")
+		return
+	}
+	start, ok := parseTime(query.Get("start"), end.Add(-window))
+	if !ok {
+		badRequest("start 不合法")
+		return
+	}
+	limit := defaultQueryLimit
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			badRequest("limit 不合法")
+			return
+		}
+		limit = n
+	}
+
+	series, err := Query(query.Get("match"), start, end, limit)
+	if err != nil {
+		badRequest(err.Error())
+		return
+	}
+
+	result := make([]queryResult, 0, len(series))
+	for _, s := range series {
+		values := make([][2]interface{}, 0, len(s.Points))
+		for _, p := range s.Points {
+			values = append(values, [2]interface{}{
+				float64(p.Time.UnixMilli()) / 1000,
+				strconv.FormatFloat(p.Value, 'f', -1, 64),
+			})
+		}
+		result = append(result, queryResult{Metric: s.Labels, Values: values})
+	}
+	writeQueryResponse(w, http.StatusOK, queryResponse{
+		Status: "success",
+		Data:   &queryData{ResultType: "matrix", Result: result},
+	})
+}
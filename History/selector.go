@@ -0,0 +1,153 @@
+package History
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// matcher 标签匹配条件，__name__ 表示指标名称
+type matcher struct {
+	name  string
+	op    string // =、!=、=~、!~
+	value string
+	re    *regexp.Regexp
+}
+
+func (m matcher) matches(value string) bool {
+	switch m.op {
+	case "=":
+		return value == m.value
+	case "!=":
+		return value != m.value
+	case "=~":
+		return m.re.MatchString(value)
+	default:
+		return !m.re.MatchString(value)
+	}
+}
+
+// parseSelector 解析 Prometheus 风格的序列选择器，如 cpu_percent{project_code="jxh",hostName=~"web-.*"}
+func parseSelector(selector string) ([]matcher, error) {
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		return nil, fmt.Errorf("选择器为空")
+	}
+
+	var matchers []matcher
+	rest := selector
+	if i := strings.IndexByte(selector, '{'); i != 0 {
+		name := selector
+		rest = ""
+		if i > 0 {
+			name, rest = selector[:i], selector[i:]
+		}
+		name = strings.TrimSpace(name)
+		if !validName(name) {
+			return nil, fmt.Errorf("指标名称 %q 不合法", name)
+		}
+		matchers = append(matchers, matcher{name: "__name__", op: "=", value: name})
+	}
+
+	if rest != "" {
+		if !strings.HasSuffix(rest, "}") {
+			return nil, fmt.Errorf("选择器缺少 }")
+		}
+		body := strings.TrimSpace(rest[1 : len(rest)-1])
+		for body != "" {
+			m, remaining, err := parseMatcher(body)
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, m)
+			body = strings.TrimSpace(remaining)
+			if body == "" {
+				break
+			}
+			if body[0] != ',' {
+				return nil, fmt.Errorf("标签条件之间缺少逗号: %s", body)
+			}
+			body = strings.TrimSpace(body[1:])
+		}
+	}
+
+	if len(matchers) == 0 {
+		return nil, fmt.Errorf("选择器至少需要一个条件")
+	}
+	return matchers, nil
+}
+
+// parseMatcher 解析一个 label op "value"，返回剩余部分
+func parseMatcher(s string) (matcher, string, error) {
+	i := 0
+	for i < len(s) && (isNameChar(s[i]) || (i > 0 && s[i] >= '0' && s[i] <= '9')) {
+		i++
+	}
+	name := s[:i]
+	if name == "" {
+		return matcher{}, "", fmt.Errorf("标签名称不合法: %s", s)
+	}
+	s = strings.TrimSpace(s[i:])
+
+	var op string
+	for _, candidate := range []string{"=~", "!~", "!=", "="} {
+		if strings.HasPrefix(s, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return matcher{}, "", fmt.Errorf("标签 %s 缺少匹配运算符", name)
+	}
+	s = strings.TrimSpace(s[len(op):])
+
+	if s == "" || (s[0] != '"' && s[0] != '\'' && s[0] != '`') {
+		return matcher{}, "", fmt.Errorf("标签 %s 的值需要用引号括起来", name)
+	}
+	end := 1
+	for end < len(s) && s[end] != s[0] {
+		if s[end] == '\\' && s[0] != '`' {
+			end++
+		}
+		end++
+	}
+	if end >= len(s) {
+		return matcher{}, "", fmt.Errorf("标签 %s 的值缺少结束引号", name)
+	}
+	quoted := s[:end+1]
+	if quoted[0] == '\'' {
+		body := strings.ReplaceAll(quoted[1:len(quoted)-1], `\'`, `'`)
+		quoted = `"` + strings.ReplaceAll(body, `"`, `\"`) + `"`
+	}
+	value, err := strconv.Unquote(quoted)
+	if err != nil {
+		return matcher{}, "", fmt.Errorf("标签 %s 的值不合法: %v", name, err)
+	}
+
+	m := matcher{name: name, op: op, value: value}
+	if op == "=~" || op == "!~" {
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return matcher{}, "", fmt.Errorf("标签 %s 的正则不合法: %v", name, err)
+		}
+		m.re = re
+	}
+	return m, s[end+1:], nil
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isNameChar(name[i]) && (i == 0 || name[i] < '0' || name[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
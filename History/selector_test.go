@@ -0,0 +1,120 @@
+package History
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     []matcher // 只比较 name/op/value
+	}{
+		{"cpu_percent", []matcher{{name: "__name__", op: "=", value: "cpu_percent"}}},
+		{" ns:metric_1 ", []matcher{{name: "__name__", op: "=", value: "ns:metric_1"}}},
+		{`cpu{project_code="jxh"}`, []matcher{
+			{name: "__name__", op: "=", value: "cpu"},
+			{name: "project_code", op: "=", value: "jxh"},
+		}},
+		{`{__name__=~"cpu_.*", hostName != 'web 1' , a!~` + "`x|y`" + `,}`, []matcher{
+			{name: "__name__", op: "=~", value: "cpu_.*"},
+			{name: "hostName", op: "!=", value: "web 1"},
+			{name: "a", op: "!~", value: "x|y"},
+		}},
+		{`m{a="say \"hi\"",b='it\'s "ok"',c="}"}`, []matcher{
+			{name: "__name__", op: "=", value: "m"},
+			{name: "a", op: "=", value: `say "hi"`},
+			{name: "b", op: "=", value: `it's "ok"`},
+			{name: "c", op: "=", value: "}"},
+		}},
+		{`m{a="中文\n"}`, []matcher{
+			{name: "__name__", op: "=", value: "m"},
+			{name: "a", op: "=", value: "中文\n"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := parseSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("parseSelector() error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseSelector() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].name != tt.want[i].name || got[i].op != tt.want[i].op || got[i].value != tt.want[i].value {
+					t.Errorf("matcher %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	tests := []struct {
+		selector string
+		wantErr  string
+	}{
+		{"", "选择器为空"},
+		{"   ", "选择器为空"},
+		{"{}", "至少需要一个条件"},
+		{"1cpu", "指标名称"},
+		{"cpu-percent", "指标名称"},
+		{"cpu}", "指标名称"},
+		{`cpu{a="1"`, "缺少 }"},
+		{`cpu{a="1"}x`, "缺少 }"},
+		{`cpu{a="1" b="2"}`, "缺少逗号"},
+		{`cpu{a="1"}}`, "缺少逗号"},
+		{`cpu{="1"}`, "标签名称不合法"},
+		{`cpu{1a="1"}`, "标签名称不合法"},
+		{`cpu{a}`, "缺少匹配运算符"},
+		{`cpu{a=="1"}`, "引号"},
+		{`cpu{a=1}`, "引号"},
+		{`cpu{a="1}`, "结束引号"},
+		{`cpu{a="\q"}`, "值不合法"},
+		{`cpu{a=~"("}`, "正则不合法"},
+		{`cpu{a!~"[z-a]"}`, "正则不合法"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			_, err := parseSelector(tt.selector)
+			if err == nil {
+				t.Fatalf("parseSelector(%q) expected error", tt.selector)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseSelector(%q) error = %v, want containing %q", tt.selector, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatcherMatches(t *testing.T) {
+	tests := []struct {
+		selector string
+		value    string
+		want     bool
+	}{
+		{`{a="x"}`, "x", true},
+		{`{a="x"}`, "xy", false},
+		{`{a!="x"}`, "y", true},
+		{`{a=~"web-.*"}`, "web-1", true},
+		{`{a=~"web"}`, "web-1", false}, // 正则需要完整匹配
+		{`{a=~"a|b"}`, "b", true},
+		{`{a!~"a|b"}`, "ab", true},
+		{`{a!~"a|b"}`, "a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector+" "+tt.value, func(t *testing.T) {
+			matchers, err := parseSelector(tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := matchers[0].matches(tt.value); got != tt.want {
+				t.Errorf("matches(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
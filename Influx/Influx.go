@@ -0,0 +1,262 @@
+package Influx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"monitor-server/Modles"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config InfluxDB v2 写入配置
+type Config struct {
+	Enabled        bool          `yaml:"enabled"`
+	URL            string        `yaml:"url"`            // 如 http://127.0.0.1:8086
+	Org            string        `yaml:"org"`            // 组织
+	Bucket         string        `yaml:"bucket"`         // 存储桶
+	Token          string        `yaml:"token"`          // API Token
+	BatchSize      int           `yaml:"batchSize"`      // 达到该行数立即发送
+	FlushInterval  time.Duration `yaml:"flushInterval"`  // 定时发送间隔
+	MaxBufferLines int           `yaml:"maxBufferLines"` // 发送失败时最多缓存的行数，超出丢弃最旧的数据
+	Timeout        time.Duration `yaml:"timeout"`        // 单次请求超时
+}
+
+// 默认值
+const (
+	defaultBatchSize      = 5000
+	defaultFlushInterval  = 5 * time.Second
+	defaultMaxBufferLines = 500000
+	defaultTimeout        = 10 * time.Second
+	maxBackoff            = 2 * time.Minute
+)
+
+func (c *Config) applyDefaults() {
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultBatchSize
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = defaultFlushInterval
+	}
+	if c.MaxBufferLines <= 0 {
+		c.MaxBufferLines = defaultMaxBufferLines
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+}
+
+// writer 带缓冲和重试的行协议写入器
+type writer struct {
+	cfg      Config
+	writeURL string
+	client   *http.Client
+
+	mu      sync.Mutex
+	buffer  []string
+	head    uint64 // buffer[0] 的序号，头部的行发送或因超限丢弃后增加
+	flushCh chan struct{}
+	fields  *fieldTypes
+}
+
+// 全局写入器，未启用时为 nil
+var (
+	current   *writer
+	currentMu sync.RWMutex
+)
+
+// Start 启动 InfluxDB 写入
+func Start(cfg Config) error {
+	cfg.applyDefaults()
+	if cfg.URL == "" || cfg.Bucket == "" {
+		return fmt.Errorf("influx.url 和 influx.bucket 不能为空")
+	}
+
+	query := url.Values{}
+	query.Set("org", cfg.Org)
+	query.Set("bucket", cfg.Bucket)
+	query.Set("precision", "ms")
+
+	w := &writer{
+		cfg:      cfg,
+		writeURL: strings.TrimSuffix(cfg.URL, "/") + "/api/v2/write?" + query.Encode(),
+		client:   &http.Client{Timeout: cfg.Timeout},
+		flushCh:  make(chan struct{}, 1),
+		fields:   newFieldTypes(),
+	}
+	go w.flushLoop()
+
+	currentMu.Lock()
+	current = w
+	currentMu.Unlock()
+
+	log.Printf("[Influx] 已启动，写入 %s bucket=%s", cfg.URL, cfg.Bucket)
+	return nil
+}
+
+// WriteBatch 将一批通过时间戳校验的上报数据转换为行协议并放入缓冲区
+// measurement 为 source，sourceTags 中的字段作为 tag，其余字段作为 field，时间为采样时间
+func WriteBatch(source, project, projectName string, samples []Modles.Sample) {
+	currentMu.RLock()
+	w := current
+	currentMu.RUnlock()
+	if w == nil {
+		return
+	}
+	tags, ok := sourceTags[source]
+	if !ok {
+		return
+	}
+
+	lines := make([]string, 0, len(samples))
+	for _, sample := range samples {
+		if line := formatLine(source, project, projectName, tags, sample.Item, sample.Time, w.fields); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	w.enqueue(lines)
+}
+
+func (w *writer) enqueue(lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	w.mu.Lock()
+	w.buffer = append(w.buffer, lines...)
+	if over := len(w.buffer) - w.cfg.MaxBufferLines; over > 0 {
+		log.Printf("[Influx] 缓冲区已满，丢弃最旧的 %d 行", over)
+		w.buffer = w.buffer[over:]
+		w.head += uint64(over)
+	}
+	full := len(w.buffer) >= w.cfg.BatchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flushLoop 定时或缓冲区满时发送，失败后保留数据并指数退避
+func (w *writer) flushLoop() {
+	backoff := w.cfg.FlushInterval
+	for {
+		select {
+		case <-w.flushCh:
+		case <-time.After(backoff):
+		}
+
+		if err := w.flush(); err != nil {
+			log.Printf("[Influx] 写入失败，%v 后重试: %v", backoff, err)
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+			continue
+		}
+		backoff = w.cfg.FlushInterval
+	}
+}
+
+// flush 按 BatchSize 分批发送缓冲区中的全部数据
+func (w *writer) flush() error {
+	for {
+		w.mu.Lock()
+		n := len(w.buffer)
+		if n == 0 {
+			w.mu.Unlock()
+			return nil
+		}
+		if n > w.cfg.BatchSize {
+			n = w.cfg.BatchSize
+		}
+		start := w.head
+		batch := make([]string, n)
+		copy(batch, w.buffer[:n])
+		w.mu.Unlock()
+
+		done, err := w.sendBatch(batch)
+		w.removeSent(start, done)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// removeSent 按序号删除已处理的行 [start, start+n)
+// 发送期间缓冲区头部可能因超限被丢弃，已经不在缓冲区中的行不再重复删除，避免删掉未发送的数据
+func (w *writer) removeSent(start uint64, n int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	end := start + uint64(n)
+	if end <= w.head {
+		return
+	}
+	drop := end - w.head
+	if drop > uint64(len(w.buffer)) {
+		drop = uint64(len(w.buffer))
+	}
+	w.buffer = w.buffer[drop:]
+	w.head += drop
+}
+
+// sendBatch 发送一批数据，被拒绝（4xx）时二分后分别发送，只丢弃单独发送仍被拒绝的行
+// 返回从头开始已处理（写入或丢弃）的行数；需要重试的错误中止发送，剩余的行保留在缓冲区
+func (w *writer) sendBatch(lines []string) (int, error) {
+	retry, err := w.send(lines)
+	if err == nil {
+		return len(lines), nil
+	}
+	if retry {
+		return 0, err
+	}
+	if len(lines) == 1 {
+		// 4xx 表示数据本身有问题，重试也不会成功
+		log.Printf("[Influx] 数据被拒绝，丢弃 1 行: %v: %s", err, lines[0])
+		return 1, nil
+	}
+
+	mid := len(lines) / 2
+	done, err := w.sendBatch(lines[:mid])
+	if err != nil {
+		return done, err
+	}
+	done, err = w.sendBatch(lines[mid:])
+	return mid + done, err
+}
+
+// send 发送一批数据，返回值 retry 表示失败后是否需要重试
+func (w *writer) send(lines []string) (bool, error) {
+	body := strings.Join(lines, "\n")
+	req, err := http.NewRequest(http.MethodPost, w.writeURL, strings.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+w.cfg.Token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			log.Printf("关闭响应体失败: %v", err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5, err
+}
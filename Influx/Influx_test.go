@@ -0,0 +1,143 @@
+package Influx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// influxServer 记录写入成功的行，reject 返回非空时以该状态码拒绝整批
+func influxServer(t *testing.T, reject func(lines []string) int) (*writer, func() []string) {
+	var mu sync.Mutex
+	var written []string
+	var w *writer
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lines := strings.Split(string(body), "\n")
+		if status := reject(lines); status != 0 {
+			http.Error(rw, "rejected", status)
+			return
+		}
+		mu.Lock()
+		written = append(written, lines...)
+		mu.Unlock()
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	w = &writer{
+		cfg:      Config{BatchSize: 4, MaxBufferLines: 8},
+		writeURL: server.URL,
+		client:   server.Client(),
+		flushCh:  make(chan struct{}, 1),
+	}
+	return w, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), written...)
+	}
+}
+
+func TestFlushBisectsRejectedBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		lines       []string
+		bad         string
+		status      int
+		wantWritten []string
+		wantBuffer  []string
+		wantErr     bool
+	}{
+		{
+			name:        "single bad line dropped",
+			lines:       []string{"a", "bad", "c", "d", "e"},
+			bad:         "bad",
+			status:      http.StatusBadRequest,
+			wantWritten: []string{"a", "c", "d", "e"},
+		},
+		{
+			name:        "every line rejected",
+			lines:       []string{"bad1", "bad2", "bad3"},
+			bad:         "bad",
+			status:      http.StatusUnprocessableEntity,
+			wantWritten: nil,
+		},
+		{
+			name:        "server error keeps unsent lines",
+			lines:       []string{"a", "b", "down", "d"},
+			bad:         "down",
+			status:      http.StatusServiceUnavailable,
+			wantWritten: nil,
+			wantBuffer:  []string{"a", "b", "down", "d"},
+			wantErr:     true,
+		},
+		{
+			name:        "server error after partial bisect",
+			lines:       []string{"a", "bad", "c", "down"},
+			bad:         "bad",
+			status:      http.StatusBadRequest,
+			wantWritten: []string{"a"},
+			wantBuffer:  []string{"c", "down"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, written := influxServer(t, func(lines []string) int {
+				for _, line := range lines {
+					if strings.HasPrefix(line, tt.bad) {
+						return tt.status
+					}
+				}
+				for _, line := range lines {
+					if line == "down" {
+						return http.StatusServiceUnavailable
+					}
+				}
+				return 0
+			})
+			w.enqueue(tt.lines)
+
+			err := w.flush()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("flush() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := written(); !reflect.DeepEqual(got, tt.wantWritten) {
+				t.Errorf("written = %v, want %v", got, tt.wantWritten)
+			}
+			if !reflect.DeepEqual(w.buffer, tt.wantBuffer) && len(w.buffer)+len(tt.wantBuffer) > 0 {
+				t.Errorf("buffer = %v, want %v", w.buffer, tt.wantBuffer)
+			}
+		})
+	}
+}
+
+func TestFlushKeepsLinesTrimmedDuringSend(t *testing.T) {
+	var w *writer
+	sent := 0
+	w, written := influxServer(t, func(lines []string) int {
+		sent++
+		if sent == 1 {
+			// 发送第一批期间缓冲区超限，头部的已发送行和部分未发送行被丢弃
+			w.enqueue([]string{"n1", "n2", "n3", "n4", "n5", "n6"})
+		}
+		return 0
+	})
+	w.enqueue([]string{"a", "b", "c", "d", "e", "f"})
+
+	if err := w.flush(); err != nil {
+		t.Fatal(err)
+	}
+	// 缓冲区上限 8 行：第一批 a-d 发送期间 a-d 被丢弃，保留 e f n1-n6，全部在之后发送
+	want := []string{"a", "b", "c", "d", "e", "f", "n1", "n2", "n3", "n4", "n5", "n6"}
+	if got := written(); !reflect.DeepEqual(got, want) {
+		t.Errorf("written = %v, want %v", got, want)
+	}
+	if len(w.buffer) != 0 || w.head != 12 {
+		t.Errorf("buffer = %v head = %d, want empty and 12", w.buffer, w.head)
+	}
+}
@@ -0,0 +1,194 @@
+package Influx
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// 行协议不允许 measurement 和 tag 中出现换行，替换为 \n 避免一条数据被拆成多行
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	keyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+	stringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// sourceTags 各 source 作为 tag 写入的字段，只包含取值有限的标识字段，其余字符串写为 string field
+// esIp、esUrl 的客户端 IP 和 URL 基数不受控制，不写入 InfluxDB（排行见 Prometheus 指标）
+var sourceTags = map[string][]string{
+	"nginx":            {"hostName"},
+	"hard":             {"hostName"},
+	"heart":            {"hostname"},
+	"ssl":              {"domain", "status"},
+	"k8s":              {"namespace", "podName", "controllerName", "container"},
+	"k8sController":    {"namespace", "controllerName", "container", "controllerType"},
+	"trafficSwitching": {"service"},
+	"k8sEvent":         {"reason", "type"},
+	"esCountry":        {"country_name"},
+}
+
+// fieldType 字段在 InfluxDB 中的类型
+// 同一 measurement 的同一字段只能有一种类型，类型冲突时 InfluxDB 拒绝整批写入
+type fieldType int
+
+const (
+	fieldFloat fieldType = iota + 1
+	fieldString
+	fieldBool
+)
+
+// numericFields 已知的数值字段，部分 agent 以字符串上报，写入前统一转换为 float
+var numericFields = map[string]map[string]func(string) (float64, bool){
+	"trafficSwitching": {"total_success_rate": parsePercent},
+}
+
+// parsePercent 解析百分比字符串，如 "85.50%" -> 0.855，与 trafficswitching_total_success_rate 指标一致
+func parsePercent(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, "%")), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v / 100, true
+}
+
+// fieldTypes 记录每个 measurement 字段第一次写入时的类型，之后的值转换为该类型，无法转换时不写入该字段
+type fieldTypes struct {
+	mu    sync.Mutex
+	types map[string]fieldType // measurement + "\x00" + field -> 类型
+}
+
+func newFieldTypes() *fieldTypes {
+	return &fieldTypes{types: map[string]fieldType{}}
+}
+
+// resolve 返回字段应写入的类型，第一次出现时记录 want
+func (f *fieldTypes) resolve(measurement, field string, want fieldType) fieldType {
+	if f == nil {
+		return want
+	}
+	key := measurement + "\x00" + field
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if t, ok := f.types[key]; ok {
+		return t
+	}
+	f.types[key] = want
+	return want
+}
+
+// formatField 按字段类型格式化字段值，值为空或无法转换为字段类型时返回 false
+func formatField(measurement, key string, value interface{}, types *fieldTypes) (string, bool) {
+	// 值本身的类型，已知数值字段的字符串按 float 处理
+	var want fieldType
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", false
+		}
+		want = fieldFloat
+	case bool:
+		want = fieldBool
+	case string:
+		if v == "" {
+			return "", false
+		}
+		want = fieldString
+		if parse, ok := numericFields[measurement][key]; ok {
+			f, ok := parse(v)
+			if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+				return "", false
+			}
+			value, want = f, fieldFloat
+		}
+	default:
+		return "", false // 嵌套对象和数组不写入
+	}
+
+	switch types.resolve(measurement, key, want) {
+	case fieldFloat:
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+				return strconv.FormatFloat(f, 'f', -1, 64), true
+			}
+		}
+		return "", false
+	case fieldBool:
+		if v, ok := value.(bool); ok {
+			return strconv.FormatBool(v), true
+		}
+		return "", false
+	default:
+		var str string
+		switch v := value.(type) {
+		case float64:
+			str = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			str = strconv.FormatBool(v)
+		case string:
+			str = v
+		}
+		return `"` + stringEscaper.Replace(str) + `"`, true
+	}
+}
+
+// formatLine 将一条上报数据转换为行协议，没有任何字段时返回空
+// project 标签为显示名称，project_code 为项目编码；字段类型按 types 统一，types 为 nil 时按值的类型写入
+func formatLine(measurement, project, projectName string, tagKeys []string, item map[string]interface{}, ts time.Time, types *fieldTypes) string {
+	tags := map[string]string{"project": projectName, "project_code": project}
+	for _, key := range tagKeys {
+		if v, ok := item[key].(string); ok && v != "" {
+			tags[key] = v
+		}
+	}
+
+	fields := make(map[string]string)
+	for key, value := range item {
+		if _, ok := tags[key]; ok || key == "timestamp" {
+			continue
+		}
+		if v, ok := formatField(measurement, key, value, types); ok {
+			fields[key] = v
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(measurementEscaper.Replace(measurement))
+	for _, k := range sortedKeys(tags) {
+		b.WriteByte(',')
+		b.WriteString(keyEscaper.Replace(k))
+		b.WriteByte('=')
+		b.WriteString(keyEscaper.Replace(tags[k]))
+	}
+	for i, k := range sortedKeys(fields) {
+		if i == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(keyEscaper.Replace(k))
+		b.WriteByte('=')
+		b.WriteString(fields[k])
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(ts.UnixMilli(), 10))
+	return b.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
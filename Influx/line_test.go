@@ -0,0 +1,160 @@
+package Influx
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"monitor-server/Modles"
+)
+
+func TestFormatLine(t *testing.T) {
+	ts := time.UnixMilli(1700000000123)
+
+	tests := []struct {
+		name        string
+		measurement string
+		tagKeys     []string
+		item        map[string]interface{}
+		want        string
+	}{
+		{
+			name:        "tags and fields",
+			measurement: "ssl",
+			tagKeys:     sourceTags["ssl"],
+			item:        map[string]interface{}{"domain": "a.com", "status": "ok", "days": 30.0, "valid": true},
+			want:        `ssl,domain=a.com,project=演示,project_code=p1,status=ok days=30,valid=true 1700000000123`,
+		},
+		{
+			name:        "tag escaping",
+			measurement: "k8s",
+			tagKeys:     sourceTags["k8s"],
+			item:        map[string]interface{}{"namespace": "a b,c=d", "podName": "x\ny", "cpu": 0.5},
+			want:        `k8s,namespace=a\ b\,c\=d,podName=x\ny,project=演示,project_code=p1 cpu=0.5 1700000000123`,
+		},
+		{
+			name:        "string field escaping",
+			measurement: "k8sEvent",
+			tagKeys:     sourceTags["k8sEvent"],
+			item:        map[string]interface{}{"reason": "Failed", "message": "say \"hi\"\nC:\\tmp", "count": 2.0},
+			want:        `k8sEvent,project=演示,project_code=p1,reason=Failed count=2,message="say \"hi\"\nC:\\tmp" 1700000000123`,
+		},
+		{
+			name:        "non tag strings become fields",
+			measurement: "heart",
+			tagKeys:     sourceTags["heart"],
+			item:        map[string]interface{}{"hostname": "web1", "ip": "10.0.0.1"},
+			want:        `heart,hostname=web1,project=演示,project_code=p1 ip="10.0.0.1" 1700000000123`,
+		},
+		{
+			name:        "field key escaping",
+			measurement: "my measure,x",
+			item:        map[string]interface{}{"a b": 1.0, "c=d": 2.0},
+			want:        `my\ measure\,x,project=演示,project_code=p1 a\ b=1,c\=d=2 1700000000123`,
+		},
+		{
+			name:        "skipped values",
+			measurement: "nginx",
+			tagKeys:     sourceTags["nginx"],
+			item: map[string]interface{}{
+				"hostName":  "",
+				"timestamp": 1700000000.0,
+				"nan":       math.NaN(),
+				"inf":       math.Inf(1),
+				"empty":     "",
+				"nested":    map[string]interface{}{"a": 1.0},
+				"list":      []interface{}{1.0},
+				"value":     1e21,
+			},
+			want: `nginx,project=演示,project_code=p1 value=1000000000000000000000 1700000000123`,
+		},
+		{
+			name:        "no fields",
+			measurement: "ssl",
+			tagKeys:     sourceTags["ssl"],
+			item:        map[string]interface{}{"domain": "a.com", "timestamp": 1.0},
+			want:        "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatLine(tt.measurement, "p1", "演示", tt.tagKeys, tt.item, ts, nil)
+			if got != tt.want {
+				t.Errorf("formatLine() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteBatchSourceAllowlist(t *testing.T) {
+	w := &writer{cfg: Config{BatchSize: 100, MaxBufferLines: 100}, flushCh: make(chan struct{}, 1)}
+	currentMu.Lock()
+	current = w
+	currentMu.Unlock()
+	defer func() {
+		currentMu.Lock()
+		current = nil
+		currentMu.Unlock()
+	}()
+
+	samples := []Modles.Sample{{Item: map[string]interface{}{"value": 1.0}, Time: time.UnixMilli(1)}}
+	tests := []struct {
+		source string
+		want   int
+	}{
+		{"nginx", 1},
+		{"esCountry", 1},
+		{"esIp", 0},
+		{"esUrl", 0},
+		{"unknown", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			w.mu.Lock()
+			w.buffer = nil
+			w.mu.Unlock()
+
+			WriteBatch(tt.source, "p1", "演示", samples)
+
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			if len(w.buffer) != tt.want {
+				t.Errorf("buffered %d lines, want %d: %v", len(w.buffer), tt.want, w.buffer)
+			}
+		})
+	}
+}
+
+func TestFormatLineFieldTypes(t *testing.T) {
+	ts := time.UnixMilli(1700000000123)
+	types := newFieldTypes()
+
+	// 按顺序写入同一 measurement，字段类型以第一次写入为准
+	steps := []struct {
+		measurement string
+		item        map[string]interface{}
+		want        string
+	}{
+		{"trafficSwitching", map[string]interface{}{"service": "api", "total_success_rate": "85.50%"},
+			`trafficSwitching,project=演示,project_code=p1,service=api total_success_rate=0.855 1700000000123`},
+		{"trafficSwitching", map[string]interface{}{"service": "api", "total_success_rate": 0.9},
+			`trafficSwitching,project=演示,project_code=p1,service=api total_success_rate=0.9 1700000000123`},
+		{"trafficSwitching", map[string]interface{}{"service": "api", "total_success_rate": "n/a", "qps": 1.0},
+			`trafficSwitching,project=演示,project_code=p1,service=api qps=1 1700000000123`},
+		{"heart", map[string]interface{}{"hostname": "web1", "load": 1.5, "note": "ok"},
+			`heart,hostname=web1,project=演示,project_code=p1 load=1.5,note="ok" 1700000000123`},
+		{"heart", map[string]interface{}{"hostname": "web1", "load": "2.5", "note": 3.0},
+			`heart,hostname=web1,project=演示,project_code=p1 load=2.5,note="3" 1700000000123`},
+		{"heart", map[string]interface{}{"hostname": "web1", "load": true, "up": true},
+			`heart,hostname=web1,project=演示,project_code=p1 up=true 1700000000123`},
+		{"heart", map[string]interface{}{"hostname": "web1", "up": 1.0, "load": "high"}, ""},
+	}
+	for i, step := range steps {
+		got := formatLine(step.measurement, "p1", "演示", sourceTags[step.measurement], step.item, ts, types)
+		if got != step.want {
+			t.Errorf("step %d: formatLine() =\n%s\nwant\n%s", i, got, step.want)
+		}
+	}
+}
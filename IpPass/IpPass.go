@@ -19,12 +19,28 @@ var domainIPCache = struct {
 	mapping: make(map[string][]string),
 }
 
-// 设置允许的域名（线程安全）
+// 设置允许的域名（线程安全），已删除域名的解析结果立即失效
 func SetAllowedDomains(domains []string) {
 	allowedDomainsMu.Lock()
-	defer allowedDomainsMu.Unlock()
 	allowedDomains = domains
-	log.Printf("已设置允许的域名: %v", allowedDomains)
+	allowedDomainsMu.Unlock()
+	pruneDomainIPCache(domains)
+	log.Printf("已设置允许的域名: %v", domains)
+}
+
+// 删除已不在白名单中的域名的解析结果
+func pruneDomainIPCache(domains []string) {
+	allowed := make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		allowed[domain] = true
+	}
+	domainIPCache.mutex.Lock()
+	defer domainIPCache.mutex.Unlock()
+	for domain := range domainIPCache.mapping {
+		if !allowed[domain] {
+			delete(domainIPCache.mapping, domain)
+		}
+	}
 }
 
 // 获取允许的域名（线程安全）
@@ -37,6 +53,17 @@ func getAllowedDomains() []string {
 	return result
 }
 
+func isAllowedDomain(domain string) bool {
+	allowedDomainsMu.RLock()
+	defer allowedDomainsMu.RUnlock()
+	for _, allowed := range allowedDomains {
+		if allowed == domain {
+			return true
+		}
+	}
+	return false
+}
+
 // 刷新域名解析缓存（单次执行，线程安全）
 func RefreshDomainIPCache() {
 	domains := getAllowedDomains()
@@ -47,9 +74,11 @@ func RefreshDomainIPCache() {
 			continue
 		}
 
-		// 更新缓存
+		// 更新缓存；解析期间域名可能已被重新加载的配置删除
 		domainIPCache.mutex.Lock()
-		domainIPCache.mapping[domain] = ips
+		if isAllowedDomain(domain) {
+			domainIPCache.mapping[domain] = ips
+		}
 		domainIPCache.mutex.Unlock()
 	}
 }
@@ -69,9 +98,9 @@ func isAllowedIP(ip string) bool {
 	return false
 }
 
-// getClientIP 获取客户端真实 IP
+// GetClientIP 获取客户端真实 IP
 // 优先从 Nginx 代理头获取，适用于 Nginx 反向代理场景
-func getClientIP(r *http.Request) (string, error) {
+func GetClientIP(r *http.Request) (string, error) {
 	// 优先使用 X-Real-IP（Nginx 通常设置此头）
 	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
 		return strings.TrimSpace(realIP), nil
@@ -96,7 +125,7 @@ func getClientIP(r *http.Request) (string, error) {
 
 func IpRestrictionMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip, err := getClientIP(r)
+		ip, err := GetClientIP(r)
 		if err != nil {
 			log.Printf("无法解析客户端 IP 地址，RemoteAddr: %s, 错误: %v", r.RemoteAddr, err)
 			http.Error(w, "无法解析客户端 IP 地址", http.StatusForbidden)
@@ -0,0 +1,30 @@
+package Metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// 最近一次重新加载配置是否成功
+	ConfigLastReloadSuccess = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "config_last_reload_success",
+			Help: "最近一次重新加载配置是否成功（1 成功，0 失败，失败时仍使用原配置）",
+		},
+	)
+
+	// 最近一次成功加载配置的时间
+	ConfigLastReloadSuccessTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "config_last_reload_success_timestamp_seconds",
+			Help: "最近一次成功加载配置的时间（Unix 秒）",
+		},
+	)
+
+	// 重新加载配置次数
+	ConfigReloads = NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "config_reloads_total",
+			Help: "重新加载配置的次数",
+		},
+		[]string{"trigger", "result"},
+	)
+)
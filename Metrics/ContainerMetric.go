@@ -2,57 +2,153 @@ package Metrics
 
 import "github.com/prometheus/client_golang/prometheus"
 
+// containerLabels 容器指标通用标签
+// namespace 为归一化后的名称，namespace_raw 为原始名称，slot 为蓝绿槽位（未匹配规则时为空）
+var containerLabels = []string{"namespace", "namespace_raw", "slot", "podName", "container", "controllerName", "project"}
+
+// containerLabelsWith 在容器通用标签后追加标签
+func containerLabelsWith(extra ...string) []string {
+	return append(append([]string{}, containerLabels...), extra...)
+}
+
 var (
 	// 定义容器 CPU 使用情况指标
-	ContainerCpuUsageMetric = prometheus.NewGaugeVec(
+	ContainerCpuUsageMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "container_cpu_usage", // 容器 CPU 使用情况
 			Help: "容器 CPU 使用情况",
 		},
-		[]string{"namespace", "podName", "container", "controllerName", "project"},
+		containerLabels,
 	)
 
 	// 定义容器内存使用情况指标
-	ContainerMemoryUsageMetric = prometheus.NewGaugeVec(
+	ContainerMemoryUsageMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "container_memory_usage", // 容器内存使用情况
 			Help: "容器内存使用情况",
 		},
-		[]string{"namespace", "podName", "container", "controllerName", "project"},
+		containerLabels,
 	)
 
 	// 定义容器 CPU 限制情况指标
-	ContainerCpuLimitMetric = prometheus.NewGaugeVec(
+	ContainerCpuLimitMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "container_cpu_limit", // 容器 CPU 限制
 			Help: "容器 CPU 限制",
 		},
-		[]string{"namespace", "podName", "container", "controllerName", "project"},
+		containerLabels,
 	)
 
 	// 定义容器内存限制情况指标
-	ContainerMemoryLimitMetric = prometheus.NewGaugeVec(
+	ContainerMemoryLimitMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "container_memory_limit", // 容器内存限制
 			Help: "容器内存限制",
 		},
-		[]string{"namespace", "podName", "container", "controllerName", "project"},
+		containerLabels,
 	)
 
 	// 定义容器重启次数指标
-	ContainerRestartCountMetric = prometheus.NewGaugeVec(
+	ContainerRestartCountMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "container_restart_count", // 容器重启次数
 			Help: "容器重启次数",
 		},
-		[]string{"namespace", "podName", "container", "controllerName", "project"},
+		containerLabels,
 	)
 	// 定义容器重启次数指标
-	ContainerLastTerminationTimeMetric = prometheus.NewGaugeVec(
+	ContainerLastTerminationTimeMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "container_last_termination_time", // 容器重启次数
 			Help: "容器重启时间",
 		},
-		[]string{"namespace", "podName", "container", "controllerName", "project"},
+		containerLabels,
+	)
+
+	// 容器 CPU 请求
+	ContainerCpuRequestMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "container_cpu_request", // 容器 CPU 请求
+			Help: "容器 CPU 请求",
+		},
+		containerLabels,
+	)
+
+	// 容器内存请求
+	ContainerMemoryRequestMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "container_memory_request", // 容器内存请求
+			Help: "容器内存请求",
+		},
+		containerLabels,
+	)
+
+	// CPU 限流占比
+	ContainerCpuThrottledRatioMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "container_cpu_throttled_ratio", // 被限流的 CFS 周期占比
+			Help: "采集周期内容器被限流的 CFS 周期占比",
+		},
+		containerLabels,
+	)
+
+	// 利用率：使用量 / 限制、使用量 / 请求，未设置限制或请求时不输出
+	ContainerCpuLimitUtilizationMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "container_cpu_limit_utilization",
+			Help: "容器 CPU 使用量 / CPU 限制",
+		},
+		containerLabels,
+	)
+
+	ContainerMemoryLimitUtilizationMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "container_memory_limit_utilization",
+			Help: "容器内存使用量 / 内存限制",
+		},
+		containerLabels,
+	)
+
+	ContainerCpuRequestUtilizationMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "container_cpu_request_utilization",
+			Help: "容器 CPU 使用量 / CPU 请求",
+		},
+		containerLabels,
+	)
+
+	ContainerMemoryRequestUtilizationMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "container_memory_request_utilization",
+			Help: "容器内存使用量 / 内存请求",
+		},
+		containerLabels,
+	)
+
+	// 容器当前等待原因，值固定为 1，没有等待时不输出
+	ContainerWaitingReasonMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "container_waiting_reason",
+			Help: "容器当前等待原因",
+		},
+		containerLabelsWith("reason"),
+	)
+
+	// 容器上次终止原因，值固定为 1
+	ContainerLastTerminationReasonMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "container_last_termination_reason",
+			Help: "容器上次终止原因",
+		},
+		containerLabelsWith("reason"),
+	)
+
+	// 容器所在 Pod 的阶段和节点，值固定为 1
+	ContainerPodInfoMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "container_pod_info",
+			Help: "容器所在 Pod 的阶段和节点",
+		},
+		containerLabelsWith("phase", "node"),
 	)
 )
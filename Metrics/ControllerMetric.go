@@ -1,32 +1,165 @@
 package Metrics
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// controllerLabels 控制器指标通用标签，namespace 相关标签与容器指标一致
+// controllerName 与容器指标的 controllerName 对应，可直接关联
+var controllerLabels = []string{"namespace", "namespace_raw", "slot", "controllerName", "controllerType", "project"}
+
+// 旧版控制器指标用 container 标签保存控制器名称，迁移期间输出时同时带上 container（值与 controllerName 相同）
+var (
+	legacyControllerLabel   bool
+	legacyControllerLabelMu sync.RWMutex
+)
+
+// SetLegacyControllerLabel 设置是否同时输出旧版 container 标签
+func SetLegacyControllerLabel(enabled bool) {
+	legacyControllerLabelMu.Lock()
+	defer legacyControllerLabelMu.Unlock()
+	legacyControllerLabel = enabled
+}
+
+func legacyControllerLabelEnabled() bool {
+	legacyControllerLabelMu.RLock()
+	defer legacyControllerLabelMu.RUnlock()
+	return legacyControllerLabel
+}
+
+// addLegacyControllerLabel 为控制器指标添加 container 标签，其它指标原样返回
+func addLegacyControllerLabel(name string, labels []*dto.LabelPair) []*dto.LabelPair {
+	if !strings.HasPrefix(name, "controller_") && name != "rollout_stuck" {
+		return labels
+	}
+	for _, label := range labels {
+		if label.GetName() == "controllerName" {
+			labels = append(labels, labelPair("container", label.GetValue()))
+			sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+			break
+		}
+	}
+	return labels
+}
 
 var (
 	// 定义容器 CPU 使用情况指标
-	ControllerReplicasMetric = prometheus.NewGaugeVec(
+	ControllerReplicasMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "controller_replicas", // 容器 CPU 使用情况
 			Help: "副本数量",
 		},
-		[]string{"namespace", "container", "controllerType", "project"},
+		controllerLabels,
 	)
 
 	// 定义容器内存使用情况指标
-	ControllerReplicasAvailableMetric = prometheus.NewGaugeVec(
+	ControllerReplicasAvailableMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "controller_replicas_available", // 容器内存使用情况
 			Help: "已就绪副本数量",
 		},
-		[]string{"namespace", "container", "controllerType", "project"},
+		controllerLabels,
 	)
 
 	// 定义容器 CPU 限制情况指标
-	ControllerReplicasUnavailableMetric = prometheus.NewGaugeVec(
+	ControllerReplicasUnavailableMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "controller_replicas_unavailable", // 容器 CPU 限制
 			Help: "未就绪副本数量",
 		},
-		[]string{"namespace", "container", "controllerType", "project"},
+		controllerLabels,
+	)
+
+	// 就绪副本数
+	ControllerReplicasReadyMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "controller_replicas_ready",
+			Help: "就绪副本数量",
+		},
+		controllerLabels,
+	)
+
+	// 已更新副本数
+	ControllerReplicasUpdatedMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "controller_replicas_updated",
+			Help: "已更新到最新版本的副本数量",
+		},
+		controllerLabels,
+	)
+
+	// 期望的 generation
+	ControllerGenerationMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "controller_generation",
+			Help: "控制器期望的 generation",
+		},
+		controllerLabels,
+	)
+
+	// 已处理的 generation
+	ControllerObservedGenerationMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "controller_observed_generation",
+			Help: "控制器已处理的 generation",
+		},
+		controllerLabels,
+	)
+
+	// 状态条件，每个 condition 输出 true/false/unknown 三条，当前状态为 1
+	ControllerConditionMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "controller_condition",
+			Help: "控制器状态条件",
+		},
+		append(append([]string{}, controllerLabels...), "condition", "status"),
+	)
+
+	// HPA
+	ControllerHpaMinReplicasMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "controller_hpa_min_replicas",
+			Help: "HPA 最小副本数",
+		},
+		controllerLabels,
+	)
+
+	ControllerHpaMaxReplicasMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "controller_hpa_max_replicas",
+			Help: "HPA 最大副本数",
+		},
+		controllerLabels,
+	)
+
+	ControllerHpaCurrentReplicasMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "controller_hpa_current_replicas",
+			Help: "HPA 当前副本数",
+		},
+		controllerLabels,
+	)
+
+	// CronJob/Job 上次成功时间
+	ControllerLastSuccessTimeMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "controller_last_success_time",
+			Help: "CronJob/Job 上次成功时间（Unix 秒）",
+		},
+		controllerLabels,
+	)
+
+	// 发布卡住：发布未完成且超过阈值没有进展，或 Progressing 为 ProgressDeadlineExceeded
+	RolloutStuckMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rollout_stuck",
+			Help: "发布是否卡住（1 为卡住）",
+		},
+		controllerLabels,
 	)
 )
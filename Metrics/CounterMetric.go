@@ -0,0 +1,179 @@
+package Metrics
+
+import (
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CounterCollector 以 counter 类型输出 agent 上报的累计值
+// prometheus.CounterVec 只能递增，无法直接写入上报值，所以用 const metric 输出
+type CounterCollector struct {
+	desc       *prometheus.Desc
+	name       string
+	labelNames []string
+	mu         sync.RWMutex
+	values     map[string]counterValue
+}
+
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterCollector 创建累计值 collector，名称需以 _total 结尾
+func NewCounterCollector(name, help string, labelNames []string) *CounterCollector {
+	return &CounterCollector{
+		desc:       prometheus.NewDesc(name, help, labelNames, nil),
+		name:       name,
+		labelNames: labelNames,
+		values:     make(map[string]counterValue),
+	}
+}
+
+func counterKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+// Set 设置标签组合的累计值（调用方负责处理归零）
+func (c *CounterCollector) Set(value float64, labelValues ...string) {
+	c.mu.Lock()
+	c.values[counterKey(labelValues)] = counterValue{labelValues: labelValues, value: value}
+	c.mu.Unlock()
+	recordValue(c.name, c.labelNames, labelValues, value)
+}
+
+// Delete 删除标签组合
+func (c *CounterCollector) Delete(labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, counterKey(labelValues))
+}
+
+func (c *CounterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *CounterCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, v := range c.values {
+		metric, err := prometheus.NewConstMetric(c.desc, prometheus.CounterValue, v.value, v.labelValues...)
+		if err != nil {
+			log.Printf("生成 counter 指标失败: %v", err)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+var (
+	// TrafficSwitching 累计请求
+	TrafficSwitchingRequestsCounter = NewCounterCollector(
+		"trafficswitching_requests_total",
+		"累计请求总数（counter）",
+		trafficSwitchingLabels,
+	)
+
+	TrafficSwitchingSuccessCounter = NewCounterCollector(
+		"trafficswitching_success_total",
+		"累计成功请求数（counter）",
+		trafficSwitchingLabels,
+	)
+
+	TrafficSwitchingErrorsCounter = NewCounterCollector(
+		"trafficswitching_errors_total",
+		"累计失败请求数（counter）",
+		trafficSwitchingLabels,
+	)
+
+	// TrafficSwitching 按后端、路由拆分的累计值
+	TrafficSwitchingBackendRequestsCounter = NewCounterCollector(
+		"trafficswitching_backend_requests_total",
+		"后端累计请求数",
+		trafficSwitchingBackendLabels,
+	)
+
+	TrafficSwitchingBackendErrorsCounter = NewCounterCollector(
+		"trafficswitching_backend_errors_total",
+		"后端累计失败请求数",
+		trafficSwitchingBackendLabels,
+	)
+
+	TrafficSwitchingRouteRequestsCounter = NewCounterCollector(
+		"trafficswitching_route_requests_total",
+		"路由累计请求数",
+		trafficSwitchingRouteLabels,
+	)
+
+	TrafficSwitchingRouteErrorsCounter = NewCounterCollector(
+		"trafficswitching_route_errors_total",
+		"路由累计失败请求数",
+		trafficSwitchingRouteLabels,
+	)
+
+	// Nginx 总请求数
+	NginxRequestsCounter = NewCounterCollector(
+		"nginx_requests_total",
+		"Nginx 总请求数（counter）",
+		[]string{"hostName", "project"},
+	)
+
+	// Nginx stub_status 累计值
+	NginxConnectionsAcceptedCounter = NewCounterCollector(
+		"nginx_connections_accepted_total",
+		"Nginx 累计接受的连接数",
+		[]string{"hostName", "project"},
+	)
+
+	NginxConnectionsHandledCounter = NewCounterCollector(
+		"nginx_connections_handled_total",
+		"Nginx 累计处理的连接数",
+		[]string{"hostName", "project"},
+	)
+
+	NginxHttpRequestsCounter = NewCounterCollector(
+		"nginx_http_requests_total",
+		"Nginx 累计处理的 HTTP 请求数（stub_status）",
+		[]string{"hostName", "project"},
+	)
+
+	// Nginx server 块响应数，code 为 1xx~5xx
+	NginxServerResponsesCounter = NewCounterCollector(
+		"nginx_server_responses_total",
+		"Nginx server 块累计响应数",
+		[]string{"hostName", "project", "server_name", "code"},
+	)
+
+	// 容器重启次数
+	ContainerRestartsCounter = NewCounterCollector(
+		"container_restarts_total",
+		"容器重启次数（counter）",
+		containerLabels,
+	)
+
+	// 容器 CPU 累计被限流时间
+	ContainerCpuThrottledSecondsCounter = NewCounterCollector(
+		"container_cpu_throttled_seconds_total",
+		"容器 CPU 累计被限流时间（秒）",
+		containerLabels,
+	)
+
+	// 容器 OOM Kill 次数
+	ContainerOomKillsCounter = NewCounterCollector(
+		"container_oom_kills_total",
+		"容器 OOM Kill 次数",
+		containerLabels,
+	)
+)
+
+// ResetLegacyCounterGauges 清空以 gauge 类型输出的旧版累计指标（关闭兼容开关时调用）
+func ResetLegacyCounterGauges() {
+	TrafficSwitchingTotalRequests.Reset()
+	TrafficSwitchingTotalSuccess.Reset()
+	TrafficSwitchingTotalErrors.Reset()
+	NginxReTotalMetric.Reset()
+	ContainerRestartCountMetric.Reset()
+}
@@ -0,0 +1,82 @@
+package Metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// 由累计值在服务端计算出的速率和增量，agent 重启导致的计数归零已做修正
+var (
+	// TrafficSwitching 请求速率
+	TrafficSwitchingRequestsPerSecond = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_requests_per_second",
+			Help: "根据累计请求数计算的每秒请求数",
+		},
+		trafficSwitchingLabels,
+	)
+
+	TrafficSwitchingErrorsPerSecond = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_errors_per_second",
+			Help: "根据累计失败数计算的每秒失败请求数",
+		},
+		trafficSwitchingLabels,
+	)
+
+	TrafficSwitchingRequestsIncrease5m = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_requests_increase_5m",
+			Help: "最近 5 分钟新增请求数",
+		},
+		trafficSwitchingLabels,
+	)
+
+	TrafficSwitchingErrorsIncrease5m = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_errors_increase_5m",
+			Help: "最近 5 分钟新增失败请求数",
+		},
+		trafficSwitchingLabels,
+	)
+
+	// Nginx 请求速率
+	NginxReTotalPerSecond = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nginx_requests_per_second", // 根据 nginx_re_total 计算
+			Help: "Nginx 每秒请求数",
+		},
+		[]string{"hostName", "project"},
+	)
+
+	NginxReTotalIncrease5m = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nginx_requests_increase_5m", // 根据 nginx_re_total 计算
+			Help: "Nginx 最近 5 分钟新增请求数",
+		},
+		[]string{"hostName", "project"},
+	)
+
+	// 容器重启
+	ContainerRestartsIncrease5m = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "container_restarts_increase_5m", // 根据 container_restart_count 计算
+			Help: "容器最近 5 分钟重启次数",
+		},
+		containerLabels,
+	)
+
+	ContainerOomKillsIncrease5m = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "container_oom_kills_increase_5m", // 根据 oomKillCount 计算
+			Help: "容器最近 5 分钟 OOM Kill 次数",
+		},
+		containerLabels,
+	)
+
+	// 累计值回退（agent 重启或计数清零）的次数
+	DerivedCounterResets = NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "derived_counter_resets_total",
+			Help: "检测到累计值归零的次数",
+		},
+		[]string{"metric"},
+	)
+)
@@ -0,0 +1,199 @@
+package Metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// 挂载点、块设备、网卡的标签
+var (
+	mountpointLabels = []string{"hostName", "project", "mountpoint", "device", "fs_type"}
+	diskIOLabels     = []string{"hostName", "project", "device"}
+	networkLabels    = []string{"hostName", "project", "interface"}
+)
+
+var (
+	// ====================== 挂载点指标 ======================
+	MountpointTotalMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mountpoint_total",
+			Help: "挂载点总空间",
+		},
+		mountpointLabels,
+	)
+
+	MountpointUsedMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mountpoint_used",
+			Help: "挂载点已使用空间",
+		},
+		mountpointLabels,
+	)
+
+	MountpointFreeMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mountpoint_free",
+			Help: "挂载点可用空间",
+		},
+		mountpointLabels,
+	)
+
+	MountpointUsedPercentMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mountpoint_used_percent",
+			Help: "挂载点空间使用百分比",
+		},
+		mountpointLabels,
+	)
+
+	MountpointInodesTotalMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mountpoint_inodes_total",
+			Help: "挂载点 inode 总数",
+		},
+		mountpointLabels,
+	)
+
+	MountpointInodesUsedMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mountpoint_inodes_used",
+			Help: "挂载点已使用 inode 数",
+		},
+		mountpointLabels,
+	)
+
+	MountpointInodesFreeMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mountpoint_inodes_free",
+			Help: "挂载点可用 inode 数",
+		},
+		mountpointLabels,
+	)
+
+	MountpointInodesUsedPercentMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mountpoint_inodes_used_percent",
+			Help: "挂载点 inode 使用百分比",
+		},
+		mountpointLabels,
+	)
+
+	// ====================== 块设备 IO 指标 ======================
+	DiskReadIopsMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "disk_read_iops",
+			Help: "块设备每秒读次数",
+		},
+		diskIOLabels,
+	)
+
+	DiskWriteIopsMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "disk_write_iops",
+			Help: "块设备每秒写次数",
+		},
+		diskIOLabels,
+	)
+
+	DiskReadBytesPerSecMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "disk_read_bytes_per_sec",
+			Help: "块设备每秒读字节数",
+		},
+		diskIOLabels,
+	)
+
+	DiskWriteBytesPerSecMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "disk_write_bytes_per_sec",
+			Help: "块设备每秒写字节数",
+		},
+		diskIOLabels,
+	)
+
+	DiskReadAwaitMsMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "disk_read_await_ms",
+			Help: "块设备平均读等待时间（毫秒）",
+		},
+		diskIOLabels,
+	)
+
+	DiskWriteAwaitMsMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "disk_write_await_ms",
+			Help: "块设备平均写等待时间（毫秒）",
+		},
+		diskIOLabels,
+	)
+
+	DiskIoUtilPercentMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "disk_io_util_percent",
+			Help: "块设备 IO 使用率百分比",
+		},
+		diskIOLabels,
+	)
+
+	// ====================== 网卡指标 ======================
+	NetworkRxBytesMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_rx_bytes",
+			Help: "网卡累计接收字节数",
+		},
+		networkLabels,
+	)
+
+	NetworkTxBytesMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_tx_bytes",
+			Help: "网卡累计发送字节数",
+		},
+		networkLabels,
+	)
+
+	NetworkRxPacketsMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_rx_packets",
+			Help: "网卡累计接收包数",
+		},
+		networkLabels,
+	)
+
+	NetworkTxPacketsMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_tx_packets",
+			Help: "网卡累计发送包数",
+		},
+		networkLabels,
+	)
+
+	NetworkRxErrorsMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_rx_errors",
+			Help: "网卡累计接收错误数",
+		},
+		networkLabels,
+	)
+
+	NetworkTxErrorsMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_tx_errors",
+			Help: "网卡累计发送错误数",
+		},
+		networkLabels,
+	)
+
+	NetworkRxDroppedMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_rx_dropped",
+			Help: "网卡累计接收丢包数",
+		},
+		networkLabels,
+	)
+
+	NetworkTxDroppedMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_tx_dropped",
+			Help: "网卡累计发送丢包数",
+		},
+		networkLabels,
+	)
+)
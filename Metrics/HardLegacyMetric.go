@@ -0,0 +1,58 @@
+package Metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// hardLegacyLabels 旧版硬件指标标签（包含主机属性），仅在迁移期间输出
+var hardLegacyLabels = []string{"hostName", "project", "cpu_model", "os_version", "kernel_version"}
+
+// newHardLegacyGauge 创建旧版标签的硬件指标，名称和说明必须与新版指标一致
+func newHardLegacyGauge(name, help string) *GaugeVec {
+	return NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, hardLegacyLabels)
+}
+
+// HardLegacyMetrics 新版硬件指标 -> 同名的旧版标签指标
+var HardLegacyMetrics = map[*GaugeVec]*GaugeVec{
+	CpuPercentMetric:        newHardLegacyGauge("cpu_percent", "CPU 使用率百分比"),
+	DiskTotalMetric:         newHardLegacyGauge("disk_total", "磁盘总空间"),
+	DiskUsedMetric:          newHardLegacyGauge("disk_used", "已使用的磁盘空间"),
+	DiskFreeMetric:          newHardLegacyGauge("disk_free", "可用磁盘空间"),
+	DiskUsedPercentMetric:   newHardLegacyGauge("disk_used_percent", "磁盘使用百分比"),
+	MemoryTotalMetric:       newHardLegacyGauge("memory_total", "内存总量"),
+	MemoryUsedMetric:        newHardLegacyGauge("memory_used", "已使用的内存"),
+	MemoryFreeMetric:        newHardLegacyGauge("memory_free", "空闲内存"),
+	MemoryUsedPercentMetric: newHardLegacyGauge("memory_used_percent", "内存使用百分比"),
+	CpuLoad1Metric:          newHardLegacyGauge("cpu_load_1", "1 分钟 CPU 负载平均值"),
+	CpuLoad5Metric:          newHardLegacyGauge("cpu_load_5", "5 分钟 CPU 负载平均值"),
+	CpuLoad15Metric:         newHardLegacyGauge("cpu_load_15", "15 分钟 CPU 负载平均值"),
+	CpuTotalMetric:          newHardLegacyGauge("cpu_total", "cpu 核心数"),
+}
+
+// uncheckedCollector 不输出描述的 collector
+// 旧版指标与新版指标同名但标签不同，按 checked collector 注册会冲突
+type uncheckedCollector struct {
+	collectors []prometheus.Collector
+}
+
+func (c uncheckedCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c uncheckedCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors {
+		collector.Collect(ch)
+	}
+}
+
+// hardLegacyCollector 旧版硬件指标的统一 collector
+func hardLegacyCollector() prometheus.Collector {
+	c := uncheckedCollector{}
+	for _, legacy := range HardLegacyMetrics {
+		c.collectors = append(c.collectors, legacy)
+	}
+	return c
+}
+
+// ResetHardLegacyMetrics 清空全部旧版硬件指标（关闭迁移开关时调用）
+func ResetHardLegacyMetrics() {
+	for _, legacy := range HardLegacyMetrics {
+		legacy.Reset()
+	}
+}
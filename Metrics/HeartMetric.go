@@ -4,14 +4,14 @@ import "github.com/prometheus/client_golang/prometheus"
 
 var (
 	//ssl
-	IsActiveMetric = prometheus.NewGaugeVec(
+	IsActiveMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "is_active", // SSL 证书剩余天数
 			Help: "agnet状态是否存活",
 		},
 		[]string{"hostName", "project"}, // 标签
 	)
-	AgentVerisonMetric = prometheus.NewGaugeVec(
+	AgentVerisonMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "agent_version", // SSL 证书剩余天数
 			Help: "agnet版本号",
@@ -0,0 +1,101 @@
+package Metrics
+
+import (
+	"log"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HistogramCollector 以 histogram 或 summary 类型输出 agent 上报的分布数据
+// prometheus.HistogramVec 只能逐个 Observe，无法写入已聚合的桶，所以用 const metric 输出
+type HistogramCollector struct {
+	desc    *prometheus.Desc
+	summary bool
+	mu      sync.RWMutex
+	values  map[string]histogramValue
+}
+
+type histogramValue struct {
+	labelValues []string
+	count       uint64
+	sum         float64
+	buckets     map[float64]uint64  // histogram: 上界 -> 累计计数
+	quantiles   map[float64]float64 // summary: 分位 -> 值
+}
+
+// NewHistogramCollector 创建 histogram 类型的 collector
+func NewHistogramCollector(name, help string, labelNames []string) *HistogramCollector {
+	return &HistogramCollector{
+		desc:   prometheus.NewDesc(name, help, labelNames, nil),
+		values: make(map[string]histogramValue),
+	}
+}
+
+// NewSummaryCollector 创建 summary 类型的 collector
+func NewSummaryCollector(name, help string, labelNames []string) *HistogramCollector {
+	c := NewHistogramCollector(name, help, labelNames)
+	c.summary = true
+	return c
+}
+
+// SetHistogram 设置标签组合的直方图
+func (c *HistogramCollector) SetHistogram(count uint64, sum float64, buckets map[float64]uint64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[counterKey(labelValues)] = histogramValue{labelValues: labelValues, count: count, sum: sum, buckets: buckets}
+}
+
+// SetSummary 设置标签组合的分位数
+func (c *HistogramCollector) SetSummary(count uint64, sum float64, quantiles map[float64]float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[counterKey(labelValues)] = histogramValue{labelValues: labelValues, count: count, sum: sum, quantiles: quantiles}
+}
+
+// Delete 删除标签组合
+func (c *HistogramCollector) Delete(labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, counterKey(labelValues))
+}
+
+func (c *HistogramCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *HistogramCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, v := range c.values {
+		var (
+			metric prometheus.Metric
+			err    error
+		)
+		if c.summary {
+			metric, err = prometheus.NewConstSummary(c.desc, v.count, v.sum, v.quantiles, v.labelValues...)
+		} else {
+			metric, err = prometheus.NewConstHistogram(c.desc, v.count, v.sum, v.buckets, v.labelValues...)
+		}
+		if err != nil {
+			log.Printf("生成分布指标失败: %v", err)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+var (
+	// TrafficSwitching 延迟分布，按上游目标区分
+	TrafficSwitchingLatencySeconds = NewHistogramCollector(
+		"trafficswitching_latency_seconds",
+		"请求延迟分布（秒）",
+		[]string{"service", "project", "target"},
+	)
+
+	TrafficSwitchingLatencyQuantileSeconds = NewSummaryCollector(
+		"trafficswitching_latency_quantile_seconds",
+		"agent 计算的请求延迟分位数（秒）",
+		[]string{"service", "project", "target"},
+	)
+)
@@ -0,0 +1,29 @@
+package Metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// 短期历史当前的序列数
+	HistorySeries = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "history_series",
+			Help: "短期历史中保存的序列数",
+		},
+	)
+
+	// 短期历史当前的采样点数
+	HistoryPoints = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "history_points",
+			Help: "短期历史中保存的采样点数",
+		},
+	)
+
+	// 超出序列数上限未记录的序列
+	HistoryDroppedSeries = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "history_dropped_series_total",
+			Help: "因超出 history.maxSeries 未记录的指标更新次数",
+		},
+	)
+)
@@ -0,0 +1,23 @@
+package Metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// 被丢弃的上报数据项（乱序或陈旧）
+	IngestDroppedSamples = NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ingest_dropped_samples_total",
+			Help: "因乱序或已过期被丢弃的上报数据项数量",
+		},
+		[]string{"source", "reason"},
+	)
+
+	// 任务队列已满时另起 goroutine 处理的上报请求（ingest.rejectWhenFull 为 false 时）
+	IngestQueueFullInline = NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ingest_queue_full_inline_total",
+			Help: "任务队列已满时不经过 worker pool 直接处理的上报请求数量",
+		},
+		[]string{"source"},
+	)
+)
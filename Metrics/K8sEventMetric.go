@@ -0,0 +1,14 @@
+package Metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// 最近的 Kubernetes 事件次数，按类型和原因统计
+	K8sEventsMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "k8s_events", // 最近 1 小时内的事件次数
+			Help: "最近 1 小时内的 Kubernetes 事件次数",
+		},
+		[]string{"namespace", "namespace_raw", "slot", "project", "type", "reason"},
+	)
+)
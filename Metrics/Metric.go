@@ -21,6 +21,33 @@ func init() {
 	CustomRegistry.MustRegister(CpuLoad5Metric)
 	CustomRegistry.MustRegister(CpuLoad15Metric)
 	CustomRegistry.MustRegister(CpuTotalMetric)
+	CustomRegistry.MustRegister(HostInfoMetric)
+	CustomRegistry.MustRegister(hardLegacyCollector())
+
+	// ====================== 挂载点 & 块设备 & 网卡 ======================
+	CustomRegistry.MustRegister(MountpointTotalMetric)
+	CustomRegistry.MustRegister(MountpointUsedMetric)
+	CustomRegistry.MustRegister(MountpointFreeMetric)
+	CustomRegistry.MustRegister(MountpointUsedPercentMetric)
+	CustomRegistry.MustRegister(MountpointInodesTotalMetric)
+	CustomRegistry.MustRegister(MountpointInodesUsedMetric)
+	CustomRegistry.MustRegister(MountpointInodesFreeMetric)
+	CustomRegistry.MustRegister(MountpointInodesUsedPercentMetric)
+	CustomRegistry.MustRegister(DiskReadIopsMetric)
+	CustomRegistry.MustRegister(DiskWriteIopsMetric)
+	CustomRegistry.MustRegister(DiskReadBytesPerSecMetric)
+	CustomRegistry.MustRegister(DiskWriteBytesPerSecMetric)
+	CustomRegistry.MustRegister(DiskReadAwaitMsMetric)
+	CustomRegistry.MustRegister(DiskWriteAwaitMsMetric)
+	CustomRegistry.MustRegister(DiskIoUtilPercentMetric)
+	CustomRegistry.MustRegister(NetworkRxBytesMetric)
+	CustomRegistry.MustRegister(NetworkTxBytesMetric)
+	CustomRegistry.MustRegister(NetworkRxPacketsMetric)
+	CustomRegistry.MustRegister(NetworkTxPacketsMetric)
+	CustomRegistry.MustRegister(NetworkRxErrorsMetric)
+	CustomRegistry.MustRegister(NetworkTxErrorsMetric)
+	CustomRegistry.MustRegister(NetworkRxDroppedMetric)
+	CustomRegistry.MustRegister(NetworkTxDroppedMetric)
 
 	// ====================== Nginx 指标 ======================
 	CustomRegistry.MustRegister(NginxIsRunMetric)
@@ -36,6 +63,13 @@ func init() {
 	CustomRegistry.MustRegister(NginxTcpClosedMetric)
 	CustomRegistry.MustRegister(NginxTcpOrphanedMetric)
 	CustomRegistry.MustRegister(NginxTcpTimewaitMetric)
+	CustomRegistry.MustRegister(NginxConnectionsActiveMetric)
+	CustomRegistry.MustRegister(NginxConnectionsReadingMetric)
+	CustomRegistry.MustRegister(NginxConnectionsWritingMetric)
+	CustomRegistry.MustRegister(NginxConnectionsWaitingMetric)
+	CustomRegistry.MustRegister(NginxUpstreamPeerUpMetric)
+	CustomRegistry.MustRegister(NginxUpstreamPeerFailsMetric)
+	CustomRegistry.MustRegister(NginxUpstreamPeerResponseTimeMsMetric)
 
 	// ====================== SSL & 容器 & Agent & Controller ======================
 	CustomRegistry.MustRegister(SslDaysLeftMetric)
@@ -45,11 +79,32 @@ func init() {
 	CustomRegistry.MustRegister(ContainerMemoryLimitMetric)
 	CustomRegistry.MustRegister(ContainerRestartCountMetric)
 	CustomRegistry.MustRegister(ContainerLastTerminationTimeMetric)
+	CustomRegistry.MustRegister(ContainerCpuRequestMetric)
+	CustomRegistry.MustRegister(ContainerMemoryRequestMetric)
+	CustomRegistry.MustRegister(ContainerCpuThrottledRatioMetric)
+	CustomRegistry.MustRegister(ContainerCpuLimitUtilizationMetric)
+	CustomRegistry.MustRegister(ContainerMemoryLimitUtilizationMetric)
+	CustomRegistry.MustRegister(ContainerCpuRequestUtilizationMetric)
+	CustomRegistry.MustRegister(ContainerMemoryRequestUtilizationMetric)
+	CustomRegistry.MustRegister(ContainerWaitingReasonMetric)
+	CustomRegistry.MustRegister(ContainerLastTerminationReasonMetric)
+	CustomRegistry.MustRegister(ContainerPodInfoMetric)
 	CustomRegistry.MustRegister(IsActiveMetric)
 	CustomRegistry.MustRegister(AgentVerisonMetric)
 	CustomRegistry.MustRegister(ControllerReplicasMetric)
 	CustomRegistry.MustRegister(ControllerReplicasAvailableMetric)
 	CustomRegistry.MustRegister(ControllerReplicasUnavailableMetric)
+	CustomRegistry.MustRegister(K8sEventsMetric)
+	CustomRegistry.MustRegister(ControllerReplicasReadyMetric)
+	CustomRegistry.MustRegister(ControllerReplicasUpdatedMetric)
+	CustomRegistry.MustRegister(ControllerGenerationMetric)
+	CustomRegistry.MustRegister(ControllerObservedGenerationMetric)
+	CustomRegistry.MustRegister(ControllerConditionMetric)
+	CustomRegistry.MustRegister(ControllerHpaMinReplicasMetric)
+	CustomRegistry.MustRegister(ControllerHpaMaxReplicasMetric)
+	CustomRegistry.MustRegister(ControllerHpaCurrentReplicasMetric)
+	CustomRegistry.MustRegister(ControllerLastSuccessTimeMetric)
+	CustomRegistry.MustRegister(RolloutStuckMetric)
 
 	// ====================== TrafficSwitching 业务指标 ======================
 	// 累计统计
@@ -66,6 +121,15 @@ func init() {
 	CustomRegistry.MustRegister(TrafficSwitchingTodayStatus3xx)
 	CustomRegistry.MustRegister(TrafficSwitchingTodayStatus4xx)
 	CustomRegistry.MustRegister(TrafficSwitchingTodayStatus5xx)
+	// 昨日统计
+	CustomRegistry.MustRegister(TrafficSwitchingYesterdayRequests)
+	CustomRegistry.MustRegister(TrafficSwitchingYesterdaySuccess)
+	CustomRegistry.MustRegister(TrafficSwitchingYesterdayErrors)
+	CustomRegistry.MustRegister(TrafficSwitchingYesterdayCanceled)
+	CustomRegistry.MustRegister(TrafficSwitchingYesterdayStatus2xx)
+	CustomRegistry.MustRegister(TrafficSwitchingYesterdayStatus3xx)
+	CustomRegistry.MustRegister(TrafficSwitchingYesterdayStatus4xx)
+	CustomRegistry.MustRegister(TrafficSwitchingYesterdayStatus5xx)
 	// 实时统计
 	CustomRegistry.MustRegister(TrafficSwitchingRealtimeQPS)
 	CustomRegistry.MustRegister(TrafficSwitchingRealtimeSuccessQPS)
@@ -73,6 +137,21 @@ func init() {
 	CustomRegistry.MustRegister(TrafficSwitchingRealtimeActiveConnections)
 	CustomRegistry.MustRegister(TrafficSwitchingRealtimeAvgLatencyMs)
 	CustomRegistry.MustRegister(TrafficSwitchingRealtimeMaxLatencyMs)
+	// 按后端、路由拆分
+	CustomRegistry.MustRegister(TrafficSwitchingBackendWeight)
+	CustomRegistry.MustRegister(TrafficSwitchingBackendAvgLatencyMs)
+	CustomRegistry.MustRegister(TrafficSwitchingBackendRequestsIncrease5m)
+	CustomRegistry.MustRegister(TrafficSwitchingBackendErrorsIncrease5m)
+	CustomRegistry.MustRegister(TrafficSwitchingBackendSuccessRatio5m)
+	CustomRegistry.MustRegister(TrafficSwitchingWeightDrift)
+	CustomRegistry.MustRegister(TrafficSwitchingRouteAvgLatencyMs)
+	CustomRegistry.MustRegister(TrafficSwitchingBackendRequestsCounter)
+	CustomRegistry.MustRegister(TrafficSwitchingBackendErrorsCounter)
+	CustomRegistry.MustRegister(TrafficSwitchingRouteRequestsCounter)
+	CustomRegistry.MustRegister(TrafficSwitchingRouteErrorsCounter)
+	// 延迟分布
+	CustomRegistry.MustRegister(TrafficSwitchingLatencySeconds)
+	CustomRegistry.MustRegister(TrafficSwitchingLatencyQuantileSeconds)
 	// 错误类型
 	CustomRegistry.MustRegister(TrafficSwitchingErrorBackendError)
 	CustomRegistry.MustRegister(TrafficSwitchingErrorBrokenPipe)
@@ -96,4 +175,59 @@ func init() {
 	CustomRegistry.MustRegister(TrafficSwitchingTransportMaxIdleConnsPerHost)
 	// 时间戳
 	CustomRegistry.MustRegister(TrafficSwitchingTimestamp)
+
+	// ====================== 日志统计排行 ======================
+	CustomRegistry.MustRegister(ClientIpRequestCountMetric)
+	CustomRegistry.MustRegister(CountryRequestCountMetric)
+	CustomRegistry.MustRegister(UrlRequestCountMetric)
+
+	// ====================== 派生速率 & 增量 ======================
+	CustomRegistry.MustRegister(TrafficSwitchingRequestsPerSecond)
+	CustomRegistry.MustRegister(TrafficSwitchingErrorsPerSecond)
+	CustomRegistry.MustRegister(TrafficSwitchingRequestsIncrease5m)
+	CustomRegistry.MustRegister(TrafficSwitchingErrorsIncrease5m)
+	CustomRegistry.MustRegister(NginxReTotalPerSecond)
+	CustomRegistry.MustRegister(NginxReTotalIncrease5m)
+	CustomRegistry.MustRegister(ContainerRestartsIncrease5m)
+	CustomRegistry.MustRegister(ContainerOomKillsIncrease5m)
+	CustomRegistry.MustRegister(DerivedCounterResets)
+
+	// ====================== 累计值 counter ======================
+	CustomRegistry.MustRegister(TrafficSwitchingRequestsCounter)
+	CustomRegistry.MustRegister(TrafficSwitchingSuccessCounter)
+	CustomRegistry.MustRegister(TrafficSwitchingErrorsCounter)
+	CustomRegistry.MustRegister(NginxRequestsCounter)
+	CustomRegistry.MustRegister(NginxConnectionsAcceptedCounter)
+	CustomRegistry.MustRegister(NginxConnectionsHandledCounter)
+	CustomRegistry.MustRegister(NginxHttpRequestsCounter)
+	CustomRegistry.MustRegister(NginxServerResponsesCounter)
+	CustomRegistry.MustRegister(ContainerRestartsCounter)
+	CustomRegistry.MustRegister(ContainerCpuThrottledSecondsCounter)
+	CustomRegistry.MustRegister(ContainerOomKillsCounter)
+
+	// ====================== 上报数据处理 ======================
+	CustomRegistry.MustRegister(IngestDroppedSamples)
+	CustomRegistry.MustRegister(IngestRejectedPayloads)
+	CustomRegistry.MustRegister(IngestQueueFullInline)
+
+	// ====================== 项目登记 ======================
+	CustomRegistry.MustRegister(ProjectInfo)
+
+	// ====================== 配置重新加载 ======================
+	CustomRegistry.MustRegister(ConfigLastReloadSuccess)
+	CustomRegistry.MustRegister(ConfigLastReloadSuccessTimestamp)
+	CustomRegistry.MustRegister(ConfigReloads)
+
+	// ====================== OTLP 接收指标 ======================
+	CustomRegistry.MustRegister(OtlpMetrics)
+
+	// ====================== 短期历史 ======================
+	CustomRegistry.MustRegister(HistorySeries)
+	CustomRegistry.MustRegister(HistoryPoints)
+	CustomRegistry.MustRegister(HistoryDroppedSeries)
+
+	// ====================== 远程写入 ======================
+	CustomRegistry.MustRegister(RemoteWritePendingSegments)
+	CustomRegistry.MustRegister(RemoteWriteLastSuccessTimestamp)
+	CustomRegistry.MustRegister(RemoteWriteFailures)
 }
@@ -4,7 +4,7 @@ import "github.com/prometheus/client_golang/prometheus"
 
 var (
 	// Nginx指标
-	NginxIsRunMetric = prometheus.NewGaugeVec(
+	NginxIsRunMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_is_run", // Nginx 是否运行
 			Help: "表示 Nginx 是否正在运行",
@@ -12,7 +12,7 @@ var (
 		[]string{"hostName", "project"},
 	)
 
-	NginxReTotalMetric = prometheus.NewGaugeVec(
+	NginxReTotalMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_re_total", // Nginx 总请求数
 			Help: "Nginx 总请求数",
@@ -20,7 +20,7 @@ var (
 		[]string{"hostName", "project"},
 	)
 
-	NginxLoginUserCountMetric = prometheus.NewGaugeVec(
+	NginxLoginUserCountMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_login_user_count", // 已登录用户数量
 			Help: "已登录用户数量",
@@ -28,7 +28,7 @@ var (
 		[]string{"hostName", "project"},
 	)
 
-	NginxRawTotalMetric = prometheus.NewGaugeVec(
+	NginxRawTotalMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_raw_total", // Nginx 原始请求总数
 			Help: "Nginx 处理的原始请求总数",
@@ -36,7 +36,7 @@ var (
 		[]string{"hostName", "project"},
 	)
 
-	NginxUdptotalMetric = prometheus.NewGaugeVec(
+	NginxUdptotalMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_udptotal", // Nginx UDP 请求总数
 			Help: "Nginx 处理的 UDP 请求总数",
@@ -44,7 +44,7 @@ var (
 		[]string{"hostName", "project"},
 	)
 
-	NginxTcpTotalMetric = prometheus.NewGaugeVec(
+	NginxTcpTotalMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_tcp_total", // Nginx TCP 请求总数
 			Help: "Nginx 处理的 TCP 请求总数",
@@ -52,7 +52,7 @@ var (
 		[]string{"hostName", "project"},
 	)
 
-	NginxTotalTcpMetric = prometheus.NewGaugeVec(
+	NginxTotalTcpMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_total_tcp", // Nginx TCP 连接总数
 			Help: "Nginx 处理的 TCP 连接总数",
@@ -60,7 +60,7 @@ var (
 		[]string{"hostName", "project"},
 	)
 
-	NginxInetTotalMetric = prometheus.NewGaugeVec(
+	NginxInetTotalMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_inet_total", // Nginx 互联网连接总数
 			Help: "Nginx 处理的互联网连接总数",
@@ -68,7 +68,7 @@ var (
 		[]string{"hostName", "project"},
 	)
 
-	NginxFragTotalMetric = prometheus.NewGaugeVec(
+	NginxFragTotalMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_frag_total", // Nginx 碎片包总数
 			Help: "Nginx 处理的碎片包总数",
@@ -76,7 +76,7 @@ var (
 		[]string{"hostName", "project"},
 	)
 
-	NginxTcpEstabMetric = prometheus.NewGaugeVec(
+	NginxTcpEstabMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_tcp_estab", // 已建立的 TCP 连接总数
 			Help: "已建立的 TCP 连接总数",
@@ -84,7 +84,7 @@ var (
 		[]string{"hostName", "project"},
 	)
 
-	NginxTcpClosedMetric = prometheus.NewGaugeVec(
+	NginxTcpClosedMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_tcp_closed", // 关闭的 TCP 连接总数
 			Help: "关闭的 TCP 连接总数",
@@ -92,7 +92,7 @@ var (
 		[]string{"hostName", "project"},
 	)
 
-	NginxTcpOrphanedMetric = prometheus.NewGaugeVec(
+	NginxTcpOrphanedMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_tcp_orphaned", // 孤立的 TCP 连接总数
 			Help: "孤立的 TCP 连接总数",
@@ -100,11 +100,69 @@ var (
 		[]string{"hostName", "project"},
 	)
 
-	NginxTcpTimewaitMetric = prometheus.NewGaugeVec(
+	NginxTcpTimewaitMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nginx_tcp_timewait", // 处于 TIME-WAIT 状态的 TCP 连接总数
 			Help: "处于 TIME-WAIT 状态的 TCP 连接总数",
 		},
 		[]string{"hostName", "project"},
 	)
+
+	// ====================== stub_status ======================
+	NginxConnectionsActiveMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nginx_connections_active", // 当前活动连接数
+			Help: "Nginx 当前活动连接数",
+		},
+		[]string{"hostName", "project"},
+	)
+
+	NginxConnectionsReadingMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nginx_connections_reading", // 正在读取请求头的连接数
+			Help: "Nginx 正在读取请求头的连接数",
+		},
+		[]string{"hostName", "project"},
+	)
+
+	NginxConnectionsWritingMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nginx_connections_writing", // 正在写响应的连接数
+			Help: "Nginx 正在写响应的连接数",
+		},
+		[]string{"hostName", "project"},
+	)
+
+	NginxConnectionsWaitingMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nginx_connections_waiting", // 空闲 keep-alive 连接数
+			Help: "Nginx 空闲 keep-alive 连接数",
+		},
+		[]string{"hostName", "project"},
+	)
+
+	// ====================== upstream ======================
+	NginxUpstreamPeerUpMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nginx_upstream_peer_up", // 后端是否可用
+			Help: "Nginx upstream 后端是否可用（1 为 up）",
+		},
+		[]string{"hostName", "project", "upstream", "peer"},
+	)
+
+	NginxUpstreamPeerFailsMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nginx_upstream_peer_fails", // 后端失败次数
+			Help: "Nginx upstream 后端失败次数",
+		},
+		[]string{"hostName", "project", "upstream", "peer"},
+	)
+
+	NginxUpstreamPeerResponseTimeMsMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nginx_upstream_peer_response_time_ms", // 后端平均响应时间
+			Help: "Nginx upstream 后端平均响应时间（毫秒）",
+		},
+		[]string{"hostName", "project", "upstream", "peer"},
+	)
 )
@@ -0,0 +1,191 @@
+package Metrics
+
+import (
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OTLP 指标的种类
+const (
+	OtlpKindGauge = iota
+	OtlpKindCounter
+	OtlpKindHistogram
+	OtlpKindSummary
+)
+
+// otlpSeries 单条 OTLP 时间序列的当前值
+type otlpSeries struct {
+	name        string
+	labelNames  []string
+	labelValues []string
+	value       float64
+	count       uint64
+	sum         float64
+	buckets     map[float64]uint64  // 上界 -> 累计计数
+	quantiles   map[float64]float64 // 分位 -> 值
+}
+
+// otlpFamily 同名指标共用的类型和说明
+type otlpFamily struct {
+	kind int
+	help string
+	refs int
+}
+
+// OtlpCollector OTLP 指标收集器
+// 指标名称和标签在运行时才确定，所以 Describe 不输出任何描述，按 unchecked collector 注册
+type OtlpCollector struct {
+	mu       sync.RWMutex
+	series   map[string]*otlpSeries
+	families map[string]*otlpFamily
+}
+
+// OtlpMetrics OTLP 接收器写入的全部指标
+var OtlpMetrics = &OtlpCollector{
+	series:   make(map[string]*otlpSeries),
+	families: make(map[string]*otlpFamily),
+}
+
+func (c *OtlpCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c *OtlpCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, s := range c.series {
+		family := c.families[s.name]
+		desc := prometheus.NewDesc(s.name, family.help, s.labelNames, nil)
+		var (
+			metric prometheus.Metric
+			err    error
+		)
+		switch family.kind {
+		case OtlpKindCounter:
+			metric, err = prometheus.NewConstMetric(desc, prometheus.CounterValue, s.value, s.labelValues...)
+		case OtlpKindHistogram:
+			metric, err = prometheus.NewConstHistogram(desc, s.count, s.sum, s.buckets, s.labelValues...)
+		case OtlpKindSummary:
+			metric, err = prometheus.NewConstSummary(desc, s.count, s.sum, s.quantiles, s.labelValues...)
+		default:
+			metric, err = prometheus.NewConstMetric(desc, prometheus.GaugeValue, s.value, s.labelValues...)
+		}
+		if err != nil {
+			log.Printf("[OTLP] 生成指标 %s 失败: %v", s.name, err)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+// familyConflicts 判断新指标是否会与已有同名指标的类型或后缀冲突（冲突会导致整个 /metrics 抓取失败）
+func (c *OtlpCollector) familyConflicts(name string, kind int) bool {
+	if family, ok := c.families[name]; ok {
+		return family.kind != kind
+	}
+	if kind == OtlpKindHistogram || kind == OtlpKindSummary {
+		for _, suffix := range []string{"_count", "_sum", "_bucket"} {
+			if _, ok := c.families[name+suffix]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	for _, suffix := range []string{"_count", "_sum", "_bucket"} {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			if family, ok := c.families[base]; ok && (family.kind == OtlpKindHistogram || family.kind == OtlpKindSummary) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getSeries 获取或创建时间序列，类型冲突时返回 nil（调用方需持有写锁）
+func (c *OtlpCollector) getSeries(key, name, help string, kind int, labelNames, labelValues []string) *otlpSeries {
+	if s, ok := c.series[key]; ok {
+		return s
+	}
+	if c.familyConflicts(name, kind) {
+		log.Printf("[OTLP] 指标 %s 与已有指标类型冲突，丢弃", name)
+		return nil
+	}
+	family, ok := c.families[name]
+	if !ok {
+		family = &otlpFamily{kind: kind, help: help}
+		c.families[name] = family
+	}
+	family.refs++
+	s := &otlpSeries{name: name, labelNames: labelNames, labelValues: labelValues}
+	c.series[key] = s
+	return s
+}
+
+// SetValue 写入 gauge/counter 数值，accumulate 为 true 时累加（delta 时间性）
+func (c *OtlpCollector) SetValue(key, name, help string, kind int, labelNames, labelValues []string, value float64, accumulate bool) bool {
+	c.mu.Lock()
+	s := c.getSeries(key, name, help, kind, labelNames, labelValues)
+	if s == nil {
+		c.mu.Unlock()
+		return false
+	}
+	if accumulate {
+		s.value += value
+	} else {
+		s.value = value
+	}
+	value = s.value
+	c.mu.Unlock()
+	recordValue(name, labelNames, labelValues, value)
+	return true
+}
+
+// SetHistogram 写入直方图，buckets 为上界 -> 累计计数
+func (c *OtlpCollector) SetHistogram(key, name, help string, labelNames, labelValues []string, count uint64, sum float64, buckets map[float64]uint64, accumulate bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.getSeries(key, name, help, OtlpKindHistogram, labelNames, labelValues)
+	if s == nil {
+		return false
+	}
+	if accumulate && s.buckets != nil {
+		s.count += count
+		s.sum += sum
+		for bound, n := range buckets {
+			s.buckets[bound] += n
+		}
+		return true
+	}
+	s.count, s.sum, s.buckets = count, sum, buckets
+	return true
+}
+
+// SetSummary 写入摘要
+func (c *OtlpCollector) SetSummary(key, name, help string, labelNames, labelValues []string, count uint64, sum float64, quantiles map[float64]float64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.getSeries(key, name, help, OtlpKindSummary, labelNames, labelValues)
+	if s == nil {
+		return false
+	}
+	s.count, s.sum, s.quantiles = count, sum, quantiles
+	return true
+}
+
+// Delete 删除时间序列，同名指标全部删除后释放其类型
+func (c *OtlpCollector) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		return
+	}
+	delete(c.series, key)
+	if family, ok := c.families[s.name]; ok {
+		family.refs--
+		if family.refs <= 0 {
+			delete(c.families, s.name)
+		}
+	}
+}
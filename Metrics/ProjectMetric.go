@@ -0,0 +1,216 @@
+package Metrics
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// ProjectInfoRow 一个项目的 project_info 标签
+type ProjectInfoRow struct {
+	Code    string
+	Owner   string
+	Contact string
+	Env     string
+	Enabled bool
+	Labels  map[string]string // 自定义标签
+}
+
+// projectInfoLabels project_info 的固定标签，project 与其它指标一样在输出时展开为 project_code 和 project_name
+var projectInfoLabels = []string{"project", "owner", "contact", "env", "enabled"}
+
+// 自定义标签名中不合法的字符替换为下划线
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// ProjectInfoCollector 输出 project_info
+// 各项目的自定义标签不同，所以按全部项目自定义标签的并集输出，没有的标签为空
+type ProjectInfoCollector struct {
+	mu   sync.RWMutex
+	desc *prometheus.Desc
+	rows [][]string
+}
+
+// Set 替换全部项目（项目登记重新加载时调用）
+func (c *ProjectInfoCollector) Set(rows []ProjectInfoRow) {
+	custom := map[string]bool{}
+	for _, row := range rows {
+		for name := range row.Labels {
+			custom[customLabelName(name)] = true
+		}
+	}
+	extra := make([]string, 0, len(custom))
+	for name := range custom {
+		extra = append(extra, name)
+	}
+	sort.Strings(extra)
+
+	values := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		v := []string{row.Code, row.Owner, row.Contact, row.Env, strconv.FormatBool(row.Enabled)}
+		labels := make(map[string]string, len(row.Labels))
+		for name, value := range row.Labels {
+			labels[customLabelName(name)] = value
+		}
+		for _, name := range extra {
+			v = append(v, labels[name])
+		}
+		values = append(values, v)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.desc = prometheus.NewDesc("project_info", "项目登记信息（值恒为 1）", append(append([]string{}, projectInfoLabels...), extra...), nil)
+	c.rows = values
+}
+
+// customLabelName 自定义标签名加 label_ 前缀，避免与固定标签冲突
+func customLabelName(name string) string {
+	return "label_" + invalidLabelChars.ReplaceAllString(name, "_")
+}
+
+// Describe 标签随项目登记变化，不预先声明（unchecked collector）
+func (c *ProjectInfoCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c *ProjectInfoCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, v := range c.rows {
+		metric, err := prometheus.NewConstMetric(c.desc, prometheus.GaugeValue, 1, v...)
+		if err != nil {
+			log.Printf("生成 project_info 失败: %v", err)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+var (
+	// 项目登记信息
+	ProjectInfo = &ProjectInfoCollector{}
+
+	// 被拒绝的上报请求（项目未登记、已停用、source 不允许、密钥不匹配、任务队列已满）
+	IngestRejectedPayloads = NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ingest_rejected_payloads_total",
+			Help: "因项目登记限制或任务队列已满被拒绝的上报请求数量",
+		},
+		[]string{"source", "reason"},
+	)
+)
+
+// 项目标签
+// 指标内部的 project 标签保存项目编码，输出时展开为 project_code（编码）和 project_name（显示名称），
+// 显示名称在每次采集时按当前项目登记填写，项目改名后已有序列立即使用新名称，不需要等待过期
+var (
+	projectNames       = map[string]string{}
+	legacyProjectLabel bool // 同时输出旧版 project 标签（值为显示名称）
+	projectLabelMu     sync.RWMutex
+)
+
+// Gatherer 对外输出的指标（/metrics、远程写入、报告、仪表盘、短期历史都使用它而不是 CustomRegistry）
+var Gatherer prometheus.Gatherer = projectLabelGatherer{CustomRegistry}
+
+// SetProjectNames 设置项目编码 -> 显示名称（项目登记重新加载时调用）
+func SetProjectNames(names map[string]string) {
+	projectLabelMu.Lock()
+	defer projectLabelMu.Unlock()
+	projectNames = names
+}
+
+// SetLegacyProjectLabel 设置是否同时输出旧版 project 标签
+func SetLegacyProjectLabel(enabled bool) {
+	projectLabelMu.Lock()
+	defer projectLabelMu.Unlock()
+	legacyProjectLabel = enabled
+}
+
+// projectLabelGatherer 展开 project 标签的 Gatherer
+type projectLabelGatherer struct {
+	gatherer prometheus.Gatherer
+}
+
+func (g projectLabelGatherer) Gather() ([]*dto.MetricFamily, error) {
+	// Registry 每次采集都生成新的 dto，可以直接修改
+	families, err := g.gatherer.Gather()
+
+	projectLabelMu.RLock()
+	names, legacy := projectNames, legacyProjectLabel
+	projectLabelMu.RUnlock()
+	legacyController := legacyControllerLabelEnabled()
+
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			m.Label = expandProjectLabel(m.GetLabel(), names, legacy)
+			if legacyController {
+				m.Label = addLegacyControllerLabel(mf.GetName(), m.Label)
+			}
+		}
+	}
+	return families, err
+}
+
+// ExpandLabels 按与 Gatherer 相同的规则展开一条序列的标签（短期历史查询时使用）
+// labels 中的 project 为项目编码，返回新的 map
+func ExpandLabels(name string, labels map[string]string) map[string]string {
+	pairs := make([]*dto.LabelPair, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, labelPair(k, v))
+	}
+
+	projectLabelMu.RLock()
+	names, legacy := projectNames, legacyProjectLabel
+	projectLabelMu.RUnlock()
+	pairs = expandProjectLabel(pairs, names, legacy)
+	if legacyControllerLabelEnabled() {
+		pairs = addLegacyControllerLabel(name, pairs)
+	}
+
+	result := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		result[pair.GetName()] = pair.GetValue()
+	}
+	return result
+}
+
+// expandProjectLabel 将 project 标签替换为 project_code 和 project_name，没有 project 标签时原样返回
+func expandProjectLabel(labels []*dto.LabelPair, names map[string]string, legacy bool) []*dto.LabelPair {
+	index := -1
+	for i, label := range labels {
+		if label.GetName() == "project" {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return labels
+	}
+
+	code := labels[index].GetValue()
+	name, ok := names[code]
+	if !ok {
+		// 未登记的项目，显示名称就是编码
+		name = code
+	}
+
+	result := make([]*dto.LabelPair, 0, len(labels)+2)
+	for i, label := range labels {
+		if i != index {
+			result = append(result, label)
+		}
+	}
+	result = append(result, labelPair("project_code", code), labelPair("project_name", name))
+	if legacy {
+		result = append(result, labelPair("project", name))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].GetName() < result[j].GetName() })
+	return result
+}
+
+func labelPair(name, value string) *dto.LabelPair {
+	return &dto.LabelPair{Name: &name, Value: &value}
+}
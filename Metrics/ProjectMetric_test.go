@@ -0,0 +1,77 @@
+package Metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func labelString(labels []*dto.LabelPair) string {
+	parts := make([]string, 0, len(labels))
+	for _, label := range labels {
+		parts = append(parts, label.GetName()+"="+label.GetValue())
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestExpandProjectLabel(t *testing.T) {
+	names := map[string]string{"jxh": "戒享花"}
+	tests := []struct {
+		name   string
+		labels []*dto.LabelPair
+		legacy bool
+		want   string
+	}{
+		{
+			name:   "registered project",
+			labels: []*dto.LabelPair{labelPair("hostName", "node-1"), labelPair("project", "jxh")},
+			want:   "hostName=node-1,project_code=jxh,project_name=戒享花",
+		},
+		{
+			name:   "unregistered project uses code as name",
+			labels: []*dto.LabelPair{labelPair("project", "demo"), labelPair("zone", "a")},
+			want:   "project_code=demo,project_name=demo,zone=a",
+		},
+		{
+			name:   "legacy label keeps display name",
+			labels: []*dto.LabelPair{labelPair("domain", "a.com"), labelPair("project", "jxh")},
+			legacy: true,
+			want:   "domain=a.com,project=戒享花,project_code=jxh,project_name=戒享花",
+		},
+		{
+			name:   "no project label",
+			labels: []*dto.LabelPair{labelPair("source", "hard")},
+			legacy: true,
+			want:   "source=hard",
+		},
+	}
+	for _, tt := range tests {
+		if got := labelString(expandProjectLabel(tt.labels, names, tt.legacy)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// 改名后已有序列在下一次采集时使用新名称
+func TestProjectLabelGathererRename(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_rename"}, []string{"project"})
+	registry.MustRegister(gauge)
+	gauge.WithLabelValues("jxh").Set(1)
+	gatherer := projectLabelGatherer{registry}
+	defer SetProjectNames(map[string]string{})
+
+	for _, name := range []string{"旧名称", "新名称"} {
+		SetProjectNames(map[string]string{"jxh": name})
+		families, err := gatherer.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := labelString(families[0].GetMetric()[0].GetLabel())
+		if want := "project_code=jxh,project_name=" + name; got != want {
+			t.Errorf("labels = %s, want %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package Metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// 远程写入待发送的 WAL 段数量
+	RemoteWritePendingSegments = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "remote_write_pending_segments",
+			Help: "远程写入待发送的 WAL 段数量",
+		},
+		[]string{"endpoint"},
+	)
+
+	// 远程写入最后一次成功的时间戳
+	RemoteWriteLastSuccessTimestamp = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "remote_write_last_success_timestamp",
+			Help: "远程写入最后一次成功的时间戳（秒）",
+		},
+		[]string{"endpoint"},
+	)
+
+	// 远程写入失败次数
+	RemoteWriteFailures = NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "remote_write_failures_total",
+			Help: "远程写入失败次数",
+		},
+		[]string{"endpoint"},
+	)
+)
@@ -4,7 +4,7 @@ import "github.com/prometheus/client_golang/prometheus"
 
 var (
 	//ssl
-	SslDaysLeftMetric = prometheus.NewGaugeVec(
+	SslDaysLeftMetric = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "ssl_domain_days_left", // SSL 证书剩余天数
 			Help: "SSL 证书到期前的剩余天数",
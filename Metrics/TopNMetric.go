@@ -0,0 +1,30 @@
+package Metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// 日志统计排行，每个项目只保留前 N 名，其余合并到 "other"
+var (
+	ClientIpRequestCountMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "client_ip_request_count", // 客户端 IP 请求数
+			Help: "客户端 IP 请求数（前 N 名，其余合并为 other）",
+		},
+		[]string{"client_ip", "project"},
+	)
+
+	CountryRequestCountMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "country_request_count", // 国家/地区请求数
+			Help: "国家/地区请求数（前 N 名，其余合并为 other）",
+		},
+		[]string{"country", "project"},
+	)
+
+	UrlRequestCountMetric = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "url_request_count", // URL 请求数
+			Help: "URL 请求数（不含查询参数，前 N 名，其余合并为 other）",
+		},
+		[]string{"url", "project"},
+	)
+)
@@ -5,9 +5,15 @@ import "github.com/prometheus/client_golang/prometheus"
 // trafficSwitchingLabels 通用标签：service, project
 var trafficSwitchingLabels = []string{"service", "project"}
 
+// 按后端、路由拆分的标签
+var (
+	trafficSwitchingBackendLabels = []string{"service", "project", "target", "version"}
+	trafficSwitchingRouteLabels   = []string{"service", "project", "route"}
+)
+
 var (
 	// 累计请求统计
-	TrafficSwitchingTotalRequests = prometheus.NewGaugeVec(
+	TrafficSwitchingTotalRequests = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_total_requests",
 			Help: "累计请求总数",
@@ -15,7 +21,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingTotalSuccess = prometheus.NewGaugeVec(
+	TrafficSwitchingTotalSuccess = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_total_success",
 			Help: "累计成功请求数",
@@ -23,7 +29,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingTotalErrors = prometheus.NewGaugeVec(
+	TrafficSwitchingTotalErrors = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_total_errors",
 			Help: "累计失败请求数",
@@ -31,7 +37,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingTotalSuccessRate = prometheus.NewGaugeVec(
+	TrafficSwitchingTotalSuccessRate = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_total_success_rate",
 			Help: "累计成功率（0-1）",
@@ -40,7 +46,7 @@ var (
 	)
 
 	// 今日统计
-	TrafficSwitchingTodayRequests = prometheus.NewGaugeVec(
+	TrafficSwitchingTodayRequests = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_today_requests",
 			Help: "今日请求数",
@@ -48,7 +54,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingTodaySuccess = prometheus.NewGaugeVec(
+	TrafficSwitchingTodaySuccess = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_today_success",
 			Help: "今日成功数",
@@ -56,7 +62,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingTodayErrors = prometheus.NewGaugeVec(
+	TrafficSwitchingTodayErrors = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_today_errors",
 			Help: "今日错误数",
@@ -64,7 +70,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingTodayCanceled = prometheus.NewGaugeVec(
+	TrafficSwitchingTodayCanceled = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_today_canceled",
 			Help: "今日取消数",
@@ -72,7 +78,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingTodayStatus2xx = prometheus.NewGaugeVec(
+	TrafficSwitchingTodayStatus2xx = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_today_status_2xx",
 			Help: "今日 2xx 状态码数",
@@ -80,7 +86,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingTodayStatus3xx = prometheus.NewGaugeVec(
+	TrafficSwitchingTodayStatus3xx = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_today_status_3xx",
 			Help: "今日 3xx 状态码数",
@@ -88,7 +94,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingTodayStatus4xx = prometheus.NewGaugeVec(
+	TrafficSwitchingTodayStatus4xx = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_today_status_4xx",
 			Help: "今日 4xx 状态码数",
@@ -96,7 +102,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingTodayStatus5xx = prometheus.NewGaugeVec(
+	TrafficSwitchingTodayStatus5xx = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_today_status_5xx",
 			Help: "今日 5xx 状态码数",
@@ -105,7 +111,7 @@ var (
 	)
 
 	// 实时统计
-	TrafficSwitchingRealtimeQPS = prometheus.NewGaugeVec(
+	TrafficSwitchingRealtimeQPS = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_realtime_qps",
 			Help: "实时 QPS",
@@ -113,7 +119,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingRealtimeSuccessQPS = prometheus.NewGaugeVec(
+	TrafficSwitchingRealtimeSuccessQPS = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_realtime_success_qps",
 			Help: "实时成功 QPS",
@@ -121,7 +127,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingRealtimeErrorQPS = prometheus.NewGaugeVec(
+	TrafficSwitchingRealtimeErrorQPS = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_realtime_error_qps",
 			Help: "实时错误 QPS",
@@ -129,7 +135,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingRealtimeActiveConnections = prometheus.NewGaugeVec(
+	TrafficSwitchingRealtimeActiveConnections = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_realtime_active_connections",
 			Help: "实时活跃连接数",
@@ -137,7 +143,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingRealtimeAvgLatencyMs = prometheus.NewGaugeVec(
+	TrafficSwitchingRealtimeAvgLatencyMs = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_realtime_avg_latency_ms",
 			Help: "实时平均延迟（毫秒）",
@@ -145,7 +151,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingRealtimeMaxLatencyMs = prometheus.NewGaugeVec(
+	TrafficSwitchingRealtimeMaxLatencyMs = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_realtime_max_latency_ms",
 			Help: "实时最大延迟（毫秒）",
@@ -154,7 +160,7 @@ var (
 	)
 
 	// 错误类型统计
-	TrafficSwitchingErrorBackendError = prometheus.NewGaugeVec(
+	TrafficSwitchingErrorBackendError = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_error_backend_error",
 			Help: "后端错误数",
@@ -162,7 +168,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingErrorBrokenPipe = prometheus.NewGaugeVec(
+	TrafficSwitchingErrorBrokenPipe = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_error_broken_pipe",
 			Help: "管道断开错误数",
@@ -170,7 +176,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingErrorConnectionRefused = prometheus.NewGaugeVec(
+	TrafficSwitchingErrorConnectionRefused = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_error_connection_refused",
 			Help: "连接拒绝错误数",
@@ -178,7 +184,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingErrorConnectionReset = prometheus.NewGaugeVec(
+	TrafficSwitchingErrorConnectionReset = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_error_connection_reset",
 			Help: "连接重置错误数",
@@ -186,7 +192,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingErrorDNSError = prometheus.NewGaugeVec(
+	TrafficSwitchingErrorDNSError = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_error_dns_error",
 			Help: "DNS 解析错误数",
@@ -194,7 +200,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingErrorEOF = prometheus.NewGaugeVec(
+	TrafficSwitchingErrorEOF = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_error_eof",
 			Help: "EOF 错误数",
@@ -202,7 +208,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingErrorTimeout = prometheus.NewGaugeVec(
+	TrafficSwitchingErrorTimeout = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_error_timeout",
 			Help: "超时错误数",
@@ -211,7 +217,7 @@ var (
 	)
 
 	// 代理缓存
-	TrafficSwitchingProxyCacheSize = prometheus.NewGaugeVec(
+	TrafficSwitchingProxyCacheSize = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_proxy_cache_size",
 			Help: "当前代理缓存大小",
@@ -219,7 +225,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingProxyMaxCacheSize = prometheus.NewGaugeVec(
+	TrafficSwitchingProxyMaxCacheSize = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_proxy_max_cache_size",
 			Help: "代理缓存最大限制",
@@ -228,7 +234,7 @@ var (
 	)
 
 	// Runtime 指标
-	TrafficSwitchingRuntimeGoroutines = prometheus.NewGaugeVec(
+	TrafficSwitchingRuntimeGoroutines = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_runtime_goroutines",
 			Help: "当前 Goroutine 数量",
@@ -236,7 +242,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingRuntimeMemoryMB = prometheus.NewGaugeVec(
+	TrafficSwitchingRuntimeMemoryMB = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_runtime_memory_mb",
 			Help: "Go 程序当前使用的内存（MB）",
@@ -244,7 +250,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingRuntimeCPUCores = prometheus.NewGaugeVec(
+	TrafficSwitchingRuntimeCPUCores = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_runtime_cpu_cores",
 			Help: "机器CPU核心数",
@@ -252,7 +258,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingRuntimeGomaxprocs = prometheus.NewGaugeVec(
+	TrafficSwitchingRuntimeGomaxprocs = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_runtime_gomaxprocs",
 			Help: "GOMAXPROCS 值",
@@ -260,7 +266,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingRuntimeGcCycles = prometheus.NewGaugeVec(
+	TrafficSwitchingRuntimeGcCycles = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_runtime_gc_cycles",
 			Help: "GC 运行次数",
@@ -269,7 +275,7 @@ var (
 	)
 
 	// Transport 配置
-	TrafficSwitchingTransportMaxConnsPerHost = prometheus.NewGaugeVec(
+	TrafficSwitchingTransportMaxConnsPerHost = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_transport_max_conns_per_host",
 			Help: "每个主机最大连接数",
@@ -277,7 +283,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingTransportMaxIdleConns = prometheus.NewGaugeVec(
+	TrafficSwitchingTransportMaxIdleConns = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_transport_max_idle_conns",
 			Help: "全局最大空闲连接数",
@@ -285,7 +291,7 @@ var (
 		trafficSwitchingLabels,
 	)
 
-	TrafficSwitchingTransportMaxIdleConnsPerHost = prometheus.NewGaugeVec(
+	TrafficSwitchingTransportMaxIdleConnsPerHost = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_transport_max_idle_conns_per_host",
 			Help: "每个主机最大空闲连接数",
@@ -294,11 +300,135 @@ var (
 	)
 
 	// 上报时间戳
-	TrafficSwitchingTimestamp = prometheus.NewGaugeVec(
+	TrafficSwitchingTimestamp = NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "trafficswitching_timestamp",
 			Help: "本次指标上报的时间戳",
 		},
 		trafficSwitchingLabels,
 	)
+
+	// ====================== 按后端拆分 ======================
+	TrafficSwitchingBackendWeight = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_backend_weight",
+			Help: "后端配置的权重",
+		},
+		trafficSwitchingBackendLabels,
+	)
+
+	TrafficSwitchingBackendAvgLatencyMs = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_backend_avg_latency_ms",
+			Help: "后端平均延迟（毫秒）",
+		},
+		trafficSwitchingBackendLabels,
+	)
+
+	TrafficSwitchingBackendRequestsIncrease5m = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_backend_requests_increase_5m",
+			Help: "后端最近 5 分钟新增请求数",
+		},
+		trafficSwitchingBackendLabels,
+	)
+
+	TrafficSwitchingBackendErrorsIncrease5m = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_backend_errors_increase_5m",
+			Help: "后端最近 5 分钟新增失败请求数",
+		},
+		trafficSwitchingBackendLabels,
+	)
+
+	TrafficSwitchingBackendSuccessRatio5m = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_backend_success_ratio_5m",
+			Help: "后端最近 5 分钟成功率（0-1），没有请求时不输出",
+		},
+		trafficSwitchingBackendLabels,
+	)
+
+	// 实际流量占比 - 配置权重占比，正数表示实际流量多于配置
+	TrafficSwitchingWeightDrift = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_weight_drift",
+			Help: "后端最近 5 分钟实际流量占比与配置权重占比之差（-1~1）",
+		},
+		trafficSwitchingBackendLabels,
+	)
+
+	// ====================== 按路由拆分 ======================
+	TrafficSwitchingRouteAvgLatencyMs = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_route_avg_latency_ms",
+			Help: "路由平均延迟（毫秒）",
+		},
+		trafficSwitchingRouteLabels,
+	)
+
+	// ====================== 昨日统计（今日统计归零前的最终值） ======================
+	TrafficSwitchingYesterdayRequests = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_yesterday_requests",
+			Help: "昨日请求总数",
+		},
+		trafficSwitchingLabels,
+	)
+
+	TrafficSwitchingYesterdaySuccess = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_yesterday_success",
+			Help: "昨日成功请求数",
+		},
+		trafficSwitchingLabels,
+	)
+
+	TrafficSwitchingYesterdayErrors = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_yesterday_errors",
+			Help: "昨日失败请求数",
+		},
+		trafficSwitchingLabels,
+	)
+
+	TrafficSwitchingYesterdayCanceled = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_yesterday_canceled",
+			Help: "昨日取消请求数",
+		},
+		trafficSwitchingLabels,
+	)
+
+	TrafficSwitchingYesterdayStatus2xx = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_yesterday_status_2xx",
+			Help: "昨日2xx 响应数",
+		},
+		trafficSwitchingLabels,
+	)
+
+	TrafficSwitchingYesterdayStatus3xx = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_yesterday_status_3xx",
+			Help: "昨日3xx 响应数",
+		},
+		trafficSwitchingLabels,
+	)
+
+	TrafficSwitchingYesterdayStatus4xx = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_yesterday_status_4xx",
+			Help: "昨日4xx 响应数",
+		},
+		trafficSwitchingLabels,
+	)
+
+	TrafficSwitchingYesterdayStatus5xx = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trafficswitching_yesterday_status_5xx",
+			Help: "昨日5xx 响应数",
+		},
+		trafficSwitchingLabels,
+	)
 )
@@ -0,0 +1,165 @@
+package Metrics
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// SeriesRecorder 接收每次指标更新（短期历史使用）
+// labelValues 中的 project 为项目编码，t 为写入指标的时间
+type SeriesRecorder func(name string, labelNames, labelValues []string, value float64, t time.Time)
+
+// 每次指标更新都会读取，使用原子指针避免上报处理在锁上竞争
+var seriesRecorder atomic.Pointer[SeriesRecorder]
+
+// SetSeriesRecorder 设置指标更新的接收方，为 nil 时不记录
+func SetSeriesRecorder(recorder SeriesRecorder) {
+	if recorder == nil {
+		seriesRecorder.Store(nil)
+		return
+	}
+	seriesRecorder.Store(&recorder)
+}
+
+func getSeriesRecorder() SeriesRecorder {
+	if recorder := seriesRecorder.Load(); recorder != nil {
+		return *recorder
+	}
+	return nil
+}
+
+// recordValue 通知一次指标更新
+func recordValue(name string, labelNames, labelValues []string, value float64) {
+	if recorder := getSeriesRecorder(); recorder != nil {
+		recorder(name, labelNames, labelValues, value, time.Now())
+	}
+}
+
+// GaugeVec 在 prometheus.GaugeVec 的基础上，每次更新时通知短期历史
+type GaugeVec struct {
+	*prometheus.GaugeVec
+	name       string
+	labelNames []string
+}
+
+// NewGaugeVec 创建 GaugeVec，用法与 prometheus.NewGaugeVec 相同
+func NewGaugeVec(opts prometheus.GaugeOpts, labelNames []string) *GaugeVec {
+	return &GaugeVec{
+		GaugeVec:   prometheus.NewGaugeVec(opts, labelNames),
+		name:       prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		labelNames: labelNames,
+	}
+}
+
+// WithLabelValues 未设置接收方时直接返回 prometheus 的子指标，不额外分配
+// 返回值只用于本次更新，不要保存：之后才设置接收方时，保存下来的子指标不会通知短期历史
+func (v *GaugeVec) WithLabelValues(labelValues ...string) prometheus.Gauge {
+	gauge := v.GaugeVec.WithLabelValues(labelValues...)
+	if getSeriesRecorder() == nil {
+		return gauge
+	}
+	return recordingGauge{Gauge: gauge, name: v.name, labelNames: v.labelNames, labelValues: labelValues}
+}
+
+type recordingGauge struct {
+	prometheus.Gauge
+	name        string
+	labelNames  []string
+	labelValues []string
+}
+
+func (g recordingGauge) Set(value float64) {
+	g.Gauge.Set(value)
+	recordValue(g.name, g.labelNames, g.labelValues, value)
+}
+
+func (g recordingGauge) Inc() {
+	g.Gauge.Inc()
+	g.record()
+}
+
+func (g recordingGauge) Dec() {
+	g.Gauge.Dec()
+	g.record()
+}
+
+func (g recordingGauge) Add(value float64) {
+	g.Gauge.Add(value)
+	g.record()
+}
+
+func (g recordingGauge) Sub(value float64) {
+	g.Gauge.Sub(value)
+	g.record()
+}
+
+func (g recordingGauge) SetToCurrentTime() {
+	g.Gauge.SetToCurrentTime()
+	g.record()
+}
+
+// record 读取累加后的当前值
+func (g recordingGauge) record() {
+	if getSeriesRecorder() == nil {
+		return // 子指标取得之后接收方被清除
+	}
+	m := &dto.Metric{}
+	if err := g.Gauge.Write(m); err == nil {
+		recordValue(g.name, g.labelNames, g.labelValues, m.GetGauge().GetValue())
+	}
+}
+
+// CounterVec 在 prometheus.CounterVec 的基础上，每次更新时通知短期历史
+type CounterVec struct {
+	*prometheus.CounterVec
+	name       string
+	labelNames []string
+}
+
+// NewCounterVec 创建 CounterVec，用法与 prometheus.NewCounterVec 相同
+func NewCounterVec(opts prometheus.CounterOpts, labelNames []string) *CounterVec {
+	return &CounterVec{
+		CounterVec: prometheus.NewCounterVec(opts, labelNames),
+		name:       prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		labelNames: labelNames,
+	}
+}
+
+// WithLabelValues 未设置接收方时直接返回 prometheus 的子指标，不额外分配（同 GaugeVec）
+func (v *CounterVec) WithLabelValues(labelValues ...string) prometheus.Counter {
+	counter := v.CounterVec.WithLabelValues(labelValues...)
+	if getSeriesRecorder() == nil {
+		return counter
+	}
+	return recordingCounter{Counter: counter, name: v.name, labelNames: v.labelNames, labelValues: labelValues}
+}
+
+type recordingCounter struct {
+	prometheus.Counter
+	name        string
+	labelNames  []string
+	labelValues []string
+}
+
+func (c recordingCounter) Inc() {
+	c.Counter.Inc()
+	c.record()
+}
+
+func (c recordingCounter) Add(value float64) {
+	c.Counter.Add(value)
+	c.record()
+}
+
+func (c recordingCounter) record() {
+	if getSeriesRecorder() == nil {
+		return
+	}
+	m := &dto.Metric{}
+	if err := c.Counter.Write(m); err == nil {
+		recordValue(c.name, c.labelNames, c.labelValues, m.GetCounter().GetValue())
+	}
+}
@@ -0,0 +1,81 @@
+package Metrics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type recorded struct {
+	name   string
+	labels []string
+	value  float64
+}
+
+func TestRecorderWrappers(t *testing.T) {
+	defer SetSeriesRecorder(nil)
+
+	gauges := NewGaugeVec(prometheus.GaugeOpts{Name: "test_gauge"}, []string{"project"})
+	counters := NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"project"})
+
+	tests := []struct {
+		name   string
+		on     bool
+		update func()
+		want   []recorded
+	}{
+		{
+			name:   "off returns plain gauge",
+			update: func() { gauges.WithLabelValues("shop").Set(1) },
+		},
+		{
+			name:   "off returns plain counter",
+			update: func() { counters.WithLabelValues("shop").Add(2) },
+		},
+		{
+			name:   "gauge set",
+			on:     true,
+			update: func() { gauges.WithLabelValues("shop").Set(5) },
+			want:   []recorded{{"test_gauge", []string{"shop"}, 5}},
+		},
+		{
+			name: "gauge add records accumulated value",
+			on:   true,
+			update: func() {
+				gauges.WithLabelValues("shop").Add(2)
+				gauges.WithLabelValues("pay").Inc()
+			},
+			want: []recorded{{"test_gauge", []string{"shop"}, 7}, {"test_gauge", []string{"pay"}, 1}},
+		},
+		{
+			name:   "counter includes updates made while off",
+			on:     true,
+			update: func() { counters.WithLabelValues("shop").Inc() },
+			want:   []recorded{{"test_total", []string{"shop"}, 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []recorded
+			SetSeriesRecorder(nil)
+			if tt.on {
+				SetSeriesRecorder(func(name string, _, labelValues []string, value float64, _ time.Time) {
+					got = append(got, recorded{name, labelValues, value})
+				})
+			}
+
+			_, wrappedGauge := gauges.WithLabelValues("shop").(recordingGauge)
+			_, wrappedCounter := counters.WithLabelValues("shop").(recordingCounter)
+			if wrappedGauge != tt.on || wrappedCounter != tt.on {
+				t.Errorf("wrapped gauge=%v counter=%v, want %v", wrappedGauge, wrappedCounter, tt.on)
+			}
+
+			tt.update()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recorded = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -24,31 +24,113 @@ type HardSource struct {
 	CPUModel          string  `json:"cpu_model" mapstructure:"cpu_model"`
 	OSVersion         string  `json:"os_version" mapstructure:"os_version"`
 	KernelVersion     string  `json:"kernel_version" mapstructure:"kernel_version"`
+	Timestamp         float64 `json:"timestamp" mapstructure:"timestamp"` // 采集时间戳（可选，秒或毫秒）
+
+	Mountpoints []HardMountpoint `json:"mountpoints" mapstructure:"mountpoints"` // 挂载点（可选）
+	Disks       []HardDisk       `json:"disks" mapstructure:"disks"`             // 块设备（可选）
+	Interfaces  []HardInterface  `json:"interfaces" mapstructure:"interfaces"`   // 网卡（可选）
+}
+
+// HardMountpoint 挂载点使用情况
+type HardMountpoint struct {
+	Mountpoint        string  `json:"mountpoint" mapstructure:"mountpoint"`
+	Device            string  `json:"device" mapstructure:"device"`
+	FsType            string  `json:"fs_type" mapstructure:"fs_type"`
+	Total             float64 `json:"total" mapstructure:"total"`
+	Used              float64 `json:"used" mapstructure:"used"`
+	Free              float64 `json:"free" mapstructure:"free"`
+	UsedPercent       float64 `json:"used_percent" mapstructure:"used_percent"`
+	InodesTotal       float64 `json:"inodes_total" mapstructure:"inodes_total"`
+	InodesUsed        float64 `json:"inodes_used" mapstructure:"inodes_used"`
+	InodesFree        float64 `json:"inodes_free" mapstructure:"inodes_free"`
+	InodesUsedPercent float64 `json:"inodes_used_percent" mapstructure:"inodes_used_percent"`
 }
+
+// HardDisk 块设备 IO
+type HardDisk struct {
+	Device           string  `json:"device" mapstructure:"device"`
+	ReadIops         float64 `json:"read_iops" mapstructure:"read_iops"`
+	WriteIops        float64 `json:"write_iops" mapstructure:"write_iops"`
+	ReadBytesPerSec  float64 `json:"read_bytes_per_sec" mapstructure:"read_bytes_per_sec"`
+	WriteBytesPerSec float64 `json:"write_bytes_per_sec" mapstructure:"write_bytes_per_sec"`
+	ReadAwaitMs      float64 `json:"read_await_ms" mapstructure:"read_await_ms"`
+	WriteAwaitMs     float64 `json:"write_await_ms" mapstructure:"write_await_ms"`
+	IoUtilPercent    float64 `json:"io_util_percent" mapstructure:"io_util_percent"`
+}
+
+// HardInterface 网卡流量（累计值）
+type HardInterface struct {
+	Interface string  `json:"interface" mapstructure:"interface"`
+	RxBytes   float64 `json:"rx_bytes" mapstructure:"rx_bytes"`
+	TxBytes   float64 `json:"tx_bytes" mapstructure:"tx_bytes"`
+	RxPackets float64 `json:"rx_packets" mapstructure:"rx_packets"`
+	TxPackets float64 `json:"tx_packets" mapstructure:"tx_packets"`
+	RxErrors  float64 `json:"rx_errors" mapstructure:"rx_errors"`
+	TxErrors  float64 `json:"tx_errors" mapstructure:"tx_errors"`
+	RxDropped float64 `json:"rx_dropped" mapstructure:"rx_dropped"`
+	TxDropped float64 `json:"tx_dropped" mapstructure:"tx_dropped"`
+}
+
 type NginxSource struct {
-	HostName       string `json:"hostName" mapstructure:"hostName"`
-	IsRun          int    `json:"isRun" mapstructure:"isRun"`
-	ReTotal        int    `json:"reTotal" mapstructure:"reTotal"`
-	LoginUserCount int    `json:"loginUserCount" mapstructure:"loginUserCount"`
-	RawTotal       int    `json:"rawTotal" mapstructure:"rawTotal"`
-	Udptotal       int    `json:"udptotal" mapstructure:"udptotal"`
-	TcpTotal       int    `json:"tcpTotal" mapstructure:"tcpTotal"`
-	TotalTcp       int    `json:"totaltcp" mapstructure:"totaltcp"`
-	InetTotal      int    `json:"inetTotal" mapstructure:"inetTotal"`
-	FragTotal      int    `json:"fragTotal" mapstructure:"fragTotal"`
-	TcpEstab       int    `json:"tcpEstab" mapstructure:"tcpEstab"`
-	TcpClosed      int    `json:"tcpClosed" mapstructure:"tcpClosed"`
-	TcpOrphaned    int    `json:"tcpOrphaned" mapstructure:"tcpOrphaned"`
-	TcpTimewait    int    `json:"tcpTimewait" mapstructure:"tcpTimewait"`
+	HostName       string  `json:"hostName" mapstructure:"hostName"`
+	IsRun          int     `json:"isRun" mapstructure:"isRun"`
+	ReTotal        int     `json:"reTotal" mapstructure:"reTotal"`
+	LoginUserCount int     `json:"loginUserCount" mapstructure:"loginUserCount"`
+	RawTotal       int     `json:"rawTotal" mapstructure:"rawTotal"`
+	Udptotal       int     `json:"udptotal" mapstructure:"udptotal"`
+	TcpTotal       int     `json:"tcpTotal" mapstructure:"tcpTotal"`
+	TotalTcp       int     `json:"totaltcp" mapstructure:"totaltcp"`
+	InetTotal      int     `json:"inetTotal" mapstructure:"inetTotal"`
+	FragTotal      int     `json:"fragTotal" mapstructure:"fragTotal"`
+	TcpEstab       int     `json:"tcpEstab" mapstructure:"tcpEstab"`
+	TcpClosed      int     `json:"tcpClosed" mapstructure:"tcpClosed"`
+	TcpOrphaned    int     `json:"tcpOrphaned" mapstructure:"tcpOrphaned"`
+	TcpTimewait    int     `json:"tcpTimewait" mapstructure:"tcpTimewait"`
+	Timestamp      float64 `json:"timestamp" mapstructure:"timestamp"` // 采集时间戳（可选，秒或毫秒）
+
+	StubStatus    *NginxStubStatus    `json:"stub_status" mapstructure:"stub_status"`       // stub_status 模块数据，未开启时为空
+	UpstreamPeers []NginxUpstreamPeer `json:"upstream_peers" mapstructure:"upstream_peers"` // upstream 后端状态
+	Servers       []NginxServerStatus `json:"servers" mapstructure:"servers"`               // 各 server 块的状态码统计
+}
+
+// NginxStubStatus stub_status 输出，accepts/handled/requests 为累计值
+type NginxStubStatus struct {
+	Active   float64 `json:"active" mapstructure:"active"`
+	Reading  float64 `json:"reading" mapstructure:"reading"`
+	Writing  float64 `json:"writing" mapstructure:"writing"`
+	Waiting  float64 `json:"waiting" mapstructure:"waiting"`
+	Accepts  float64 `json:"accepts" mapstructure:"accepts"`
+	Handled  float64 `json:"handled" mapstructure:"handled"`
+	Requests float64 `json:"requests" mapstructure:"requests"`
+}
+
+// NginxUpstreamPeer upstream 中单个后端的状态
+type NginxUpstreamPeer struct {
+	Upstream       string  `json:"upstream" mapstructure:"upstream"`                 // upstream 名称
+	Server         string  `json:"server" mapstructure:"server"`                     // 后端地址，如 10.0.0.1:8080
+	State          string  `json:"state" mapstructure:"state"`                       // up、down、unhealthy
+	Fails          float64 `json:"fails" mapstructure:"fails"`                       // 失败次数
+	ResponseTimeMs float64 `json:"response_time_ms" mapstructure:"response_time_ms"` // 平均响应时间（毫秒）
+}
+
+// NginxServerStatus server 块的累计响应数，按状态码分类
+type NginxServerStatus struct {
+	ServerName string  `json:"server_name" mapstructure:"server_name"`
+	Status1xx  float64 `json:"status_1xx" mapstructure:"status_1xx"`
+	Status2xx  float64 `json:"status_2xx" mapstructure:"status_2xx"`
+	Status3xx  float64 `json:"status_3xx" mapstructure:"status_3xx"`
+	Status4xx  float64 `json:"status_4xx" mapstructure:"status_4xx"`
+	Status5xx  float64 `json:"status_5xx" mapstructure:"status_5xx"`
 }
 
 type SslSource struct {
-	Domain     string `json:"domain" mapstructure:"domain"`
-	Comment    string `json:"comment" mapstructure:"comment"`
-	Expiration string `json:"expiration" mapstructure:"expiration"`
-	DaysLeft   int    `json:"days_left" mapstructure:"days_left"`
-	Status     string `json:"status" mapstructure:"status"`
-	Resolve    bool   `json:"resolve" mapstructure:"resolve"`
+	Domain     string  `json:"domain" mapstructure:"domain"`
+	Comment    string  `json:"comment" mapstructure:"comment"`
+	Expiration string  `json:"expiration" mapstructure:"expiration"`
+	DaysLeft   int     `json:"days_left" mapstructure:"days_left"`
+	Status     string  `json:"status" mapstructure:"status"`
+	Resolve    bool    `json:"resolve" mapstructure:"resolve"`
+	Timestamp  float64 `json:"timestamp" mapstructure:"timestamp"` // 采集时间戳（可选，秒或毫秒）
 }
 
 type ContainerResource struct {
@@ -64,14 +146,42 @@ type ContainerResource struct {
 	UseMemory           int64   `json:"useMemory" mapstructure:"useMemory"`         // 使用的内存字节数
 	RestartCount        int     `json:"restartCount" mapstructure:"restartCount"`   // 重启次数
 	LastTerminationTime int64   `json:"lastTerminationTime" mapstructure:"lastTerminationTime"`
+	Timestamp           float64 `json:"timestamp" mapstructure:"timestamp"` // 采集时间戳（可选，秒或毫秒）
+
+	CpuThrottledSeconds   float64 `json:"cpuThrottledSeconds" mapstructure:"cpuThrottledSeconds"`     // 累计 CPU 被限流时间（秒）
+	CpuThrottledRatio     float64 `json:"cpuThrottledRatio" mapstructure:"cpuThrottledRatio"`         // 采集周期内被限流的 CFS 周期占比（0-1）
+	OomKillCount          int     `json:"oomKillCount" mapstructure:"oomKillCount"`                   // 累计 OOM Kill 次数
+	WaitingReason         string  `json:"waitingReason" mapstructure:"waitingReason"`                 // 等待原因，如 CrashLoopBackOff、ImagePullBackOff
+	LastTerminationReason string  `json:"lastTerminationReason" mapstructure:"lastTerminationReason"` // 上次终止原因，如 OOMKilled、Error
+	PodPhase              string  `json:"podPhase" mapstructure:"podPhase"`                           // Pod 阶段，如 Running、Pending
+	NodeName              string  `json:"nodeName" mapstructure:"nodeName"`                           // 所在节点
 }
 type ControllerResource struct {
-	Namespace           string `json:"namespace" mapstructure:"namespace"`
-	Container           string `json:"container" mapstructure:"container"`
-	ControllerType      string `json:"controllerType" mapstructure:"controllerType"`
-	Replicas            int32  `json:"replicas" mapstructure:"replicas"`
-	ReplicasAvailable   int32  `json:"replicas_available" mapstructure:"replicas_available"`
-	ReplicasUnavailable int32  `json:"replicas_unavailable" mapstructure:"replicas_unavailable"`
+	Namespace           string  `json:"namespace" mapstructure:"namespace"`
+	Container           string  `json:"container" mapstructure:"container"`
+	ControllerType      string  `json:"controllerType" mapstructure:"controllerType"`
+	Replicas            int32   `json:"replicas" mapstructure:"replicas"`
+	ReplicasAvailable   int32   `json:"replicas_available" mapstructure:"replicas_available"`
+	ReplicasUnavailable int32   `json:"replicas_unavailable" mapstructure:"replicas_unavailable"`
+	Timestamp           float64 `json:"timestamp" mapstructure:"timestamp"` // 采集时间戳（可选，秒或毫秒）
+
+	ControllerName     string                `json:"controllerName" mapstructure:"controllerName"`           // 控制器名称，旧版 agent 未上报时使用 container
+	ReplicasReady      int32                 `json:"replicas_ready" mapstructure:"replicas_ready"`           // 就绪副本数
+	ReplicasUpdated    int32                 `json:"replicas_updated" mapstructure:"replicas_updated"`       // 已更新到最新版本的副本数
+	Generation         int64                 `json:"generation" mapstructure:"generation"`                   // 期望的 generation（metadata.generation）
+	ObservedGeneration int64                 `json:"observed_generation" mapstructure:"observed_generation"` // 控制器已处理的 generation
+	Conditions         []ControllerCondition `json:"conditions" mapstructure:"conditions"`                   // Progressing、Available、ReplicaFailure 等
+	HpaMinReplicas     int32                 `json:"hpa_min_replicas" mapstructure:"hpa_min_replicas"`       // HPA 最小副本数，没有 HPA 时为 0
+	HpaMaxReplicas     int32                 `json:"hpa_max_replicas" mapstructure:"hpa_max_replicas"`       // HPA 最大副本数，没有 HPA 时为 0
+	HpaCurrentReplicas int32                 `json:"hpa_current_replicas" mapstructure:"hpa_current_replicas"`
+	LastSuccessTime    int64                 `json:"last_success_time" mapstructure:"last_success_time"` // CronJob/Job 上次成功时间（秒）
+}
+
+// ControllerCondition 控制器状态条件
+type ControllerCondition struct {
+	Type   string `json:"type" mapstructure:"type"`     // Progressing、Available、ReplicaFailure
+	Status string `json:"status" mapstructure:"status"` // True、False、Unknown
+	Reason string `json:"reason" mapstructure:"reason"` // 如 ProgressDeadlineExceeded
 }
 
 // MetricWithTimestamp 用于存储指标值和最后更新的时间戳
@@ -81,25 +191,31 @@ type MetricWithTimestamp struct {
 	Timestamp  time.Time
 }
 type HeartSource struct {
-	IsActive int     `json:"isActive" mapstructure:"isActive"` // 是否活跃（1：活跃，0：不活跃）
-	Project  string  `json:"project" mapstructure:"project"`   // 项目名称
-	Hostname string  `json:"hostname" mapstructure:"hostname"` // 主机名
-	Version  float64 `json:"version" mapstructure:"version"`   //当前版本号
+	IsActive  int     `json:"isActive" mapstructure:"isActive"`   // 是否活跃（1：活跃，0：不活跃）
+	Project   string  `json:"project" mapstructure:"project"`     // 项目名称
+	Hostname  string  `json:"hostname" mapstructure:"hostname"`   // 主机名
+	Version   float64 `json:"version" mapstructure:"version"`     //当前版本号
+	Timestamp float64 `json:"timestamp" mapstructure:"timestamp"` // 采集时间戳（可选，秒或毫秒）
 }
+
+// 日志统计（Elasticsearch 聚合）数据，每次上报为该项目的完整排行
 type EsIpSource struct {
-	IpCount  int    `json:"client_ip_count"`
-	ClientIp string `json:"client_ip"`
-	Project  string `json:"project"`
+	IpCount   int     `json:"client_ip_count" mapstructure:"client_ip_count"`
+	ClientIp  string  `json:"client_ip" mapstructure:"client_ip"`
+	Project   string  `json:"project" mapstructure:"project"`
+	Timestamp float64 `json:"timestamp" mapstructure:"timestamp"` // 采集时间戳（可选，秒或毫秒）
 }
 type EsCountrySource struct {
-	CountryCount int    `json:"country_name_count"`
-	CountryName  string `json:"country_name"`
-	Project      string `json:"project"`
+	CountryCount int     `json:"country_name_count" mapstructure:"country_name_count"`
+	CountryName  string  `json:"country_name" mapstructure:"country_name"`
+	Project      string  `json:"project" mapstructure:"project"`
+	Timestamp    float64 `json:"timestamp" mapstructure:"timestamp"` // 采集时间戳（可选，秒或毫秒）
 }
 type EsUrlSource struct {
-	UrlCount   int    `json:"request_url_count"`
-	RequestUrl string `json:"request_url"`
-	Project    string `json:"project"`
+	UrlCount   int     `json:"request_url_count" mapstructure:"request_url_count"`
+	RequestUrl string  `json:"request_url" mapstructure:"request_url"`
+	Project    string  `json:"project" mapstructure:"project"`
+	Timestamp  float64 `json:"timestamp" mapstructure:"timestamp"` // 采集时间戳（可选，秒或毫秒）
 }
 
 // TrafficSwitchingSource 流量切换平台上报数据
@@ -157,4 +273,85 @@ type TrafficSwitchingSource struct {
 
 	// 上报时间戳
 	Timestamp float64 `json:"timestamp" mapstructure:"timestamp"`
+
+	// 延迟分布，按上游目标区分
+	Latency []TrafficSwitchingLatency `json:"latency" mapstructure:"latency"`
+
+	// 按后端、路由拆分的统计
+	Backends []TrafficSwitchingBackend `json:"backends" mapstructure:"backends"`
+	Routes   []TrafficSwitchingRoute   `json:"routes" mapstructure:"routes"`
+}
+
+// TrafficSwitchingBackend 单个后端的流量统计，请求数和失败数为累计值
+type TrafficSwitchingBackend struct {
+	Target        string  `json:"target" mapstructure:"target"`                 // 后端地址或名称
+	Version       string  `json:"version" mapstructure:"version"`               // 版本，如 v1、v2
+	Weight        float64 `json:"weight" mapstructure:"weight"`                 // 配置的权重
+	TotalRequests float64 `json:"total_requests" mapstructure:"total_requests"` // 累计请求数
+	TotalErrors   float64 `json:"total_errors" mapstructure:"total_errors"`     // 累计失败数
+	AvgLatencyMs  float64 `json:"avg_latency_ms" mapstructure:"avg_latency_ms"` // 平均延迟（毫秒）
+}
+
+// TrafficSwitchingRoute 单个路由的流量统计，route 应为路由模板（如 /api/users/:id），请求数和失败数为累计值
+type TrafficSwitchingRoute struct {
+	Route         string  `json:"route" mapstructure:"route"`
+	TotalRequests float64 `json:"total_requests" mapstructure:"total_requests"`
+	TotalErrors   float64 `json:"total_errors" mapstructure:"total_errors"`
+	AvgLatencyMs  float64 `json:"avg_latency_ms" mapstructure:"avg_latency_ms"`
+}
+
+// TrafficSwitchingLatency 单个上游目标的延迟直方图，bucket 和 count 为启动以来的累计值
+type TrafficSwitchingLatency struct {
+	Target  string                   `json:"target" mapstructure:"target"`   // 上游目标，如 v1、10.0.0.1:8080
+	Buckets []TrafficSwitchingBucket `json:"buckets" mapstructure:"buckets"` // 按 le 升序，不需要上报 +Inf 桶
+	Count   float64                  `json:"count" mapstructure:"count"`     // 总请求数（即 +Inf 桶）
+	SumMs   float64                  `json:"sum_ms" mapstructure:"sum_ms"`   // 延迟总和（毫秒）
+	P50Ms   *float64                 `json:"p50_ms" mapstructure:"p50_ms"`   // 可选分位数（毫秒）
+	P90Ms   *float64                 `json:"p90_ms" mapstructure:"p90_ms"`
+	P99Ms   *float64                 `json:"p99_ms" mapstructure:"p99_ms"`
+}
+
+// TrafficSwitchingBucket 直方图桶，count 为延迟 <= le 的累计请求数
+type TrafficSwitchingBucket struct {
+	Le    float64 `json:"le" mapstructure:"le"` // 上界（毫秒）
+	Count float64 `json:"count" mapstructure:"count"`
+}
+
+// K8sEvent Kubernetes Event 对象（core/v1），agent 原样上报
+type K8sEvent struct {
+	Metadata       K8sObjectMeta      `json:"metadata" mapstructure:"metadata"`
+	InvolvedObject K8sObjectReference `json:"involvedObject" mapstructure:"involvedObject"`
+	Reason         string             `json:"reason" mapstructure:"reason"`   // 如 BackOff、FailedScheduling、Unhealthy
+	Message        string             `json:"message" mapstructure:"message"` // 事件详情
+	Type           string             `json:"type" mapstructure:"type"`       // Normal、Warning
+	Count          int32              `json:"count" mapstructure:"count"`     // 事件合并后的发生次数
+	FirstTimestamp string             `json:"firstTimestamp" mapstructure:"firstTimestamp"`
+	LastTimestamp  string             `json:"lastTimestamp" mapstructure:"lastTimestamp"`
+	EventTime      string             `json:"eventTime" mapstructure:"eventTime"` // 新版事件只有 eventTime
+	Source         K8sEventSource     `json:"source" mapstructure:"source"`
+	Timestamp      float64            `json:"timestamp" mapstructure:"timestamp"` // 采集时间戳（可选，秒或毫秒）
+}
+
+type K8sObjectMeta struct {
+	Name      string `json:"name" mapstructure:"name"`
+	Namespace string `json:"namespace" mapstructure:"namespace"`
+	UID       string `json:"uid" mapstructure:"uid"`
+}
+
+type K8sObjectReference struct {
+	Kind      string `json:"kind" mapstructure:"kind"`
+	Name      string `json:"name" mapstructure:"name"`
+	Namespace string `json:"namespace" mapstructure:"namespace"`
+}
+
+type K8sEventSource struct {
+	Component string `json:"component" mapstructure:"component"`
+	Host      string `json:"host" mapstructure:"host"`
+}
+
+// Sample 通过时间戳校验、已写入指标的一条上报数据，Time 为采样时间
+// 输出到 InfluxDB 等下游时使用，保证与 Prometheus 指标一致
+type Sample struct {
+	Item map[string]interface{}
+	Time time.Time
 }
@@ -0,0 +1,168 @@
+package Modles
+
+import (
+	"strconv"
+	"strings"
+)
+
+// OTLP 聚合时间性（AggregationTemporality）
+const (
+	OtlpTemporalityDelta      = 1
+	OtlpTemporalityCumulative = 2
+)
+
+// OtlpUint64 兼容 OTLP/JSON 中以字符串或数字表示的 uint64/fixed64 字段
+type OtlpUint64 uint64
+
+func (v *OtlpUint64) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*v = 0
+		return nil
+	}
+	parsed, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*v = OtlpUint64(parsed)
+	return nil
+}
+
+// OtlpInt64 兼容 OTLP/JSON 中以字符串或数字表示的 int64 字段
+type OtlpInt64 int64
+
+func (v *OtlpInt64) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*v = 0
+		return nil
+	}
+	parsed, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*v = OtlpInt64(parsed)
+	return nil
+}
+
+// OtlpExportMetricsRequest 对应 OTLP ExportMetricsServiceRequest
+type OtlpExportMetricsRequest struct {
+	ResourceMetrics []OtlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type OtlpResourceMetrics struct {
+	Resource     OtlpResource       `json:"resource"`
+	ScopeMetrics []OtlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type OtlpResource struct {
+	Attributes []OtlpKeyValue `json:"attributes"`
+}
+
+type OtlpScopeMetrics struct {
+	Scope   OtlpScope    `json:"scope"`
+	Metrics []OtlpMetric `json:"metrics"`
+}
+
+type OtlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// OtlpMetric 单个指标，gauge/sum/histogram/summary 只会出现其中之一
+type OtlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Unit        string         `json:"unit"`
+	Gauge       *OtlpGauge     `json:"gauge"`
+	Sum         *OtlpSum       `json:"sum"`
+	Histogram   *OtlpHistogram `json:"histogram"`
+	Summary     *OtlpSummary   `json:"summary"`
+}
+
+type OtlpGauge struct {
+	DataPoints []OtlpNumberDataPoint `json:"dataPoints"`
+}
+
+type OtlpSum struct {
+	DataPoints             []OtlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type OtlpHistogram struct {
+	DataPoints             []OtlpHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                      `json:"aggregationTemporality"`
+}
+
+type OtlpSummary struct {
+	DataPoints []OtlpSummaryDataPoint `json:"dataPoints"`
+}
+
+type OtlpNumberDataPoint struct {
+	Attributes   []OtlpKeyValue `json:"attributes"`
+	TimeUnixNano OtlpUint64     `json:"timeUnixNano"`
+	AsDouble     *float64       `json:"asDouble"`
+	AsInt        *OtlpInt64     `json:"asInt"`
+}
+
+// Value 返回数据点的数值（asDouble 优先）
+func (p OtlpNumberDataPoint) Value() float64 {
+	if p.AsDouble != nil {
+		return *p.AsDouble
+	}
+	if p.AsInt != nil {
+		return float64(*p.AsInt)
+	}
+	return 0
+}
+
+type OtlpHistogramDataPoint struct {
+	Attributes     []OtlpKeyValue `json:"attributes"`
+	TimeUnixNano   OtlpUint64     `json:"timeUnixNano"`
+	Count          OtlpUint64     `json:"count"`
+	Sum            *float64       `json:"sum"`
+	BucketCounts   []OtlpUint64   `json:"bucketCounts"`
+	ExplicitBounds []float64      `json:"explicitBounds"`
+}
+
+type OtlpSummaryDataPoint struct {
+	Attributes     []OtlpKeyValue      `json:"attributes"`
+	TimeUnixNano   OtlpUint64          `json:"timeUnixNano"`
+	Count          OtlpUint64          `json:"count"`
+	Sum            float64             `json:"sum"`
+	QuantileValues []OtlpQuantileValue `json:"quantileValues"`
+}
+
+type OtlpQuantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+type OtlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value OtlpAnyValue `json:"value"`
+}
+
+// OtlpAnyValue 仅保留标量类型，数组/kvlist 类型的属性不会转换为标签
+type OtlpAnyValue struct {
+	StringValue *string    `json:"stringValue"`
+	BoolValue   *bool      `json:"boolValue"`
+	IntValue    *OtlpInt64 `json:"intValue"`
+	DoubleValue *float64   `json:"doubleValue"`
+}
+
+// String 将属性值转换为标签字符串
+func (v OtlpAnyValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case v.IntValue != nil:
+		return strconv.FormatInt(int64(*v.IntValue), 10)
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'g', -1, 64)
+	}
+	return ""
+}
@@ -0,0 +1,212 @@
+package RemoteWrite
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"monitor-server/Metrics"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Endpoint 远程写入目标（VictoriaMetrics、Thanos receive、Mimir 等）
+type Endpoint struct {
+	Name        string            `yaml:"name"`        // 名称，用于 WAL 子目录和指标标签
+	URL         string            `yaml:"url"`         // 写入地址，如 http://vm:8428/api/v1/write
+	BearerToken string            `yaml:"bearerToken"` // Bearer 认证
+	Username    string            `yaml:"username"`    // Basic 认证
+	Password    string            `yaml:"password"`
+	Headers     map[string]string `yaml:"headers"` // 额外请求头，如 X-Scope-OrgID
+}
+
+// Config 远程写入配置
+type Config struct {
+	Enabled           bool              `yaml:"enabled"`
+	Interval          time.Duration     `yaml:"interval"`          // 采集间隔
+	Timeout           time.Duration     `yaml:"timeout"`           // 单次请求超时
+	WalDir            string            `yaml:"walDir"`            // WAL 目录
+	MaxWalSegments    int               `yaml:"maxWalSegments"`    // 每个目标最多保留的待发送段
+	MaxSamplesPerSend int               `yaml:"maxSamplesPerSend"` // 每个请求最多携带的样本数
+	ExternalLabels    map[string]string `yaml:"externalLabels"`    // 附加到所有序列的标签
+	Endpoints         []Endpoint        `yaml:"endpoints"`
+}
+
+// 默认值
+const (
+	defaultInterval          = 30 * time.Second
+	defaultTimeout           = 30 * time.Second
+	defaultWalDir            = "data/remote-write"
+	defaultMaxWalSegments    = 2880 // 30 秒间隔下约保留 1 天
+	defaultMaxSamplesPerSend = 5000
+	maxBackoff               = 5 * time.Minute
+)
+
+func (c *Config) applyDefaults() {
+	if c.Interval <= 0 {
+		c.Interval = defaultInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.WalDir == "" {
+		c.WalDir = defaultWalDir
+	}
+	if c.MaxWalSegments <= 0 {
+		c.MaxWalSegments = defaultMaxWalSegments
+	}
+	if c.MaxSamplesPerSend <= 0 {
+		c.MaxSamplesPerSend = defaultMaxSamplesPerSend
+	}
+}
+
+// Start 启动远程写入：定期采集 gatherer 的全部指标，写入每个目标的 WAL，并由各自的发送协程重试发送
+func Start(cfg Config, gatherer prometheus.Gatherer) error {
+	cfg.applyDefaults()
+	if len(cfg.Endpoints) == 0 {
+		return fmt.Errorf("未配置 remoteWrite.endpoints")
+	}
+
+	queues := make([]*walQueue, 0, len(cfg.Endpoints))
+	for i, ep := range cfg.Endpoints {
+		if ep.URL == "" {
+			return fmt.Errorf("remoteWrite.endpoints[%d] 缺少 url", i)
+		}
+		if ep.Name == "" {
+			ep.Name = fmt.Sprintf("endpoint-%d", i)
+			cfg.Endpoints[i].Name = ep.Name
+		}
+		q, err := newWalQueue(filepath.Join(cfg.WalDir, ep.Name), cfg.MaxWalSegments)
+		if err != nil {
+			return err
+		}
+		queues = append(queues, q)
+		go sendLoop(ep, q, cfg.Timeout)
+	}
+
+	go func() {
+		for {
+			collectOnce(cfg, gatherer, queues)
+			time.Sleep(cfg.Interval)
+		}
+	}()
+
+	log.Printf("[RemoteWrite] 已启动，目标数量: %d，间隔: %v", len(cfg.Endpoints), cfg.Interval)
+	return nil
+}
+
+// collectOnce 采集一次并写入所有目标的 WAL
+func collectOnce(cfg Config, gatherer prometheus.Gatherer, queues []*walQueue) {
+	families, err := gatherer.Gather()
+	if err != nil {
+		// Gather 出错时仍会返回其余正常的指标
+		log.Printf("[RemoteWrite] 采集指标出错: %v", err)
+	}
+	series := familiesToSeries(families, cfg.ExternalLabels, time.Now().UnixMilli())
+	if len(series) == 0 {
+		return
+	}
+
+	for start := 0; start < len(series); start += cfg.MaxSamplesPerSend {
+		end := start + cfg.MaxSamplesPerSend
+		if end > len(series) {
+			end = len(series)
+		}
+		payload := snappy.Encode(nil, encodeWriteRequest(series[start:end]))
+		for i, q := range queues {
+			if err := q.Append(payload); err != nil {
+				log.Printf("[RemoteWrite] %s: %v", cfg.Endpoints[i].Name, err)
+			}
+		}
+	}
+	for i, q := range queues {
+		Metrics.RemoteWritePendingSegments.WithLabelValues(cfg.Endpoints[i].Name).Set(float64(q.Len()))
+	}
+}
+
+// sendLoop 按写入顺序发送 WAL 段，失败时指数退避重试
+func sendLoop(ep Endpoint, q *walQueue, timeout time.Duration) {
+	client := &http.Client{Timeout: timeout}
+	backoff := time.Second
+
+	for {
+		name, data, err := q.Oldest()
+		if err != nil {
+			log.Printf("[RemoteWrite] %s: 读取 WAL 段失败: %v，丢弃", ep.Name, err)
+			q.Remove(name)
+			continue
+		}
+		if name == "" {
+			// 队列为空，等待新数据
+			select {
+			case <-q.notify:
+			case <-time.After(10 * time.Second):
+			}
+			continue
+		}
+
+		retry, err := send(client, ep, data)
+		switch {
+		case err == nil:
+			q.Remove(name)
+			backoff = time.Second
+			Metrics.RemoteWriteLastSuccessTimestamp.WithLabelValues(ep.Name).Set(float64(time.Now().Unix()))
+		case !retry:
+			// 4xx 表示数据本身被拒绝，重试也不会成功
+			log.Printf("[RemoteWrite] %s: 数据被拒绝，丢弃段 %s: %v", ep.Name, name, err)
+			q.Remove(name)
+			Metrics.RemoteWriteFailures.WithLabelValues(ep.Name).Inc()
+		default:
+			log.Printf("[RemoteWrite] %s: 发送失败，%v 后重试: %v", ep.Name, backoff, err)
+			Metrics.RemoteWriteFailures.WithLabelValues(ep.Name).Inc()
+			time.Sleep(backoff)
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+		Metrics.RemoteWritePendingSegments.WithLabelValues(ep.Name).Set(float64(q.Len()))
+	}
+}
+
+// send 发送一个段，返回值 retry 表示失败后是否需要重试
+func send(client *http.Client, ep Endpoint, data []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, ep.URL, bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "monitor-server")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for k, v := range ep.Headers {
+		req.Header.Set(k, v)
+	}
+	if ep.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+ep.BearerToken)
+	} else if ep.Username != "" {
+		req.SetBasicAuth(ep.Username, ep.Password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			log.Printf("关闭响应体失败: %v", err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	// 429 和 5xx 可重试
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5, err
+}
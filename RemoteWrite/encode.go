@@ -0,0 +1,137 @@
+package RemoteWrite
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// prompbLabel 对应 prometheus.prompb.Label
+type prompbLabel struct {
+	Name  string
+	Value string
+}
+
+// prompbSeries 对应 prometheus.prompb.TimeSeries（每条只携带一个样本）
+type prompbSeries struct {
+	Labels      []prompbLabel
+	Value       float64
+	TimestampMs int64
+}
+
+// familiesToSeries 将 Gather 得到的指标族展开为 remote-write 时间序列
+// histogram/summary 按 Prometheus 文本格式的规则展开为 _bucket/_sum/_count 和 quantile 序列
+func familiesToSeries(families []*dto.MetricFamily, externalLabels map[string]string, nowMs int64) []prompbSeries {
+	var series []prompbSeries
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			ts := nowMs
+			if m.TimestampMs != nil {
+				ts = m.GetTimestampMs()
+			}
+			add := func(metricName string, value float64, extra ...prompbLabel) {
+				series = append(series, prompbSeries{
+					Labels:      buildLabels(metricName, m.GetLabel(), externalLabels, extra...),
+					Value:       value,
+					TimestampMs: ts,
+				})
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					add(name, q.GetValue(), prompbLabel{Name: "quantile", Value: formatFloat(q.GetQuantile())})
+				}
+				add(name+"_sum", s.GetSampleSum())
+				add(name+"_count", float64(s.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				hasInf := false
+				for _, b := range h.GetBucket() {
+					if math.IsInf(b.GetUpperBound(), +1) {
+						hasInf = true
+					}
+					add(name+"_bucket", float64(b.GetCumulativeCount()), prompbLabel{Name: "le", Value: formatFloat(b.GetUpperBound())})
+				}
+				if !hasInf {
+					add(name+"_bucket", float64(h.GetSampleCount()), prompbLabel{Name: "le", Value: "+Inf"})
+				}
+				add(name+"_sum", h.GetSampleSum())
+				add(name+"_count", float64(h.GetSampleCount()))
+			}
+		}
+	}
+	return series
+}
+
+// buildLabels 生成按名称排序的标签集合（remote-write 协议要求有序）
+func buildLabels(name string, pairs []*dto.LabelPair, externalLabels map[string]string, extra ...prompbLabel) []prompbLabel {
+	labels := make([]prompbLabel, 0, len(pairs)+len(externalLabels)+len(extra)+1)
+	labels = append(labels, prompbLabel{Name: "__name__", Value: name})
+	seen := map[string]bool{"__name__": true}
+	for _, p := range pairs {
+		labels = append(labels, prompbLabel{Name: p.GetName(), Value: p.GetValue()})
+		seen[p.GetName()] = true
+	}
+	for _, l := range extra {
+		labels = append(labels, l)
+		seen[l.Name] = true
+	}
+	// 外部标签不覆盖指标自带的标签
+	for k, v := range externalLabels {
+		if !seen[k] {
+			labels = append(labels, prompbLabel{Name: k, Value: v})
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, +1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// encodeWriteRequest 编码为 prometheus.prompb.WriteRequest
+func encodeWriteRequest(series []prompbSeries) []byte {
+	var buf []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.Labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.Name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.Value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.Value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(s.TimestampMs))
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, ts)
+	}
+	return buf
+}
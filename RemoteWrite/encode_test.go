@@ -0,0 +1,197 @@
+package RemoteWrite
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func labelPair(name, value string) *dto.LabelPair {
+	return &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)}
+}
+
+func TestFamiliesToSeries(t *testing.T) {
+	const now = int64(1700000000000)
+	counterType := dto.MetricType_COUNTER
+	gaugeType := dto.MetricType_GAUGE
+	summaryType := dto.MetricType_SUMMARY
+	histogramType := dto.MetricType_HISTOGRAM
+
+	tests := []struct {
+		name     string
+		family   *dto.MetricFamily
+		external map[string]string
+		want     []prompbSeries
+	}{
+		{
+			name: "counter with external labels",
+			family: &dto.MetricFamily{Name: proto.String("requests_total"), Type: &counterType, Metric: []*dto.Metric{{
+				Label:   []*dto.LabelPair{labelPair("project", "a")},
+				Counter: &dto.Counter{Value: proto.Float64(5)},
+			}}},
+			external: map[string]string{"cluster": "prod", "project": "ignored"},
+			want: []prompbSeries{{
+				Labels:      []prompbLabel{{"__name__", "requests_total"}, {"cluster", "prod"}, {"project", "a"}},
+				Value:       5,
+				TimestampMs: now,
+			}},
+		},
+		{
+			name: "gauge keeps metric timestamp",
+			family: &dto.MetricFamily{Name: proto.String("temp"), Type: &gaugeType, Metric: []*dto.Metric{{
+				Gauge:       &dto.Gauge{Value: proto.Float64(-1.5)},
+				TimestampMs: proto.Int64(1000),
+			}}},
+			want: []prompbSeries{{Labels: []prompbLabel{{"__name__", "temp"}}, Value: -1.5, TimestampMs: 1000}},
+		},
+		{
+			name: "summary",
+			family: &dto.MetricFamily{Name: proto.String("rt"), Type: &summaryType, Metric: []*dto.Metric{{
+				Summary: &dto.Summary{
+					SampleCount: proto.Uint64(4),
+					SampleSum:   proto.Float64(2),
+					Quantile:    []*dto.Quantile{{Quantile: proto.Float64(0.5), Value: proto.Float64(0.3)}},
+				},
+			}}},
+			want: []prompbSeries{
+				{Labels: []prompbLabel{{"__name__", "rt"}, {"quantile", "0.5"}}, Value: 0.3, TimestampMs: now},
+				{Labels: []prompbLabel{{"__name__", "rt_sum"}}, Value: 2, TimestampMs: now},
+				{Labels: []prompbLabel{{"__name__", "rt_count"}}, Value: 4, TimestampMs: now},
+			},
+		},
+		{
+			name: "histogram adds +Inf bucket",
+			family: &dto.MetricFamily{Name: proto.String("latency"), Type: &histogramType, Metric: []*dto.Metric{{
+				Histogram: &dto.Histogram{
+					SampleCount: proto.Uint64(3),
+					SampleSum:   proto.Float64(1.2),
+					Bucket:      []*dto.Bucket{{UpperBound: proto.Float64(0.1), CumulativeCount: proto.Uint64(1)}},
+				},
+			}}},
+			want: []prompbSeries{
+				{Labels: []prompbLabel{{"__name__", "latency_bucket"}, {"le", "0.1"}}, Value: 1, TimestampMs: now},
+				{Labels: []prompbLabel{{"__name__", "latency_bucket"}, {"le", "+Inf"}}, Value: 3, TimestampMs: now},
+				{Labels: []prompbLabel{{"__name__", "latency_sum"}}, Value: 1.2, TimestampMs: now},
+				{Labels: []prompbLabel{{"__name__", "latency_count"}}, Value: 3, TimestampMs: now},
+			},
+		},
+		{
+			name: "histogram with explicit +Inf bucket",
+			family: &dto.MetricFamily{Name: proto.String("latency"), Type: &histogramType, Metric: []*dto.Metric{{
+				Histogram: &dto.Histogram{
+					SampleCount: proto.Uint64(2),
+					SampleSum:   proto.Float64(0.5),
+					Bucket:      []*dto.Bucket{{UpperBound: proto.Float64(math.Inf(+1)), CumulativeCount: proto.Uint64(2)}},
+				},
+			}}},
+			want: []prompbSeries{
+				{Labels: []prompbLabel{{"__name__", "latency_bucket"}, {"le", "+Inf"}}, Value: 2, TimestampMs: now},
+				{Labels: []prompbLabel{{"__name__", "latency_sum"}}, Value: 0.5, TimestampMs: now},
+				{Labels: []prompbLabel{{"__name__", "latency_count"}}, Value: 2, TimestampMs: now},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := familiesToSeries([]*dto.MetricFamily{tt.family}, tt.external, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("familiesToSeries() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// decodeWriteRequest 按 prompb.WriteRequest 的字段编号解码，用于校验编码结果
+func decodeWriteRequest(t *testing.T, b []byte) []prompbSeries {
+	t.Helper()
+	var series []prompbSeries
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 || num != 1 || typ != protowire.BytesType {
+			t.Fatalf("unexpected WriteRequest field %d/%d", num, typ)
+		}
+		b = b[n:]
+		ts, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		var s prompbSeries
+		for len(ts) > 0 {
+			num, _, n := protowire.ConsumeTag(ts)
+			ts = ts[n:]
+			data, n := protowire.ConsumeBytes(ts)
+			if n < 0 {
+				t.Fatal(protowire.ParseError(n))
+			}
+			ts = ts[n:]
+			switch num {
+			case 1:
+				var l prompbLabel
+				for len(data) > 0 {
+					num, _, n := protowire.ConsumeTag(data)
+					data = data[n:]
+					v, n := protowire.ConsumeString(data)
+					data = data[n:]
+					if num == 1 {
+						l.Name = v
+					} else {
+						l.Value = v
+					}
+				}
+				s.Labels = append(s.Labels, l)
+			case 2:
+				for len(data) > 0 {
+					num, _, n := protowire.ConsumeTag(data)
+					data = data[n:]
+					if num == 1 {
+						v, n := protowire.ConsumeFixed64(data)
+						data = data[n:]
+						s.Value = math.Float64frombits(v)
+					} else {
+						v, n := protowire.ConsumeVarint(data)
+						data = data[n:]
+						s.TimestampMs = int64(v)
+					}
+				}
+			}
+		}
+		series = append(series, s)
+	}
+	return series
+}
+
+func TestEncodeWriteRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []prompbSeries
+	}{
+		{"empty", nil},
+		{"single", []prompbSeries{{Labels: []prompbLabel{{"__name__", "up"}, {"job", "monitor"}}, Value: 1, TimestampMs: 1700000000000}}},
+		{"special values", []prompbSeries{
+			{Labels: []prompbLabel{{"__name__", "a"}}, Value: math.Inf(-1), TimestampMs: 1},
+			{Labels: []prompbLabel{{"__name__", "b"}, {"path", "/中文?x=\"1\""}}, Value: -0.25, TimestampMs: 2},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := snappy.Encode(nil, encodeWriteRequest(tt.series))
+			raw, err := snappy.Decode(nil, payload)
+			if err != nil {
+				t.Fatalf("snappy decode: %v", err)
+			}
+			got := decodeWriteRequest(t, raw)
+			if !reflect.DeepEqual(got, tt.series) {
+				t.Errorf("round trip = %+v, want %+v", got, tt.series)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package RemoteWrite
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// walQueue 基于本地目录的重试队列，每个待发送批次对应一个段文件
+// 段文件内容为 snappy 压缩后的 WriteRequest，进程重启后会继续发送
+// 段列表在启动时从目录加载一次，之后随写入和删除更新，发送和统计不再读取目录
+type walQueue struct {
+	dir         string
+	maxSegments int
+	mu          sync.Mutex
+	seq         int64
+	segments    []string // 按文件名（即写入顺序）排序的段
+	notify      chan struct{}
+}
+
+const segmentSuffix = ".seg"
+
+func newWalQueue(dir string, maxSegments int) (*walQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建 WAL 目录失败: %v", err)
+	}
+	segments, err := loadSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) > 0 {
+		log.Printf("[RemoteWrite] %s: 加载 %d 个待发送的 WAL 段", dir, len(segments))
+	}
+	return &walQueue{
+		dir:         dir,
+		maxSegments: maxSegments,
+		segments:    segments,
+		notify:      make(chan struct{}, 1),
+	}, nil
+}
+
+// loadSegments 读取目录中的段文件并排序，删除上次退出时未完成重命名的临时文件
+func loadSegments(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取 WAL 目录失败: %v", err)
+	}
+	var segments []string
+	for _, e := range entries {
+		switch {
+		case e.IsDir():
+		case strings.HasSuffix(e.Name(), segmentSuffix):
+			segments = append(segments, e.Name())
+		case strings.HasSuffix(e.Name(), segmentSuffix+".tmp"):
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				log.Printf("[RemoteWrite] 删除 WAL 临时文件失败: %v", err)
+			}
+		}
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+// Append 写入一个段文件（先写临时文件再重命名，避免发送半个文件）
+func (q *walQueue) Append(data []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	name := fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), q.seq%1000000, segmentSuffix)
+	tmp := filepath.Join(q.dir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("写入 WAL 段失败: %v", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(q.dir, name)); err != nil {
+		return fmt.Errorf("重命名 WAL 段失败: %v", err)
+	}
+	q.insertLocked(name)
+
+	// 超出上限时丢弃最旧的段
+	for len(q.segments) > q.maxSegments {
+		log.Printf("[RemoteWrite] WAL 段数量超过上限 %d，丢弃最旧的段: %s", q.maxSegments, q.segments[0])
+		if err := os.Remove(filepath.Join(q.dir, q.segments[0])); err != nil && !os.IsNotExist(err) {
+			log.Printf("[RemoteWrite] 删除 WAL 段失败: %v", err)
+		}
+		q.segments = q.segments[1:]
+	}
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Oldest 返回最旧的段文件名和内容，没有待发送数据时返回空
+func (q *walQueue) Oldest() (string, []byte, error) {
+	q.mu.Lock()
+	if len(q.segments) == 0 {
+		q.mu.Unlock()
+		return "", nil, nil
+	}
+	name := q.segments[0]
+	q.mu.Unlock()
+	data, err := os.ReadFile(filepath.Join(q.dir, name))
+	return name, data, err
+}
+
+// Remove 删除已发送（或确认无法发送）的段，段已因超限被丢弃时忽略
+func (q *walQueue) Remove(name string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if i := sort.SearchStrings(q.segments, name); i < len(q.segments) && q.segments[i] == name {
+		q.segments = append(q.segments[:i], q.segments[i+1:]...)
+	}
+	if err := os.Remove(filepath.Join(q.dir, name)); err != nil && !os.IsNotExist(err) {
+		log.Printf("[RemoteWrite] 删除 WAL 段失败: %v", err)
+	}
+}
+
+// Len 返回待发送的段数量
+func (q *walQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.segments)
+}
+
+// insertLocked 按文件名顺序插入段（调用方需持有锁）
+// 系统时钟回拨时新段的文件名可能小于已有的段，插入到对应位置，与重启后从目录加载的顺序一致
+func (q *walQueue) insertLocked(name string) {
+	i := sort.SearchStrings(q.segments, name)
+	q.segments = append(q.segments, "")
+	copy(q.segments[i+1:], q.segments[i:])
+	q.segments[i] = name
+}
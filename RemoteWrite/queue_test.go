@@ -0,0 +1,137 @@
+package RemoteWrite
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// drain 按顺序取出并删除全部段，返回段内容
+func drain(t *testing.T, q *walQueue) []string {
+	t.Helper()
+	var got []string
+	for {
+		name, data, err := q.Oldest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "" {
+			return got
+		}
+		got = append(got, string(data))
+		q.Remove(name)
+	}
+}
+
+func TestWalQueue(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    map[string]string // 启动前目录中的文件
+		maxSegments int
+		appends     []string
+		want        []string
+	}{
+		{
+			name:        "append order",
+			maxSegments: 10,
+			appends:     []string{"a", "b", "c"},
+			want:        []string{"a", "b", "c"},
+		},
+		{
+			name:        "oldest dropped over limit",
+			maxSegments: 2,
+			appends:     []string{"a", "b", "c", "d"},
+			want:        []string{"c", "d"},
+		},
+		{
+			name: "segments from previous run sent first, temp files removed",
+			existing: map[string]string{
+				"00000000000000000002-000001.seg":     "old2",
+				"00000000000000000001-000001.seg":     "old1",
+				"00000000000000000003-000001.seg.tmp": "partial",
+				"notes.txt":                           "ignored",
+			},
+			maxSegments: 10,
+			appends:     []string{"new"},
+			want:        []string{"old1", "old2", "new"},
+		},
+		{
+			name: "limit applies to loaded segments",
+			existing: map[string]string{
+				"00000000000000000001-000001.seg": "old1",
+				"00000000000000000002-000001.seg": "old2",
+			},
+			maxSegments: 2,
+			appends:     []string{"new"},
+			want:        []string{"old2", "new"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			q, err := newWalQueue(dir, tt.maxSegments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "00000000000000000003-000001.seg.tmp")); !os.IsNotExist(err) {
+				t.Errorf("temp file not removed: %v", err)
+			}
+			for _, data := range tt.appends {
+				if err := q.Append([]byte(data)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if q.Len() != len(tt.want) {
+				t.Errorf("Len() = %d, want %d", q.Len(), len(tt.want))
+			}
+			if got := drain(t, q); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("segments = %v, want %v", got, tt.want)
+			}
+			if q.Len() != 0 {
+				t.Errorf("Len() after drain = %d", q.Len())
+			}
+		})
+	}
+}
+
+func TestWalQueueIndexedInMemory(t *testing.T) {
+	dir := t.TempDir()
+	q, err := newWalQueue(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Append([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	name, _, _ := q.Oldest()
+
+	// 启动后目录中出现的文件不会被读取
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000000-000000.seg"), []byte("stray"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := q.Oldest(); got != name || q.Len() != 1 {
+		t.Errorf("Oldest() = %s Len() = %d, want %s and 1", got, q.Len(), name)
+	}
+
+	// 发送中的段被超限丢弃后再删除，不影响其它段
+	q.Remove(name)
+	q.Remove(name)
+	if q.Len() != 0 {
+		t.Errorf("Len() after double remove = %d", q.Len())
+	}
+
+	// 时钟回拨时新段按文件名插入，与重启后的加载顺序一致
+	q.insertLocked("00000000000000000005-000001.seg")
+	q.insertLocked("00000000000000000003-000002.seg")
+	q.insertLocked("00000000000000000004-000003.seg")
+	want := []string{"00000000000000000003-000002.seg", "00000000000000000004-000003.seg", "00000000000000000005-000001.seg"}
+	if !reflect.DeepEqual(q.segments, want) {
+		t.Errorf("segments = %v, want %v", q.segments, want)
+	}
+}
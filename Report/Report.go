@@ -0,0 +1,416 @@
+package Report
+
+import (
+	"fmt"
+	"log"
+	"monitor-server/Daily"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Config 项目健康报告配置
+type Config struct {
+	Enabled        bool          `yaml:"enabled"`
+	Dir            string        `yaml:"dir"`            // 报告目录
+	At             string        `yaml:"at"`             // 每天生成报告的时间（项目时区），如 09:00
+	Weekday        string        `yaml:"weekday"`        // 周报生成日，如 Monday，统计上周一到周日
+	SampleInterval time.Duration `yaml:"sampleInterval"` // 在线率等统计的采样间隔
+	ExpiringDays   int           `yaml:"expiringDays"`   // 剩余天数不超过该值的证书列入报告
+	TopContainers  int           `yaml:"topContainers"`  // 重启次数排行数量
+	Webhook        WebhookConfig `yaml:"webhook"`        // 报告推送（可选）
+}
+
+// 默认值
+const (
+	defaultDir            = "data/reports"
+	defaultAt             = "09:00"
+	defaultWeekday        = time.Monday
+	defaultSampleInterval = time.Minute
+	defaultExpiringDays   = 30
+	defaultTopContainers  = 10
+)
+
+func (c *Config) applyDefaults() {
+	if c.Dir == "" {
+		c.Dir = defaultDir
+	}
+	if c.At == "" {
+		c.At = defaultAt
+	}
+	if c.SampleInterval <= 0 {
+		c.SampleInterval = defaultSampleInterval
+	}
+	if c.ExpiringDays <= 0 {
+		c.ExpiringDays = defaultExpiringDays
+	}
+	if c.TopContainers <= 0 {
+		c.TopContainers = defaultTopContainers
+	}
+	if c.Webhook.Timeout <= 0 {
+		c.Webhook.Timeout = defaultWebhookTimeout
+	}
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	if name == "" {
+		return defaultWeekday, nil
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("report.weekday %s 不合法", name)
+}
+
+// 报告类型
+const (
+	KindDaily  = "daily"
+	KindWeekly = "weekly"
+)
+
+// Report 一个项目一个周期的健康报告
+type Report struct {
+	Kind        string              `json:"kind"` // daily 或 weekly
+	Project     string              `json:"project"`
+	ProjectCode string              `json:"project_code"`
+	From        string              `json:"from"` // YYYY-MM-DD（项目时区，包含）
+	To          string              `json:"to"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Uptime      float64             `json:"uptime"` // 全部 agent 的平均在线率（0-1），无数据时为 -1
+	Agents      []AgentUptime       `json:"agents"`
+	Certs       []CertExpiry        `json:"certificates"`
+	Restarts    []ContainerRestarts `json:"restarts"`
+	Traffic     []ServiceTraffic    `json:"traffic"`
+	TrafficAll  ServiceTraffic      `json:"traffic_total"`
+}
+
+// AgentUptime 主机 agent 在线率
+type AgentUptime struct {
+	Host   string  `json:"host"`
+	Uptime float64 `json:"uptime"` // 0-1
+}
+
+// CertExpiry 即将到期的证书
+type CertExpiry struct {
+	Domain   string  `json:"domain"`
+	Comment  string  `json:"comment"`
+	DaysLeft float64 `json:"days_left"`
+}
+
+// ContainerRestarts 容器重启次数
+type ContainerRestarts struct {
+	Namespace  string  `json:"namespace"`
+	Controller string  `json:"controller"`
+	Pod        string  `json:"pod"`
+	Container  string  `json:"container"`
+	Restarts   float64 `json:"restarts"`
+}
+
+// ServiceTraffic 服务请求量和成功率
+type ServiceTraffic struct {
+	Service     string  `json:"service"`
+	Requests    float64 `json:"requests"`
+	Success     float64 `json:"success"`
+	Errors      float64 `json:"errors"`
+	SuccessRate float64 `json:"success_rate"` // 0-1，无请求时为 -1
+}
+
+var (
+	cfg          Config
+	weekday      time.Weekday
+	projectNames func() map[string]string // 项目编码 -> 显示名称（projects.json）
+	cfgMu        sync.RWMutex
+)
+
+// Start 启动定期采样和报告生成，names 返回 projects.json 中的项目编码和显示名称
+func Start(c Config, gatherer prometheus.Gatherer, names func() map[string]string) error {
+	c.applyDefaults()
+	day, err := parseWeekday(c.Weekday)
+	if err != nil {
+		return err
+	}
+	if _, err := time.Parse("15:04", c.At); err != nil {
+		return fmt.Errorf("report.at %s 不合法，格式为 HH:MM", c.At)
+	}
+	switch c.Webhook.Type {
+	case "", "json", "dingtalk", "wecom":
+	default:
+		return fmt.Errorf("report.webhook.type %s 不合法", c.Webhook.Type)
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return fmt.Errorf("创建报告目录失败: %v", err)
+	}
+
+	cfgMu.Lock()
+	cfg = c
+	weekday = day
+	projectNames = names
+	cfgMu.Unlock()
+
+	go func() {
+		for {
+			now := time.Now()
+			if err := sample(gatherer, now); err != nil {
+				log.Printf("[Report] 采集指标失败: %v", err)
+			}
+			generateDue(now)
+			time.Sleep(c.SampleInterval)
+		}
+	}()
+
+	log.Printf("[Report] 已启动，目录: %s，每天 %s 生成日报，%s 生成周报", c.Dir, c.At, day)
+	return nil
+}
+
+// projects 返回需要生成报告的项目，编码 -> 显示名称
+func projects() map[string]string {
+	cfgMu.RLock()
+	names := projectNames
+	cfgMu.RUnlock()
+
+	result := map[string]string{}
+	if names != nil {
+		for code, name := range names() {
+			if name == "" {
+				name = code
+			}
+			result[code] = name
+		}
+	}
+	// 不在 projects.json 中的项目，显示名称就是编码
+	for _, code := range statsProjects() {
+		if _, ok := result[code]; !ok {
+			result[code] = code
+		}
+	}
+	return result
+}
+
+// location 返回项目（编码）的日界时区
+func location(code string) *time.Location {
+	cfgMu.RLock()
+	names := projectNames
+	cfgMu.RUnlock()
+	if names != nil {
+		if name, ok := names()[code]; ok {
+			return Daily.Location(code, name)
+		}
+	}
+	return Daily.Location(code)
+}
+
+// generateDue 生成到期但尚未生成的日报和周报
+func generateDue(now time.Time) {
+	cfgMu.RLock()
+	c, day := cfg, weekday
+	cfgMu.RUnlock()
+	at, _ := time.Parse("15:04", c.At)
+
+	for code, name := range projects() {
+		local := now.In(Daily.Location(code, name))
+		if local.Hour()*60+local.Minute() < at.Hour()*60+at.Minute() {
+			continue
+		}
+		yesterday := local.AddDate(0, 0, -1).Format("2006-01-02")
+		generate(c, KindDaily, name, code, yesterday, yesterday, now)
+		if local.Weekday() == day {
+			generate(c, KindWeekly, name, code, local.AddDate(0, 0, -7).Format("2006-01-02"), yesterday, now)
+		}
+	}
+}
+
+// generate 生成并保存一份报告，已存在时跳过
+func generate(c Config, kind, name, code, from, to string, now time.Time) {
+	base := filepath.Join(c.Dir, safeName(code), kind+"-"+from)
+	if _, err := os.Stat(base + ".md"); err == nil {
+		return
+	}
+
+	report := build(c, kind, name, code, from, to, now)
+	if report == nil {
+		return
+	}
+	markdown, html, err := render(report)
+	if err != nil {
+		log.Printf("[Report] 生成报告失败 project=%s kind=%s: %v", name, kind, err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+		log.Printf("[Report] 创建报告目录失败: %v", err)
+		return
+	}
+	if err := os.WriteFile(base+".html", []byte(html), 0o644); err != nil {
+		log.Printf("[Report] 保存报告失败: %v", err)
+		return
+	}
+	// .md 最后写入，作为已生成的标记
+	if err := os.WriteFile(base+".md", []byte(markdown), 0o644); err != nil {
+		log.Printf("[Report] 保存报告失败: %v", err)
+		return
+	}
+	log.Printf("[Report] 已生成 %s 报告 project=%s %s ~ %s", kind, name, from, to)
+
+	if c.Webhook.URL != "" {
+		go func() {
+			if err := push(c.Webhook, report, markdown, html); err != nil {
+				log.Printf("[Report] 推送报告失败 project=%s kind=%s: %v", name, kind, err)
+			}
+		}()
+	}
+}
+
+// build 汇总一个项目 from~to 的统计，没有任何数据时返回 nil
+func build(c Config, kind, name, code, from, to string, now time.Time) *Report {
+	report := &Report{
+		Kind:        kind,
+		Project:     name,
+		ProjectCode: code,
+		From:        from,
+		To:          to,
+		GeneratedAt: now,
+		Uptime:      -1,
+	}
+
+	statsMu.Lock()
+	hosts := map[string]*hostUptime{}
+	restarts := map[string]*ContainerRestarts{}
+	for day, d := range stats[code] {
+		if day < from || day > to {
+			continue
+		}
+		for host, h := range d.hosts {
+			total, ok := hosts[host]
+			if !ok {
+				total = &hostUptime{}
+				hosts[host] = total
+			}
+			total.samples += h.samples
+			total.up += h.up
+		}
+		for key, r := range d.restarts {
+			total, ok := restarts[key]
+			if !ok {
+				total = &ContainerRestarts{Namespace: r.namespace, Controller: r.controller, Pod: r.pod, Container: r.container}
+				restarts[key] = total
+			}
+			total.Restarts += r.restarts
+		}
+	}
+	certs := map[string]CertExpiry{}
+	for _, cert := range certificates[code] {
+		// 同一域名有多条记录（解析地址不同）时取最小剩余天数
+		if old, ok := certs[cert.domain]; ok && old.DaysLeft <= cert.daysLeft {
+			continue
+		}
+		certs[cert.domain] = CertExpiry{Domain: cert.domain, Comment: cert.comment, DaysLeft: cert.daysLeft}
+	}
+	statsMu.Unlock()
+
+	// agent 在线率
+	var samples, up int
+	for host, h := range hosts {
+		report.Agents = append(report.Agents, AgentUptime{Host: host, Uptime: float64(h.up) / float64(h.samples)})
+		samples += h.samples
+		up += h.up
+	}
+	if samples > 0 {
+		report.Uptime = float64(up) / float64(samples)
+	}
+	sort.Slice(report.Agents, func(i, j int) bool {
+		if report.Agents[i].Uptime != report.Agents[j].Uptime {
+			return report.Agents[i].Uptime < report.Agents[j].Uptime
+		}
+		return report.Agents[i].Host < report.Agents[j].Host
+	})
+
+	// 即将到期的证书
+	for _, cert := range certs {
+		if cert.DaysLeft <= float64(c.ExpiringDays) {
+			report.Certs = append(report.Certs, cert)
+		}
+	}
+	sort.Slice(report.Certs, func(i, j int) bool {
+		if report.Certs[i].DaysLeft != report.Certs[j].DaysLeft {
+			return report.Certs[i].DaysLeft < report.Certs[j].DaysLeft
+		}
+		return report.Certs[i].Domain < report.Certs[j].Domain
+	})
+
+	// 重启次数排行
+	for _, r := range restarts {
+		report.Restarts = append(report.Restarts, *r)
+	}
+	sort.Slice(report.Restarts, func(i, j int) bool {
+		if report.Restarts[i].Restarts != report.Restarts[j].Restarts {
+			return report.Restarts[i].Restarts > report.Restarts[j].Restarts
+		}
+		return report.Restarts[i].Pod < report.Restarts[j].Pod
+	})
+	if len(report.Restarts) > c.TopContainers {
+		report.Restarts = report.Restarts[:c.TopContainers]
+	}
+
+	// 流量成功率（每日历史）
+	records, err := Daily.Query(code, "", from, to)
+	if err == nil && name != code {
+		// 旧版本按显示名称保存的记录
+		var legacy []Daily.Record
+		legacy, err = Daily.Query(name, "", from, to)
+		records = append(records, legacy...)
+	}
+	if err != nil {
+		log.Printf("[Report] 读取每日历史失败 project=%s: %v", name, err)
+	}
+	services := map[string]*ServiceTraffic{}
+	report.TrafficAll.Service = "合计"
+	for _, record := range records {
+		s, ok := services[record.Service]
+		if !ok {
+			s = &ServiceTraffic{Service: record.Service}
+			services[record.Service] = s
+		}
+		for _, t := range []*ServiceTraffic{s, &report.TrafficAll} {
+			t.Requests += record.Values["requests"]
+			t.Success += record.Values["success"]
+			t.Errors += record.Values["errors"]
+		}
+	}
+	for _, s := range services {
+		s.SuccessRate = successRate(s)
+		report.Traffic = append(report.Traffic, *s)
+	}
+	report.TrafficAll.SuccessRate = successRate(&report.TrafficAll)
+	sort.Slice(report.Traffic, func(i, j int) bool {
+		if report.Traffic[i].Requests != report.Traffic[j].Requests {
+			return report.Traffic[i].Requests > report.Traffic[j].Requests
+		}
+		return report.Traffic[i].Service < report.Traffic[j].Service
+	})
+
+	if len(report.Agents) == 0 && len(certs) == 0 && len(restarts) == 0 && len(report.Traffic) == 0 {
+		return nil
+	}
+	return report
+}
+
+func successRate(t *ServiceTraffic) float64 {
+	if t.Requests <= 0 {
+		return -1
+	}
+	return t.Success / t.Requests
+}
+
+// safeName 防止项目编码中的 .. 或路径分隔符逃逸出目录
+func safeName(name string) string {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "_"
+	}
+	return name
+}
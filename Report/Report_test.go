@@ -0,0 +1,163 @@
+package Report
+
+import (
+	"testing"
+	"time"
+
+	"monitor-server/Daily"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// testMetrics 报告采集的三类指标，project_code 为 Metrics.Gatherer 展开后的项目编码
+type testMetrics struct {
+	registry *prometheus.Registry
+	active   *prometheus.GaugeVec
+	ssl      *prometheus.GaugeVec
+	restarts *prometheus.GaugeVec
+}
+
+func newTestMetrics() *testMetrics {
+	m := &testMetrics{
+		registry: prometheus.NewRegistry(),
+		active:   prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: agentActiveMetric}, []string{"hostName", "project_code"}),
+		ssl:      prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: sslDaysLeftMetric}, []string{"domain", "comment", "project_code"}),
+		restarts: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: containerRestartsName}, []string{"namespace", "controllerName", "podName", "container", "project_code"}),
+	}
+	m.registry.MustRegister(m.active, m.ssl, m.restarts)
+	return m
+}
+
+func resetStats(t *testing.T) {
+	t.Helper()
+	if err := Daily.Start(Daily.Config{Dir: t.TempDir(), Timezone: "UTC"}); err != nil {
+		t.Fatal(err)
+	}
+	statsMu.Lock()
+	stats = map[string]map[string]*dayStats{}
+	lastRestarts = map[string]float64{}
+	certificates = map[string][]certificate{}
+	statsMu.Unlock()
+}
+
+func TestBuildAggregatesSamples(t *testing.T) {
+	resetStats(t)
+	m := newTestMetrics()
+	day1 := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	// node-1 第一天 3/4 在线，第二天全部在线；node-2 第一天全部离线
+	for i, up := range []float64{1, 1, 0, 1} {
+		m.active.WithLabelValues("node-1", "shop").Set(up)
+		m.active.WithLabelValues("node-2", "shop").Set(0)
+		// 重启计数 5 是基准值，之后增加 1、2，中途归零后从 1 重新累计
+		m.restarts.WithLabelValues("default", "api", "api-0", "app", "shop").Set([]float64{5, 6, 8, 1}[i])
+		if err := sample(m.registry, day1.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.active.DeleteLabelValues("node-2", "shop")
+	m.ssl.WithLabelValues("shop.example.com", "main", "shop").Set(40)
+	m.ssl.WithLabelValues("pay.example.com", "", "shop").Set(12)
+	for i := 0; i < 4; i++ {
+		if err := sample(m.registry, day2.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 同一域名另一个解析地址剩余天数更少
+	m.ssl.WithLabelValues("pay.example.com", "backup", "shop").Set(5)
+	if err := sample(m.registry, day2.Add(5*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{}
+	c.applyDefaults()
+
+	daily := build(c, KindDaily, "shop", "shop", "2024-03-04", "2024-03-04", day2)
+	if daily == nil {
+		t.Fatal("daily report is nil")
+	}
+	if len(daily.Agents) != 2 || daily.Agents[0].Host != "node-2" || daily.Agents[0].Uptime != 0 || daily.Agents[1].Uptime != 0.75 {
+		t.Errorf("daily agents = %+v", daily.Agents)
+	}
+	if daily.Uptime != 3.0/8 {
+		t.Errorf("daily uptime = %v, want %v", daily.Uptime, 3.0/8)
+	}
+	if len(daily.Restarts) != 1 || daily.Restarts[0].Restarts != 3 || daily.Restarts[0].Pod != "api-0" {
+		t.Errorf("daily restarts = %+v, want api-0 with 3", daily.Restarts)
+	}
+
+	weekly := build(c, KindWeekly, "shop", "shop", "2024-03-04", "2024-03-05", day2)
+	if weekly.Uptime != 8.0/13 {
+		t.Errorf("weekly uptime = %v, want %v", weekly.Uptime, 8.0/13)
+	}
+	if len(weekly.Certs) != 1 || weekly.Certs[0].Domain != "pay.example.com" || weekly.Certs[0].DaysLeft != 5 || weekly.Certs[0].Comment != "backup" {
+		t.Errorf("weekly certs = %+v, want pay.example.com with 5 days left", weekly.Certs)
+	}
+
+	if r := build(c, KindDaily, "other", "other", "2024-03-04", "2024-03-04", day2); r != nil {
+		t.Errorf("report without data = %+v, want nil", r)
+	}
+}
+
+func TestBuildTraffic(t *testing.T) {
+	resetStats(t)
+	records := []Daily.Record{
+		{Date: "2024-03-04", Service: "api", Values: map[string]float64{"requests": 100, "success": 90, "errors": 10}},
+		{Date: "2024-03-05", Service: "api", Values: map[string]float64{"requests": 100, "success": 100}},
+		{Date: "2024-03-05", Service: "web", Values: map[string]float64{"requests": 300, "success": 270, "errors": 30}},
+		{Date: "2024-03-05", Service: "idle", Values: map[string]float64{}},
+		{Date: "2024-03-06", Service: "api", Values: map[string]float64{"requests": 1000}}, // 不在统计范围内
+	}
+	for _, record := range records {
+		record.Project = "traffic"
+		if err := Daily.Save(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := Config{}
+	c.applyDefaults()
+	report := build(c, KindWeekly, "traffic", "traffic", "2024-03-04", "2024-03-05", time.Now())
+	if report == nil {
+		t.Fatal("report is nil")
+	}
+
+	want := []ServiceTraffic{
+		{Service: "web", Requests: 300, Success: 270, Errors: 30, SuccessRate: 0.9},
+		{Service: "api", Requests: 200, Success: 190, Errors: 10, SuccessRate: 0.95},
+		{Service: "idle", SuccessRate: -1},
+	}
+	if len(report.Traffic) != len(want) {
+		t.Fatalf("traffic = %+v, want %+v", report.Traffic, want)
+	}
+	for i := range want {
+		if report.Traffic[i] != want[i] {
+			t.Errorf("traffic[%d] = %+v, want %+v", i, report.Traffic[i], want[i])
+		}
+	}
+	total := ServiceTraffic{Service: "合计", Requests: 500, Success: 460, Errors: 40, SuccessRate: 0.92}
+	if report.TrafficAll != total {
+		t.Errorf("traffic total = %+v, want %+v", report.TrafficAll, total)
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	for name, want := range map[string]time.Weekday{"": time.Monday, "sunday": time.Sunday, "Friday": time.Friday} {
+		got, err := parseWeekday(name)
+		if err != nil || got != want {
+			t.Errorf("parseWeekday(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := parseWeekday("Funday"); err == nil {
+		t.Error("parseWeekday(Funday) succeeded, want error")
+	}
+}
+
+func TestSafeName(t *testing.T) {
+	for name, want := range map[string]string{"shop": "shop", "": "_", "..": "_", "a/b": "_", `a\b`: "_"} {
+		if got := safeName(name); got != want {
+			t.Errorf("safeName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
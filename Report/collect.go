@@ -0,0 +1,197 @@
+package Report
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// 报告用到的指标
+const (
+	agentActiveMetric     = "is_active"
+	sslDaysLeftMetric     = "ssl_domain_days_left"
+	containerRestartsName = "container_restarts_total"
+)
+
+// 每日统计保留天数（周报需要 7 天）
+const keepDays = 8
+
+// hostUptime 主机 agent 在一天内的采样次数和在线次数
+type hostUptime struct {
+	samples int
+	up      int
+}
+
+// containerRestarts 容器在一天内的重启次数
+type containerRestarts struct {
+	namespace  string
+	controller string
+	pod        string
+	container  string
+	restarts   float64
+}
+
+// dayStats 项目一天的统计
+type dayStats struct {
+	hosts    map[string]*hostUptime
+	restarts map[string]*containerRestarts
+}
+
+// certificate 证书剩余天数（当前值）
+type certificate struct {
+	domain   string
+	comment  string
+	daysLeft float64
+}
+
+var (
+	// 项目编码 -> day -> 统计，day 为项目时区的 YYYY-MM-DD
+	stats = map[string]map[string]*dayStats{}
+	// 容器重启累计值的上一次采样，key 为 project|:|namespace|:|controller|:|pod|:|container
+	lastRestarts = map[string]float64{}
+	// 每个项目当前的证书
+	certificates = map[string][]certificate{}
+	statsMu      sync.Mutex
+)
+
+func labelValue(m *dto.Metric, name string) string {
+	for _, label := range m.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+func metricValue(m *dto.Metric) float64 {
+	if m.Counter != nil {
+		return m.GetCounter().GetValue()
+	}
+	return m.GetGauge().GetValue()
+}
+
+// dayOf 返回项目在 at 时刻的统计，不存在时创建
+func dayOf(project string, at time.Time) *dayStats {
+	day := at.In(location(project)).Format("2006-01-02")
+	days, ok := stats[project]
+	if !ok {
+		days = map[string]*dayStats{}
+		stats[project] = days
+	}
+	d, ok := days[day]
+	if !ok {
+		d = &dayStats{hosts: map[string]*hostUptime{}, restarts: map[string]*containerRestarts{}}
+		days[day] = d
+	}
+	return d
+}
+
+// sample 采集一次当前指标，累加到各项目当天的统计中
+func sample(gatherer prometheus.Gatherer, now time.Time) error {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return err
+	}
+
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	seenRestarts := map[string]bool{}
+	currentCerts := map[string][]certificate{}
+	for _, mf := range families {
+		switch mf.GetName() {
+		case agentActiveMetric:
+			for _, m := range mf.GetMetric() {
+				project, host := labelValue(m, "project_code"), labelValue(m, "hostName")
+				d := dayOf(project, now)
+				h, ok := d.hosts[host]
+				if !ok {
+					h = &hostUptime{}
+					d.hosts[host] = h
+				}
+				h.samples++
+				if metricValue(m) >= 1 {
+					h.up++
+				}
+			}
+		case sslDaysLeftMetric:
+			for _, m := range mf.GetMetric() {
+				project := labelValue(m, "project_code")
+				currentCerts[project] = append(currentCerts[project], certificate{
+					domain:   labelValue(m, "domain"),
+					comment:  labelValue(m, "comment"),
+					daysLeft: metricValue(m),
+				})
+			}
+		case containerRestartsName:
+			for _, m := range mf.GetMetric() {
+				c := containerRestarts{
+					namespace:  labelValue(m, "namespace"),
+					controller: labelValue(m, "controllerName"),
+					pod:        labelValue(m, "podName"),
+					container:  labelValue(m, "container"),
+				}
+				project := labelValue(m, "project_code")
+				key := project + "|:|" + c.namespace + "|:|" + c.controller + "|:|" + c.pod + "|:|" + c.container
+				value := metricValue(m)
+				seenRestarts[key] = true
+
+				// 第一次看到的容器没有基准值，不计入
+				last, ok := lastRestarts[key]
+				lastRestarts[key] = value
+				if !ok || value <= last {
+					continue
+				}
+				d := dayOf(project, now)
+				r, ok := d.restarts[key]
+				if !ok {
+					r = &c
+					d.restarts[key] = r
+				}
+				r.restarts += value - last
+			}
+		}
+	}
+	certificates = currentCerts
+
+	// 已过期的容器不再保留基准值
+	for key := range lastRestarts {
+		if !seenRestarts[key] {
+			delete(lastRestarts, key)
+		}
+	}
+
+	// 删除过旧的统计
+	for project, days := range stats {
+		oldest := now.In(location(project)).AddDate(0, 0, -keepDays).Format("2006-01-02")
+		for day := range days {
+			if day < oldest {
+				delete(days, day)
+			}
+		}
+		if len(days) == 0 {
+			delete(stats, project)
+		}
+	}
+	return nil
+}
+
+// statsProjects 返回有统计数据的项目
+func statsProjects() []string {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	seen := map[string]bool{}
+	for project := range stats {
+		seen[project] = true
+	}
+	for project := range certificates {
+		seen[project] = true
+	}
+	projects := make([]string, 0, len(seen))
+	for project := range seen {
+		projects = append(projects, project)
+	}
+	return projects
+}
@@ -0,0 +1,132 @@
+package Report
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"text/template"
+)
+
+var funcs = map[string]interface{}{
+	"percent": func(v float64) string {
+		if v < 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.2f%%", v*100)
+	},
+	"number": func(v float64) string {
+		return fmt.Sprintf("%.0f", v)
+	},
+	"title": title,
+}
+
+// title 报告标题，如 "戒享花 日报（2026-01-02）"
+func title(r *Report) string {
+	if r.Kind == KindWeekly {
+		return fmt.Sprintf("%s 周报（%s ~ %s）", r.Project, r.From, r.To)
+	}
+	return fmt.Sprintf("%s 日报（%s）", r.Project, r.From)
+}
+
+const markdownTemplate = `# {{title .}}
+
+生成时间：{{.GeneratedAt.Format "2006-01-02 15:04:05"}}
+
+## Agent 在线率
+
+平均在线率：{{percent .Uptime}}
+{{if .Agents}}
+| 主机 | 在线率 |
+| --- | --- |
+{{range .Agents}}| {{.Host}} | {{percent .Uptime}} |
+{{end}}{{else}}
+无 agent 数据
+{{end}}
+## 即将到期的证书
+{{if .Certs}}
+| 域名 | 备注 | 剩余天数 |
+| --- | --- | --- |
+{{range .Certs}}| {{.Domain}} | {{.Comment}} | {{number .DaysLeft}} |
+{{end}}{{else}}
+无
+{{end}}
+## 容器重启排行
+{{if .Restarts}}
+| namespace | 控制器 | Pod | 容器 | 重启次数 |
+| --- | --- | --- | --- | --- |
+{{range .Restarts}}| {{.Namespace}} | {{.Controller}} | {{.Pod}} | {{.Container}} | {{number .Restarts}} |
+{{end}}{{else}}
+无重启
+{{end}}
+## 流量成功率
+{{if .Traffic}}
+| 服务 | 请求数 | 成功数 | 失败数 | 成功率 |
+| --- | --- | --- | --- | --- |
+{{range .Traffic}}| {{.Service}} | {{number .Requests}} | {{number .Success}} | {{number .Errors}} | {{percent .SuccessRate}} |
+{{end}}{{with .TrafficAll}}| **{{.Service}}** | {{number .Requests}} | {{number .Success}} | {{number .Errors}} | {{percent .SuccessRate}} |
+{{end}}{{else}}
+无流量数据
+{{end}}`
+
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{title .}}</title>
+<style>
+body { font-family: -apple-system, "PingFang SC", "Microsoft YaHei", sans-serif; margin: 24px; color: #222; }
+table { border-collapse: collapse; margin: 8px 0 24px; }
+th, td { border: 1px solid #ddd; padding: 4px 10px; text-align: left; }
+th { background: #f5f5f5; }
+.muted { color: #888; }
+</style>
+</head>
+<body>
+<h1>{{title .}}</h1>
+<p class="muted">生成时间：{{.GeneratedAt.Format "2006-01-02 15:04:05"}}</p>
+
+<h2>Agent 在线率</h2>
+<p>平均在线率：{{percent .Uptime}}</p>
+{{if .Agents}}<table>
+<tr><th>主机</th><th>在线率</th></tr>
+{{range .Agents}}<tr><td>{{.Host}}</td><td>{{percent .Uptime}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">无 agent 数据</p>{{end}}
+
+<h2>即将到期的证书</h2>
+{{if .Certs}}<table>
+<tr><th>域名</th><th>备注</th><th>剩余天数</th></tr>
+{{range .Certs}}<tr><td>{{.Domain}}</td><td>{{.Comment}}</td><td>{{number .DaysLeft}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">无</p>{{end}}
+
+<h2>容器重启排行</h2>
+{{if .Restarts}}<table>
+<tr><th>namespace</th><th>控制器</th><th>Pod</th><th>容器</th><th>重启次数</th></tr>
+{{range .Restarts}}<tr><td>{{.Namespace}}</td><td>{{.Controller}}</td><td>{{.Pod}}</td><td>{{.Container}}</td><td>{{number .Restarts}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">无重启</p>{{end}}
+
+<h2>流量成功率</h2>
+{{if .Traffic}}<table>
+<tr><th>服务</th><th>请求数</th><th>成功数</th><th>失败数</th><th>成功率</th></tr>
+{{range .Traffic}}<tr><td>{{.Service}}</td><td>{{number .Requests}}</td><td>{{number .Success}}</td><td>{{number .Errors}}</td><td>{{percent .SuccessRate}}</td></tr>
+{{end}}{{with .TrafficAll}}<tr><th>{{.Service}}</th><th>{{number .Requests}}</th><th>{{number .Success}}</th><th>{{number .Errors}}</th><th>{{percent .SuccessRate}}</th></tr>
+{{end}}</table>{{else}}<p class="muted">无流量数据</p>{{end}}
+</body>
+</html>
+`
+
+var (
+	markdownTmpl = template.Must(template.New("markdown").Funcs(funcs).Parse(markdownTemplate))
+	htmlTmpl     = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(htmlTemplate))
+)
+
+// render 生成 Markdown 和 HTML 两种格式的报告
+func render(r *Report) (string, string, error) {
+	var markdown, html bytes.Buffer
+	if err := markdownTmpl.Execute(&markdown, r); err != nil {
+		return "", "", err
+	}
+	if err := htmlTmpl.Execute(&html, r); err != nil {
+		return "", "", err
+	}
+	return markdown.String(), html.String(), nil
+}
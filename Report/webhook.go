@@ -0,0 +1,60 @@
+package Report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookConfig 报告推送配置
+type WebhookConfig struct {
+	URL     string        `yaml:"url"`     // 为空时不推送
+	Type    string        `yaml:"type"`    // json（默认，推送完整报告）、dingtalk、wecom
+	Timeout time.Duration `yaml:"timeout"` // 请求超时
+}
+
+const defaultWebhookTimeout = 10 * time.Second
+
+// push 推送一份报告
+func push(c WebhookConfig, r *Report, markdown, html string) error {
+	var body interface{}
+	switch c.Type {
+	case "dingtalk":
+		body = map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"title": title(r), "text": markdown},
+		}
+	case "wecom":
+		body = map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"content": markdown},
+		}
+	case "", "json":
+		body = struct {
+			*Report
+			Markdown string `json:"markdown"`
+			HTML     string `json:"html"`
+		}{r, markdown, html}
+	default:
+		return fmt.Errorf("不支持的 webhook 类型: %s", c.Type)
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: c.Timeout}
+	resp, err := client.Post(c.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"monitor-server/Archive"
+	"monitor-server/Daily"
+	"monitor-server/Handers"
+	"monitor-server/History"
+	"monitor-server/Influx"
+	"monitor-server/RemoteWrite"
+	"monitor-server/Report"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 配置结构体
+// 加载顺序：默认值 -> 配置文件 -> 环境变量（MONITOR_*）-> 命令行参数，启动和重新加载都走 loadConfig
+type Config struct {
+	Encrypted string   `yaml:"encrypted"` // 加密盐
+	IpPass    []string `yaml:"ipPass"`    // IP 白名单
+
+	Server             ServerConfig  `yaml:"server"`             // HTTP 服务
+	Ingest             IngestConfig  `yaml:"ingest"`             // 上报处理
+	Admin              AdminConfig   `yaml:"admin"`              // 管理接口
+	ProjectsFile       string        `yaml:"projectsFile"`       // 项目登记文件
+	MetricTTL          time.Duration `yaml:"metricTTL"`          // 指标过期时间，超过该时间未更新的指标会被删除
+	CheckInterval      time.Duration `yaml:"checkInterval"`      // 检查指标过期的间隔
+	DnsRefreshInterval time.Duration `yaml:"dnsRefreshInterval"` // 刷新 IP 白名单域名解析的间隔
+
+	AgentTimestamp        Handers.TimestampConfig `yaml:"agentTimestamp"`        // agent 时间戳
+	Otlp                  Handers.OtlpConfig      `yaml:"otlp"`                  // OTLP 接收
+	HardLegacyLabels      bool                    `yaml:"hardLegacyLabels"`      // 迁移期间同时输出带主机属性标签的旧版硬件指标
+	LegacyProjectLabel    bool                    `yaml:"legacyProjectLabel"`    // 迁移期间同时输出值为显示名称的旧版 project 标签
+	LegacyCounterGauges   bool                    `yaml:"legacyCounterGauges"`   // 同时以 gauge 类型输出旧版累计指标
+	LegacyControllerLabel bool                    `yaml:"legacyControllerLabel"` // 迁移期间控制器指标同时输出旧版 container 标签
+	NamespaceRules        []Handers.NamespaceRule `yaml:"namespaceRules"`        // namespace 归一化规则
+	RolloutStuckAfter     time.Duration           `yaml:"rolloutStuckAfter"`     // 发布无进展多久后视为卡住
+	TopN                  int                     `yaml:"topN"`                  // 日志统计（esIp/esCountry/esUrl）每个项目保留的排行数量
+	RejectUnknownProjects bool                    `yaml:"rejectUnknownProjects"` // 拒绝 projects.json 中没有登记的项目
+
+	RemoteWrite RemoteWrite.Config `yaml:"remoteWrite"` // 远程写入
+	Influx      Influx.Config      `yaml:"influx"`      // InfluxDB 输出
+	Archive     Archive.Config     `yaml:"archive"`     // 原始数据归档
+	Daily       Daily.Config       `yaml:"daily"`       // 每日统计历史和日界时区
+	Report      Report.Config      `yaml:"report"`      // 项目日报/周报
+	History     History.Config     `yaml:"history"`     // 每条序列的短期历史
+}
+
+// ServerConfig HTTP 服务配置
+type ServerConfig struct {
+	Listen       string        `yaml:"listen"`       // 监听地址
+	ReadTimeout  time.Duration `yaml:"readTimeout"`  // 读取请求超时
+	WriteTimeout time.Duration `yaml:"writeTimeout"` // 写入响应超时
+	IdleTimeout  time.Duration `yaml:"idleTimeout"`  // 空闲连接超时
+}
+
+// IngestConfig 上报处理配置
+type IngestConfig struct {
+	Workers        int  `yaml:"workers"`        // 并发 worker 数量
+	QueueSize      int  `yaml:"queueSize"`      // 任务队列缓冲大小
+	RejectWhenFull bool `yaml:"rejectWhenFull"` // 队列满时返回 503 由 agent 重试，默认另起 goroutine 处理（旧版 agent 不会重试 503）
+	MaxBodySizeMB  int  `yaml:"maxBodySizeMB"`  // 请求体大小上限（MB）
+}
+
+// AdminConfig 管理接口配置
+type AdminConfig struct {
+	Token string `yaml:"token"` // 管理接口令牌，为空时禁用 /api/admin/reload 和 /api/admin/replay
+}
+
+// defaultConfig 默认配置，配置文件中没有的项保持默认值
+func defaultConfig() Config {
+	return Config{
+		Server: ServerConfig{
+			Listen:       ":8080",
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  120 * time.Second,
+		},
+		Ingest: IngestConfig{
+			Workers:       500,
+			QueueSize:     10000,
+			MaxBodySizeMB: 10,
+		},
+		ProjectsFile:       "config/projects.json",
+		MetricTTL:          20 * time.Second,
+		CheckInterval:      5 * time.Second,
+		DnsRefreshInterval: 5 * time.Minute,
+		AgentTimestamp:     Handers.TimestampConfig{ClockSkew: 10 * time.Second},
+		// 迁移期间保留旧版指标和标签，配置文件中没有这些项时不影响已有的看板和告警
+		LegacyCounterGauges:   true,
+		LegacyControllerLabel: true,
+	}
+}
+
+// envPrefix 环境变量前缀，如 MONITOR_SERVER_LISTEN 覆盖 server.listen
+const envPrefix = "MONITOR_"
+
+// configOverrides 命令行参数中的配置覆盖，重新加载时同样生效
+type configOverrides []string
+
+func (o *configOverrides) String() string { return strings.Join(*o, ",") }
+
+func (o *configOverrides) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("格式为 key=value，如 ingest.workers=200")
+	}
+	*o = append(*o, value)
+	return nil
+}
+
+// loadConfig 读取配置文件，依次应用环境变量和命令行覆盖，并校验
+func loadConfig(configFile string, overrides []string) (*Config, error) {
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("打开配置文件失败: %v", err)
+	}
+
+	config := defaultConfig()
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true) // 拼错的配置项直接报错，而不是静默使用默认值
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, envPrefix) || name == envPrefix+"CONFIG" {
+			continue
+		}
+		err := setConfigValue(&config, func(path []string) bool { return envName(path) == name }, value)
+		if errors.Is(err, errUnknownKey) {
+			// 可能是其它程序使用的同前缀变量，不影响启动
+			log.Printf("忽略环境变量 %s: %v", name, err)
+		} else if err != nil {
+			return nil, fmt.Errorf("环境变量 %s: %v", name, err)
+		}
+	}
+
+	for _, override := range overrides {
+		key, value, _ := strings.Cut(override, "=")
+		if err := setConfigValue(&config, func(path []string) bool { return strings.Join(path, ".") == key }, value); err != nil {
+			return nil, fmt.Errorf("参数 -set %s: %v", key, err)
+		}
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// validate 校验配置，返回全部错误
+func (c *Config) validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	n := len(c.Encrypted)
+	check(n == 16 || n == 24 || n == 32, "encrypted: AES 密钥长度应为 16/24/32 字节，当前: %d 字节", n)
+	// 白名单为空时所有 IP 都无法访问 /metrics，多半是配置文件只保存了一半
+	check(len(c.IpPass) > 0, "ipPass: 至少需要一项")
+	for i, entry := range c.IpPass {
+		check(strings.TrimSpace(entry) != "", "ipPass[%d]: 不能为空", i)
+	}
+	check(c.Server.Listen != "", "server.listen: 不能为空")
+	check(c.Server.ReadTimeout >= 0, "server.readTimeout: 不能为负数")
+	check(c.Server.WriteTimeout >= 0, "server.writeTimeout: 不能为负数")
+	check(c.Server.IdleTimeout >= 0, "server.idleTimeout: 不能为负数")
+	check(c.Ingest.Workers > 0, "ingest.workers: 需要大于 0")
+	check(c.Ingest.QueueSize > 0, "ingest.queueSize: 需要大于 0")
+	check(c.Ingest.MaxBodySizeMB > 0, "ingest.maxBodySizeMB: 需要大于 0")
+	check(c.ProjectsFile != "", "projectsFile: 不能为空")
+	check(c.MetricTTL > 0, "metricTTL: 需要大于 0")
+	check(c.CheckInterval > 0, "checkInterval: 需要大于 0")
+	check(c.MetricTTL <= 0 || c.CheckInterval <= c.MetricTTL, "checkInterval: 不能大于 metricTTL（%v）", c.MetricTTL)
+	check(c.DnsRefreshInterval > 0, "dnsRefreshInterval: 需要大于 0")
+	// 管理令牌可以修改服务端配置，不能与上报使用的密钥或令牌相同
+	check(c.Admin.Token == "" || (c.Admin.Token != c.Encrypted && c.Admin.Token != c.Otlp.Token), "admin.token: 不能与 encrypted 或 otlp.token 相同")
+	if err := Handers.CheckProjectSecrets(c.Encrypted, c.Otlp.Token); err != nil {
+		problems = append(problems, err.Error())
+	}
+	check(c.AgentTimestamp.ClockSkew >= 0, "agentTimestamp.clockSkew: 不能为负数")
+	check(c.RolloutStuckAfter >= 0, "rolloutStuckAfter: 不能为负数")
+	check(c.TopN >= 0, "topN: 不能为负数")
+	for i, rule := range c.NamespaceRules {
+		_, err := regexp.Compile(rule.Match)
+		check(err == nil, "namespaceRules[%d].match: 正则 %q 不合法: %v", i, rule.Match, err)
+	}
+	if c.Daily.Timezone != "" {
+		_, err := time.LoadLocation(c.Daily.Timezone)
+		check(err == nil, "daily.timezone: 时区 %s 不合法: %v", c.Daily.Timezone, err)
+	}
+	for project, name := range c.Daily.ProjectTimezones {
+		_, err := time.LoadLocation(name)
+		check(err == nil, "daily.projectTimezones.%s: 时区 %s 不合法: %v", project, name, err)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("配置校验失败:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// printConfig 以 YAML 输出生效的配置，密钥类字段打码
+func printConfig(c *Config) error {
+	masked := *c
+	maskSecrets(reflect.ValueOf(&masked).Elem())
+	out, err := yaml.Marshal(&masked)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// secretFields 输出配置时需要打码的字段（yaml 名称）
+var secretFields = map[string]bool{"encrypted": true, "bearerToken": true, "password": true, "token": true}
+
+func maskSecrets(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if secretFields[yamlName(field)] && field.Type.Kind() == reflect.String && v.Field(i).String() != "" {
+				v.Field(i).SetString("******")
+				continue
+			}
+			maskSecrets(v.Field(i))
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		// 复制一份，避免修改原配置共享的底层数组
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(copied, v)
+		v.Set(copied)
+		for i := 0; i < v.Len(); i++ {
+			maskSecrets(v.Index(i))
+		}
+	}
+}
+
+func yamlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// envName 配置路径对应的环境变量名，如 [agentTimestamp clockSkew] -> MONITOR_AGENT_TIMESTAMP_CLOCK_SKEW
+func envName(path []string) string {
+	var b strings.Builder
+	b.WriteString(envPrefix)
+	for i, segment := range path {
+		if i > 0 {
+			b.WriteByte('_')
+		}
+		runes := []rune(segment)
+		for j, r := range runes {
+			if j > 0 && unicode.IsUpper(r) {
+				prevLower := unicode.IsLower(runes[j-1]) || unicode.IsDigit(runes[j-1])
+				nextLower := j+1 < len(runes) && unicode.IsLower(runes[j+1])
+				if prevLower || (unicode.IsUpper(runes[j-1]) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var errUnknownKey = errors.New("没有对应的配置项")
+
+// setConfigValue 设置第一个路径匹配的标量配置项（字符串、布尔、数字、时长、逗号分隔的字符串列表）
+// 没有匹配的配置项时返回错误
+func setConfigValue(c *Config, match func(path []string) bool, value string) error {
+	found, err := setField(reflect.ValueOf(c).Elem(), nil, match, value)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errUnknownKey
+	}
+	return nil
+}
+
+func setField(v reflect.Value, path []string, match func([]string) bool, value string) (bool, error) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldPath := append(append([]string{}, path...), yamlName(field))
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			if found, err := setField(fv, fieldPath, match, value); found || err != nil {
+				return found, err
+			}
+			continue
+		}
+		if !match(fieldPath) {
+			continue
+		}
+		return true, setScalar(fv, value)
+	}
+	return false, nil
+}
+
+func setScalar(v reflect.Value, value string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("时长 %q 不合法", value)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("布尔值 %q 不合法", value)
+		}
+		v.SetBool(b)
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("整数 %q 不合法", value)
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("数字 %q 不合法", value)
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("该配置项不支持通过环境变量或参数覆盖，请在配置文件中设置")
+	}
+	return nil
+}
+
+// cliOptions 命令行参数
+type cliOptions struct {
+	configFile  string
+	overrides   configOverrides
+	printConfig bool
+}
+
+// parseFlags 解析命令行参数
+// -listen 是 -set server.listen= 的简写，配置文件路径也可以用 MONITOR_CONFIG 指定
+func parseFlags(args []string) cliOptions {
+	var opts cliOptions
+	fs := flag.NewFlagSet("monitor-server", flag.ExitOnError)
+	defaultFile := os.Getenv(envPrefix + "CONFIG")
+	if defaultFile == "" {
+		defaultFile = "config/config.yaml"
+	}
+	fs.StringVar(&opts.configFile, "config", defaultFile, "配置文件")
+	listen := fs.String("listen", "", "监听地址，覆盖 server.listen")
+	fs.Var(&opts.overrides, "set", "覆盖配置项，如 -set ingest.workers=200，可重复")
+	fs.BoolVar(&opts.printConfig, "print-config", false, "输出生效的配置（密钥打码）后退出")
+	if err := fs.Parse(args); err != nil {
+		log.Fatal(err)
+	}
+	if *listen != "" {
+		opts.overrides = append(opts.overrides, "server.listen="+*listen)
+	}
+	return opts
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"monitor-server/RemoteWrite"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKey = "0123456789abcdef"
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string][]string{
+		"MONITOR_SERVER_LISTEN":              {"server", "listen"},
+		"MONITOR_IP_PASS":                    {"ipPass"},
+		"MONITOR_AGENT_TIMESTAMP_CLOCK_SKEW": {"agentTimestamp", "clockSkew"},
+		"MONITOR_METRIC_TTL":                 {"metricTTL"},
+		"MONITOR_TOP_N":                      {"topN"},
+		"MONITOR_INGEST_MAX_BODY_SIZE_MB":    {"ingest", "maxBodySizeMB"},
+		"MONITOR_DAILY_TIMEZONE":             {"daily", "timezone"},
+	}
+	for want, path := range tests {
+		if got := envName(path); got != want {
+			t.Errorf("envName(%v) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, `
+encrypted: "`+testKey+`"
+server:
+  listen: ":8080"
+ingest:
+  workers: 10
+metricTTL: 30s
+`)
+	t.Setenv("MONITOR_INGEST_WORKERS", "20")
+	t.Setenv("MONITOR_METRIC_TTL", "1m")
+	t.Setenv("MONITOR_IP_PASS", " a.com, ,10.0.0.1 ")
+	t.Setenv("MONITOR_NOT_A_KEY", "ignored")
+
+	c, err := loadConfig(path, []string{"ingest.workers=30", "server.listen=:9090"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Ingest.Workers != 30 {
+		t.Errorf("ingest.workers = %d, want 30 from -set", c.Ingest.Workers)
+	}
+	if c.MetricTTL != time.Minute {
+		t.Errorf("metricTTL = %v, want 1m from env", c.MetricTTL)
+	}
+	if c.Server.Listen != ":9090" {
+		t.Errorf("server.listen = %q, want :9090", c.Server.Listen)
+	}
+	if strings.Join(c.IpPass, "|") != "a.com|10.0.0.1" {
+		t.Errorf("ipPass = %q", c.IpPass)
+	}
+	// 配置文件中没有的项保持默认值
+	if c.Ingest.QueueSize != defaultConfig().Ingest.QueueSize || c.CheckInterval != defaultConfig().CheckInterval {
+		t.Errorf("defaults lost: queueSize=%d checkInterval=%v", c.Ingest.QueueSize, c.CheckInterval)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	valid := `encrypted: "` + testKey + `"` + "\n"
+	tests := []struct {
+		name      string
+		content   string
+		env       map[string]string
+		overrides []string
+		wantErr   string
+	}{
+		{name: "unknown key in file", content: valid + "metricTtl: 5s\n", wantErr: "metricTtl"},
+		{name: "bad env value", content: valid, env: map[string]string{"MONITOR_INGEST_WORKERS": "many"}, wantErr: "MONITOR_INGEST_WORKERS"},
+		{name: "unknown override", content: valid, overrides: []string{"ingest.worker=1"}, wantErr: "ingest.worker"},
+		{name: "override of non-scalar", content: valid, overrides: []string{"namespaceRules=x"}, wantErr: "不支持"},
+		{name: "bad duration override", content: valid, overrides: []string{"checkInterval=5"}, wantErr: "时长"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			_, err := loadConfig(writeConfig(t, tt.content), tt.overrides)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	c := defaultConfig()
+	c.Encrypted = "short"
+	c.IpPass = []string{"a.com", " "}
+	c.Ingest.Workers = 0
+	c.CheckInterval = time.Minute // 大于 metricTTL
+	c.TopN = -1
+	c.Admin.Token = "short"
+
+	err := c.validate()
+	if err == nil {
+		t.Fatal("validate() = nil")
+	}
+	for _, want := range []string{"encrypted", "ipPass[1]", "ingest.workers", "checkInterval", "topN", "admin.token"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validate() error missing %s:\n%v", want, err)
+		}
+	}
+
+	c = defaultConfig()
+	c.Encrypted = testKey
+	c.IpPass = []string{"127.0.0.1"}
+	if err := c.validate(); err != nil {
+		t.Errorf("default config with key and ipPass: %v", err)
+	}
+}
+
+func TestMaskSecretsKeepsOriginal(t *testing.T) {
+	c := defaultConfig()
+	c.Encrypted = testKey
+	c.Admin.Token = "admin-token"
+	c.RemoteWrite.Endpoints = []RemoteWrite.Endpoint{{Name: "vm", BearerToken: "token-1"}}
+	masked := c
+	maskSecrets(reflect.ValueOf(&masked).Elem())
+	if masked.Encrypted != "******" || masked.Admin.Token != "******" || masked.RemoteWrite.Endpoints[0].BearerToken != "******" {
+		t.Errorf("masked = %q %q %q", masked.Encrypted, masked.Admin.Token, masked.RemoteWrite.Endpoints[0].BearerToken)
+	}
+	// 切片复制后打码，不影响原配置
+	if c.Encrypted != testKey || c.RemoteWrite.Endpoints[0].BearerToken != "token-1" {
+		t.Errorf("original changed: %q %q", c.Encrypted, c.RemoteWrite.Endpoints[0].BearerToken)
+	}
+}
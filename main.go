@@ -1,145 +1,202 @@
 package main
 
 import (
-	"fmt"
-	"github.com/fsnotify/fsnotify"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/spf13/viper"
-	"gopkg.in/yaml.v3"
 	"log"
+	"monitor-server/Archive"
+	"monitor-server/Daily"
+	"monitor-server/Dashboard"
 	"monitor-server/Handers"
+	"monitor-server/History"
+	"monitor-server/Influx"
 	"monitor-server/IpPass"
 	"monitor-server/Metrics"
+	"monitor-server/RemoteWrite"
+	"monitor-server/Report"
 	"net/http"
 	"os"
 	"time"
 )
 
-// 配置结构体
-type Config struct {
-	Encrypted string   `yaml:"encrypted"` // 加密盐
-	IpPass    []string `yaml:"ipPass"`    // IP 白名单
-}
-
-// 读取配置文件的函数
-func loadConfig(configFile string) (*Config, error) {
-	file, err := os.Open(configFile)
-	if err != nil {
-		return nil, fmt.Errorf("打开配置文件失败: %v", err)
-	}
-	defer func(file *os.File) {
-		if err := file.Close(); err != nil {
-			log.Printf("关闭配置文件失败: %v", err)
-		}
-	}(file)
-
-	var config Config
-	decoder := yaml.NewDecoder(file)
-	if err := decoder.Decode(&config); err != nil {
-		return nil, fmt.Errorf("解析配置文件失败: %v", err)
-	}
-	return &config, nil
-}
-
-// 动态加载配置并监听变化
-func loadConfigWithViper() {
-	viper.SetConfigFile("config/config.yaml")
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatalf("配置文件读取失败: %v", err)
-	}
-
-	// 监听配置文件变化
-	viper.WatchConfig()
-	viper.OnConfigChange(func(e fsnotify.Event) {
-		log.Printf("配置文件已更新: %v", e.Name)
-		Handers.SetEncryptionKey(viper.GetString("encrypted"))
-		IpPass.SetAllowedDomains(viper.GetStringSlice("ipPass"))
-	})
-}
-
 // 启动定时任务和心跳检查
-func startHeartbeatChecks() {
+func startHeartbeatChecks(checkInterval, dnsRefreshInterval time.Duration) {
 	// 启动 goroutine
 	go func() {
 		for {
 			Handers.CheckContainerHeartbeats()
-			time.Sleep(5 * time.Second) // 每 5 秒检查一次
+			time.Sleep(checkInterval)
 		}
 	}()
 	go func() {
 		for {
 			Handers.CheckHardHeartbeats()
-			time.Sleep(5 * time.Second) // 每 5 秒检查一次
+			time.Sleep(checkInterval)
+		}
+	}()
+	go func() {
+		for {
+			Handers.CheckNginxStatusHeartbeats()
+			time.Sleep(checkInterval)
+		}
+	}()
+	go func() {
+		for {
+			Handers.CheckTopNHeartbeats()
+			time.Sleep(checkInterval)
+		}
+	}()
+	go func() {
+		for {
+			Handers.CheckK8sEventHeartbeats()
+			time.Sleep(checkInterval)
+		}
+	}()
+	go func() {
+		for {
+			Handers.CheckHardDeviceHeartbeats()
+			time.Sleep(checkInterval)
 		}
 	}()
 	go func() {
 		for {
 			Handers.CheckHeartbeats()
-			time.Sleep(5 * time.Second) // 每 5 秒检查一次
+			time.Sleep(checkInterval)
 		}
 	}()
 	go func() {
 		for {
 			Handers.CheckSSLHeartbeats()
-			time.Sleep(5 * time.Second) // 每 5 秒检查一次
+			time.Sleep(checkInterval)
 		}
 	}()
 	go func() {
 		for {
 			Handers.CheckControllerHeartbeats()
-			time.Sleep(5 * time.Second) // 每 5 秒检查一次
+			time.Sleep(checkInterval)
 		}
 	}()
 	go func() {
 		for {
 			Handers.CheckNginxHeartbeats()
-			time.Sleep(5 * time.Second) // 每 5 秒检查一次
+			time.Sleep(checkInterval)
 		}
 	}()
 	go func() {
 		for {
 			IpPass.RefreshDomainIPCache()
-			time.Sleep(5 * time.Minute) // 每 5 分钟刷新一次域名解析缓存
+			time.Sleep(dnsRefreshInterval) // 刷新域名解析缓存
 		}
 	}()
 	go func() {
 		for {
 			Handers.CheckTrafficSwitchingHeartbeats()
-			time.Sleep(5 * time.Second) // 每 5 秒检查一次
+			time.Sleep(checkInterval)
+		}
+	}()
+	go func() {
+		for {
+			Handers.CheckOtlpHeartbeats()
+			time.Sleep(checkInterval)
+		}
+	}()
+	go func() {
+		for {
+			Handers.CheckAgentTimes()
+			time.Sleep(checkInterval)
 		}
 	}()
 }
 
 func main() {
+	// 子命令：回放归档数据
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		runReplay(os.Args[2:])
+		return
+	}
 
-	//var wg sync.WaitGroup
-	// 加载配置文件
-	config, err := loadConfig("config/config.yaml")
+	// 加载配置文件（环境变量和命令行参数可覆盖），校验失败时退出
+	opts := parseFlags(os.Args[1:])
+	config, err := loadConfig(opts.configFile, opts.overrides)
 	if err != nil {
 		log.Fatalf("加载配置失败: %v", err)
 	}
+	if opts.printConfig {
+		if err := printConfig(config); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	// 设置加密盐
-	Handers.SetEncryptionKey(config.Encrypted)
+	// 应用可以在运行时修改的配置
+	if err := applyConfig(config); err != nil {
+		log.Fatal(err)
+	}
 
-	// 启动域名解析缓存的定时刷新功能
-	err = Handers.LoadProjectDict("config/projects.json")
+	// 启动上报处理 worker pool
+	Handers.StartWorkers(config.Ingest.Workers, config.Ingest.QueueSize)
+
+	// 加载项目登记，文件变化时自动重新加载
+	err = Handers.LoadProjectDict(config.ProjectsFile)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err := Handers.WatchProjectDict(config.ProjectsFile); err != nil {
+		log.Printf("监听项目配置失败，修改后需重启生效: %v", err)
+	}
 
-	// 设置 IP 白名单
-	IpPass.SetAllowedDomains(config.IpPass)
-
-	// 启动动态配置加载
-	go loadConfigWithViper()
+	// 配置文件变化、SIGHUP、管理接口都会重新加载配置，校验失败时保留原配置
+	reloader := newConfigReloader(opts.configFile, opts.overrides, config)
+	if err := reloader.watch(); err != nil {
+		log.Printf("监听配置文件失败，修改后需 SIGHUP 或调用 /api/admin/reload 生效: %v", err)
+	}
+	reloader.handleSignals()
 
 	// 启动定时任务和心跳检查
-	go startHeartbeatChecks()
+	go startHeartbeatChecks(config.CheckInterval, config.DnsRefreshInterval)
+
+	// 启动远程写入（可选）
+	if config.RemoteWrite.Enabled {
+		if err := RemoteWrite.Start(config.RemoteWrite, Metrics.Gatherer); err != nil {
+			log.Fatalf("远程写入启动失败: %v", err)
+		}
+	}
+
+	// 启动 InfluxDB 输出（可选）
+	if config.Influx.Enabled {
+		if err := Influx.Start(config.Influx); err != nil {
+			log.Fatalf("InfluxDB 输出启动失败: %v", err)
+		}
+	}
+
+	// 启动原始数据归档（可选）
+	if config.Archive.Enabled {
+		if err := Archive.Start(config.Archive); err != nil {
+			log.Fatalf("归档启动失败: %v", err)
+		}
+	}
+
+	// 启动每日统计历史
+	if err := Daily.Start(config.Daily); err != nil {
+		log.Fatalf("每日历史启动失败: %v", err)
+	}
+
+	// 启动项目日报/周报（可选）
+	if config.Report.Enabled {
+		if err := Report.Start(config.Report, Metrics.Gatherer, Handers.ProjectNames); err != nil {
+			log.Fatalf("报告启动失败: %v", err)
+		}
+	}
+
+	// 启动短期历史（可选）
+	if config.History.Enabled {
+		if err := History.Start(config.History); err != nil {
+			log.Fatalf("短期历史启动失败: %v", err)
+		}
+	}
 
 	// 暴露自定义指标
 	metricsHandler := promhttp.HandlerFor(
-		Metrics.CustomRegistry, // 使用自定义的 Registry
+		Metrics.Gatherer, // 使用自定义的 Registry（project 标签展开为 project_code/project_name）
 		promhttp.HandlerOpts{},
 	)
 
@@ -151,17 +208,45 @@ func main() {
 		Handers.MetricsHandler(w, r, Metrics.CustomRegistry)
 	})
 
+	// OTLP/HTTP 指标接收端（带 IP 限制和密钥校验）
+	http.Handle("/v1/metrics", IpPass.IpRestrictionMiddleware(http.HandlerFunc(Handers.OtlpMetricsHandler)))
+
+	// Kubernetes 事件查询接口（带 IP 限制）
+	http.Handle("/api/k8s/events", IpPass.IpRestrictionMiddleware(http.HandlerFunc(Handers.K8sEventsHandler)))
+
+	// 每日历史查询接口（带 IP 限制）
+	http.Handle("/api/daily", IpPass.IpRestrictionMiddleware(http.HandlerFunc(Daily.QueryHandler)))
+
+	// 短期历史查询接口（带 IP 限制）
+	http.Handle("/api/history", IpPass.IpRestrictionMiddleware(http.HandlerFunc(History.QueryHandler)))
+
+	// 重新加载配置（带 IP 限制和管理令牌校验）
+	http.Handle("/api/admin/reload", IpPass.IpRestrictionMiddleware(reloader))
+
+	// 归档回放接口（带 IP 限制和管理令牌校验）
+	http.Handle("/api/admin/replay", IpPass.IpRestrictionMiddleware(newReplayHandler(reloader, config.ProjectsFile)))
+
+	// 归档查询接口（带 IP 限制）
+	http.Handle("/api/archive", IpPass.IpRestrictionMiddleware(http.HandlerFunc(Archive.QueryHandler)))
+
+	// 只读仪表盘和 JSON 接口（带 IP 限制）
+	http.Handle(Dashboard.Prefix, IpPass.IpRestrictionMiddleware(Dashboard.Handler(Dashboard.Source{
+		Gatherer:     Metrics.Gatherer,
+		ProjectNames: Handers.ProjectNames,
+		LastSeen:     Handers.AgentLastSeen,
+	})))
+
 	// 创建自定义 HTTP 服务器（配置超时）
 	server := &http.Server{
-		Addr:         ":8080",
+		Addr:         config.Server.Listen,
 		Handler:      nil,
-		ReadTimeout:  30 * time.Second,  // 读取请求超时
-		WriteTimeout: 30 * time.Second,  // 写入响应超时
-		IdleTimeout:  120 * time.Second, // 空闲连接超时
+		ReadTimeout:  config.Server.ReadTimeout,  // 读取请求超时
+		WriteTimeout: config.Server.WriteTimeout, // 写入响应超时
+		IdleTimeout:  config.Server.IdleTimeout,  // 空闲连接超时
 	}
 
 	// 启动 HTTP 服务
-	log.Println("服务启动，监听端口 8080...")
+	log.Printf("服务启动，监听 %s...", config.Server.Listen)
 	if err := server.ListenAndServe(); err != nil {
 		log.Fatalf("HTTP 服务启动失败: %v", err)
 	}
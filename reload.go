@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"monitor-server/Daily"
+	"monitor-server/Handers"
+	"monitor-server/IpPass"
+	"monitor-server/Metrics"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// configReloader 重新加载配置（文件变化、SIGHUP、管理接口）
+// 新配置完整解析和校验通过后才应用，失败时保留原配置
+type configReloader struct {
+	mu        sync.Mutex // 同一时间只进行一次重新加载
+	file      string
+	overrides []string
+	current   *Config
+}
+
+func newConfigReloader(file string, overrides []string, current *Config) *configReloader {
+	r := &configReloader{file: file, overrides: overrides, current: current}
+	Metrics.ConfigLastReloadSuccess.Set(1)
+	Metrics.ConfigLastReloadSuccessTimestamp.SetToCurrentTime()
+	return r
+}
+
+// reload 重新加载配置，trigger 为触发方式（file、signal、api）
+func (r *configReloader) reload(trigger string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config, err := loadConfig(r.file, r.overrides)
+	if err == nil {
+		err = applyConfig(config)
+	}
+	if err != nil {
+		log.Printf("重新加载配置失败（%s），保留原配置: %v", trigger, err)
+		Metrics.ConfigLastReloadSuccess.Set(0)
+		Metrics.ConfigReloads.WithLabelValues(trigger, "failure").Inc()
+		return err
+	}
+
+	if restartRequired(r.current, config) {
+		log.Printf("server、ingest、projectsFile、checkInterval、dnsRefreshInterval 及可选模块的修改需要重启后生效")
+	}
+	r.current = config
+
+	Metrics.ConfigLastReloadSuccess.Set(1)
+	Metrics.ConfigLastReloadSuccessTimestamp.SetToCurrentTime()
+	Metrics.ConfigReloads.WithLabelValues(trigger, "success").Inc()
+	log.Printf("配置已重新加载（%s）: %s", trigger, r.file)
+	return nil
+}
+
+// runtimeConfig 校验通过、等待应用的运行时配置
+type runtimeConfig struct {
+	handers   *Handers.PreparedRuntime
+	timezones *Daily.Timezones
+	config    *Config
+}
+
+// prepareRuntime 校验并构建全部运行时配置，不修改当前配置
+// 有任何一项不合法时返回全部错误，这时什么都不应用
+func prepareRuntime(config *Config) (*runtimeConfig, error) {
+	var problems []string
+	handers, err := Handers.PrepareRuntime(Handers.Runtime{
+		EncryptionKey:         config.Encrypted,
+		Otlp:                  config.Otlp,
+		Timestamp:             config.AgentTimestamp,
+		MetricTTL:             config.MetricTTL,
+		MaxBodySizeMB:         config.Ingest.MaxBodySizeMB,
+		RejectWhenQueueFull:   config.Ingest.RejectWhenFull,
+		HardLegacyLabels:      config.HardLegacyLabels,
+		LegacyCounterGauges:   config.LegacyCounterGauges,
+		RolloutStuckAfter:     config.RolloutStuckAfter,
+		TopN:                  config.TopN,
+		RejectUnknownProjects: config.RejectUnknownProjects,
+		NamespaceRules:        config.NamespaceRules,
+	})
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	timezones, err := Daily.LoadTimezones(config.Daily.Timezone, config.Daily.ProjectTimezones)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("应用配置失败: %s", strings.Join(problems, "; "))
+	}
+	return &runtimeConfig{handers: handers, timezones: timezones, config: config}, nil
+}
+
+// apply 应用已校验的运行时配置，不会失败
+// 上报处理的配置（密钥、令牌、过期时间、规则等）作为一个快照整体替换
+func (rc *runtimeConfig) apply() {
+	rc.handers.Apply()
+
+	// 设置 IP 白名单，已删除域名的解析结果同步清除，新域名在后台解析
+	IpPass.SetAllowedDomains(rc.config.IpPass)
+	go IpPass.RefreshDomainIPCache()
+
+	// 设置是否输出旧版 project 标签
+	Metrics.SetLegacyProjectLabel(rc.config.LegacyProjectLabel)
+
+	// 设置控制器指标是否输出旧版 container 标签
+	Metrics.SetLegacyControllerLabel(rc.config.LegacyControllerLabel)
+
+	// 设置日界时区
+	rc.timezones.Apply()
+}
+
+// applyConfig 应用可以在运行时修改的配置（启动和重新加载时调用）
+// 先校验全部设置，任何一项不合法时返回错误并保留原配置
+func applyConfig(config *Config) error {
+	rc, err := prepareRuntime(config)
+	if err != nil {
+		return err
+	}
+	rc.apply()
+	return nil
+}
+
+// restartRequired 判断是否修改了只在启动时生效的配置
+func restartRequired(old, new *Config) bool {
+	return !reflect.DeepEqual(old.Server, new.Server) ||
+		old.Ingest.Workers != new.Ingest.Workers ||
+		old.Ingest.QueueSize != new.Ingest.QueueSize ||
+		old.ProjectsFile != new.ProjectsFile ||
+		old.CheckInterval != new.CheckInterval ||
+		old.DnsRefreshInterval != new.DnsRefreshInterval ||
+		!reflect.DeepEqual(old.RemoteWrite, new.RemoteWrite) ||
+		!reflect.DeepEqual(old.Influx, new.Influx) ||
+		!reflect.DeepEqual(old.Archive, new.Archive) ||
+		old.Daily.Dir != new.Daily.Dir ||
+		!reflect.DeepEqual(old.Report, new.Report) ||
+		!reflect.DeepEqual(old.History, new.History)
+}
+
+// watch 监听配置文件变化并重新加载
+func (r *configReloader) watch() error {
+	return Handers.WatchFile(r.file, func() {
+		_ = r.reload("file")
+	})
+}
+
+// handleSignals 收到 SIGHUP 时重新加载配置
+func (r *configReloader) handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			_ = r.reload("signal")
+		}
+	}()
+}
+
+// adminToken 当前配置的管理接口令牌
+func (r *configReloader) adminToken() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current.Admin.Token
+}
+
+// adminAuthorized 校验请求携带的管理令牌（Authorization: Bearer 或 X-Admin-Token）
+// 与 AES 密钥、OTLP 令牌相互独立，未设置 admin.token 时拒绝全部请求
+func adminAuthorized(req *http.Request, token string) bool {
+	provided := req.Header.Get("X-Admin-Token")
+	if provided == "" {
+		provided = strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	}
+	return token != "" && provided != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// checkAdmin 校验管理令牌，失败时写入错误响应并返回 false
+func (r *configReloader) checkAdmin(w http.ResponseWriter, req *http.Request) bool {
+	token := r.adminToken()
+	if token == "" {
+		http.Error(w, "未设置 admin.token，管理接口已禁用", http.StatusForbidden)
+		return false
+	}
+	if !adminAuthorized(req, token) {
+		log.Printf("管理接口令牌无效: %s %s", req.RemoteAddr, req.URL.Path)
+		http.Error(w, "管理令牌无效", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// ServeHTTP 管理接口 POST /api/admin/reload，重新加载配置并返回结果
+// 除 IP 限制外还需要携带 admin.token
+func (r *configReloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "仅支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	if !r.checkAdmin(w, req) {
+		return
+	}
+
+	status := http.StatusOK
+	response := map[string]interface{}{"success": true}
+	if err := r.reload("api"); err != nil {
+		status = http.StatusBadRequest
+		response = map[string]interface{}{"success": false, "error": err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("响应失败: %v", err)
+	}
+}
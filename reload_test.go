@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"monitor-server/Daily"
+	"monitor-server/Handers"
+	"monitor-server/Metrics"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestConfigReloaderRejectsInvalidConfig(t *testing.T) {
+	base := `encrypted: "` + testKey + `"
+ipPass: [127.0.0.1]
+metricTTL: 30s
+`
+	path := writeConfig(t, base)
+	current, err := loadConfig(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newConfigReloader(path, nil, current)
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"syntax error", "encrypted: [", "解析配置文件失败"},
+		{"empty ipPass", `encrypted: "` + testKey + `"` + "\n", "ipPass"},
+		{"bad namespace rule", base + "namespaceRules:\n  - match: \"(\"\n", "namespaceRules[0].match"},
+		{"bad timezone", base + "daily:\n  timezone: Mars/Olympus\n", "daily.timezone"},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		failures := testutil.ToFloat64(Metrics.ConfigReloads.WithLabelValues("signal", "failure"))
+		err := r.reload("signal")
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: reload error = %v, want containing %q", tt.name, err, tt.wantErr)
+		}
+		if r.current != current {
+			t.Errorf("%s: current config replaced after failed reload", tt.name)
+		}
+		if got := testutil.ToFloat64(Metrics.ConfigLastReloadSuccess); got != 0 {
+			t.Errorf("%s: config_last_reload_success = %v, want 0", tt.name, got)
+		}
+		if got := testutil.ToFloat64(Metrics.ConfigReloads.WithLabelValues("signal", "failure")); got != failures+1 {
+			t.Errorf("%s: failure count = %v, want %v", tt.name, got, failures+1)
+		}
+	}
+
+	// 修复后重新加载成功
+	if err := os.WriteFile(path, []byte(strings.Replace(base, "30s", "45s", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reload("signal"); err != nil {
+		t.Fatal(err)
+	}
+	if r.current.MetricTTL != 45*time.Second || testutil.ToFloat64(Metrics.ConfigLastReloadSuccess) != 1 {
+		t.Errorf("after valid reload: metricTTL=%v success=%v", r.current.MetricTTL, testutil.ToFloat64(Metrics.ConfigLastReloadSuccess))
+	}
+}
+
+func TestConfigReloaderHTTP(t *testing.T) {
+	const adminToken = "admin-token-for-tests"
+	base := `encrypted: "` + testKey + `"
+ipPass: [127.0.0.1]
+`
+	path := writeConfig(t, base+"admin:\n  token: "+adminToken+"\n")
+	current, err := loadConfig(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newConfigReloader(path, nil, current)
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		content string // 为空时不修改配置文件
+		want    int
+		wantErr string
+	}{
+		{name: "get", method: http.MethodGet, want: http.StatusMethodNotAllowed},
+		{name: "no token", method: http.MethodPost, want: http.StatusUnauthorized},
+		{name: "wrong token", method: http.MethodPost, headers: map[string]string{"X-Admin-Token": "guess"}, want: http.StatusUnauthorized},
+		{name: "aes key is not an admin token", method: http.MethodPost, headers: map[string]string{"Authorization": "Bearer " + testKey}, want: http.StatusUnauthorized},
+		{name: "bearer token", method: http.MethodPost, headers: map[string]string{"Authorization": "Bearer " + adminToken}, want: http.StatusOK},
+		{name: "invalid config", method: http.MethodPost, headers: map[string]string{"X-Admin-Token": adminToken},
+			content: base + "admin:\n  token: " + adminToken + "\ningest:\n  workers: 0\n", want: http.StatusBadRequest, wantErr: "ingest.workers"},
+		{name: "token removed by reload", method: http.MethodPost, headers: map[string]string{"X-Admin-Token": adminToken},
+			content: base, want: http.StatusOK},
+		{name: "disabled without token", method: http.MethodPost, headers: map[string]string{"X-Admin-Token": adminToken}, want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if tt.content != "" {
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		req := httptest.NewRequest(tt.method, "/api/admin/reload", nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body.String())
+			continue
+		}
+		if tt.wantErr != "" {
+			var response struct {
+				Success bool   `json:"success"`
+				Error   string `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Success || !strings.Contains(response.Error, tt.wantErr) {
+				t.Errorf("%s: response = %+v, want error containing %q", tt.name, response, tt.wantErr)
+			}
+		}
+	}
+}
+
+func TestApplyConfigAllOrNothing(t *testing.T) {
+	config := defaultConfig()
+	config.Encrypted = testKey
+	config.IpPass = []string{"127.0.0.1"}
+	if err := applyConfig(&config); err != nil {
+		t.Fatal(err)
+	}
+	before := Daily.Location("shop")
+
+	// 时区合法但密钥和规则不合法：全部不应用
+	bad := config
+	bad.Encrypted = "short"
+	bad.NamespaceRules = []Handers.NamespaceRule{{Match: "("}}
+	bad.Daily.ProjectTimezones = map[string]string{"shop": "Asia/Tokyo"}
+	err := applyConfig(&bad)
+	if err == nil || !strings.Contains(err.Error(), "AES") || !strings.Contains(err.Error(), "namespace") {
+		t.Fatalf("applyConfig error = %v, want key and namespace problems", err)
+	}
+	if got := Daily.Location("shop"); got != before {
+		t.Errorf("timezone applied from rejected config: %v", got)
+	}
+	if got := string(Handers.GetEncryptionKey()); got != testKey {
+		t.Errorf("encryption key = %q, want unchanged", got)
+	}
+
+	bad.Encrypted = testKey
+	bad.NamespaceRules = nil
+	if err := applyConfig(&bad); err != nil {
+		t.Fatal(err)
+	}
+	if got := Daily.Location("shop").String(); got != "Asia/Tokyo" {
+		t.Errorf("timezone after valid config = %s, want Asia/Tokyo", got)
+	}
+}
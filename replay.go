@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"monitor-server/Archive"
+	"monitor-server/Handers"
+	"monitor-server/Metrics"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/expfmt"
+)
+
+// runReplay 将归档窗口内的原始数据重新交给处理函数，写入本进程全新的 registry
+// 用法: monitor-server replay -from "2026-01-02 10:00" -to "2026-01-02 11:00" [-project jxh] [-source k8s] [-listen :9099]
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	dir := fs.String("dir", "data/archive", "归档目录")
+	projects := fs.String("projects", "config/projects.json", "项目名称字典")
+	fromStr := fs.String("from", "", "开始时间（RFC3339 或 2006-01-02 15:04:05）")
+	toStr := fs.String("to", "", "结束时间，默认当前时间")
+	project := fs.String("project", "", "只回放指定项目")
+	source := fs.String("source", "", "只回放指定 source")
+	listen := fs.String("listen", "", "回放完成后在该地址提供 /metrics，为空时输出到标准输出")
+	if err := fs.Parse(args); err != nil {
+		log.Fatal(err)
+	}
+
+	if *fromStr == "" {
+		log.Fatal("缺少 -from 参数")
+	}
+	from, err := Archive.ParseTime(*fromStr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	to := time.Now()
+	if *toStr != "" {
+		if to, err = Archive.ParseTime(*toStr); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := Handers.LoadProjectDict(*projects); err != nil {
+		log.Fatal(err)
+	}
+
+	count := 0
+	err = Archive.Read(*dir, from, to, *project, *source, func(record Archive.Record) error {
+		var payload map[string]interface{}
+		if err := json.Unmarshal(record.Payload, &payload); err != nil {
+			log.Printf("跳过无法解析的记录: %v", err)
+			return nil
+		}
+		data, ok := payload["data"].([]interface{})
+		if !ok {
+			return nil
+		}
+		// 同步处理，保证按接收顺序写入
+		Handers.HandlePayload(record.Source, record.Project, data, record.ReceivedAt)
+		count++
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("读取归档失败: %v", err)
+	}
+	log.Printf("回放完成，共 %d 条记录", count)
+
+	if *listen != "" {
+		http.Handle("/metrics", promhttp.HandlerFor(Metrics.Gatherer, promhttp.HandlerOpts{}))
+		log.Printf("回放结果监听 %s/metrics", *listen)
+		log.Fatal(http.ListenAndServe(*listen, nil))
+	}
+
+	families, err := Metrics.Gatherer.Gather()
+	if err != nil {
+		log.Printf("采集指标出错: %v", err)
+	}
+	for _, mf := range families {
+		if _, err := expfmt.MetricFamilyToText(os.Stdout, mf); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// 管理接口回放的超时时间
+const replayTimeout = 5 * time.Minute
+
+// replayHandler 管理接口 POST /api/admin/replay，将归档窗口回放到全新的 registry 并返回指标文本
+// 回放在子进程（monitor-server replay）中进行，不影响本进程的指标和状态；需要携带 admin.token
+// 参数：from（必填）、to（默认当前时间）、project、source
+type replayHandler struct {
+	reloader     *configReloader
+	projectsFile string
+	dir          func() string                                            // 归档目录，未启用归档时为空
+	run          func(ctx context.Context, args []string) ([]byte, error) // 执行回放子命令，返回标准输出
+
+	mu sync.Mutex // 同一时间只进行一次回放
+}
+
+func newReplayHandler(reloader *configReloader, projectsFile string) *replayHandler {
+	return &replayHandler{reloader: reloader, projectsFile: projectsFile, dir: Archive.Dir, run: execReplay}
+}
+
+func (h *replayHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "仅支持 POST 请求", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.reloader.checkAdmin(w, req) {
+		return
+	}
+	dir := h.dir()
+	if dir == "" {
+		http.Error(w, "归档未启用", http.StatusNotFound)
+		return
+	}
+
+	query := req.URL.Query()
+	if query.Get("from") == "" {
+		http.Error(w, "缺少 from 参数", http.StatusBadRequest)
+		return
+	}
+	from, err := Archive.ParseTime(query.Get("from"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to := time.Now()
+	if v := query.Get("to"); v != "" {
+		if to, err = Archive.ParseTime(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if !to.After(from) {
+		http.Error(w, "to 需要晚于 from", http.StatusBadRequest)
+		return
+	}
+
+	if !h.mu.TryLock() {
+		http.Error(w, "已有回放在进行中", http.StatusTooManyRequests)
+		return
+	}
+	defer h.mu.Unlock()
+
+	args := []string{"replay", "-dir", dir, "-projects", h.projectsFile,
+		"-from", from.Format(time.RFC3339Nano), "-to", to.Format(time.RFC3339Nano)}
+	if v := query.Get("project"); v != "" {
+		args = append(args, "-project", v)
+	}
+	if v := query.Get("source"); v != "" {
+		args = append(args, "-source", v)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), replayTimeout)
+	defer cancel()
+	log.Printf("管理接口回放归档: %s ~ %s project=%s source=%s", from.Format(time.RFC3339), to.Format(time.RFC3339), query.Get("project"), query.Get("source"))
+	out, err := h.run(ctx, args)
+	if err != nil {
+		log.Printf("回放归档失败: %v", err)
+		http.Error(w, "回放失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := w.Write(out); err != nil {
+		log.Printf("响应失败: %v", err)
+	}
+}
+
+// execReplay 以子进程运行回放子命令，失败时返回子进程输出的最后一行日志
+func execReplay(ctx context.Context, args []string) ([]byte, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, executable, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		return nil, fmt.Errorf("%v: %s", err, lines[len(lines)-1])
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayHandler(t *testing.T) {
+	const adminToken = "admin-token-for-tests"
+	path := writeConfig(t, `encrypted: "`+testKey+`"
+ipPass: [127.0.0.1]
+admin:
+  token: `+adminToken+"\n")
+	current, err := loadConfig(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := "2026-01-02T10:00:00Z"
+	to := "2026-01-02T11:00:00Z"
+	tests := []struct {
+		name     string
+		method   string
+		token    string
+		query    string
+		dir      string
+		busy     bool
+		runErr   error
+		want     int
+		wantArgs []string
+	}{
+		{name: "get", method: http.MethodGet, token: adminToken, dir: "data/archive", want: http.StatusMethodNotAllowed},
+		{name: "no token", method: http.MethodPost, query: "from=" + from, dir: "data/archive", want: http.StatusUnauthorized},
+		{name: "archive disabled", method: http.MethodPost, token: adminToken, query: "from=" + from, want: http.StatusNotFound},
+		{name: "missing from", method: http.MethodPost, token: adminToken, dir: "data/archive", want: http.StatusBadRequest},
+		{name: "to before from", method: http.MethodPost, token: adminToken, query: "from=" + to + "&to=" + from, dir: "data/archive", want: http.StatusBadRequest},
+		{name: "busy", method: http.MethodPost, token: adminToken, query: "from=" + from, dir: "data/archive", busy: true, want: http.StatusTooManyRequests},
+		{name: "replay fails", method: http.MethodPost, token: adminToken, query: "from=" + from + "&to=" + to, dir: "data/archive", runErr: errors.New("exit status 1"), want: http.StatusInternalServerError},
+		{
+			name: "window with filters", method: http.MethodPost, token: adminToken,
+			query: "from=" + from + "&to=" + to + "&project=shop&source=k8s", dir: "/var/archive", want: http.StatusOK,
+			wantArgs: []string{"replay", "-dir", "/var/archive", "-projects", "config/projects.json",
+				"-from", from, "-to", to, "-project", "shop", "-source", "k8s"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotArgs []string
+			h := newReplayHandler(newConfigReloader(path, nil, current), "config/projects.json")
+			h.dir = func() string { return tt.dir }
+			h.run = func(ctx context.Context, args []string) ([]byte, error) {
+				if _, ok := ctx.Deadline(); !ok {
+					t.Error("replay runs without a deadline")
+				}
+				gotArgs = args
+				return []byte("monitor_up 1\n"), tt.runErr
+			}
+			if tt.busy {
+				h.mu.Lock()
+				defer h.mu.Unlock()
+			}
+
+			req := httptest.NewRequest(tt.method, "/api/admin/replay?"+tt.query, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.want, strings.TrimSpace(w.Body.String()))
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			// 时间按本地时区解析后以 RFC3339 传给子进程
+			for i, arg := range gotArgs {
+				if i > 0 && (gotArgs[i-1] == "-from" || gotArgs[i-1] == "-to") {
+					parsed, err := time.Parse(time.RFC3339Nano, arg)
+					if err != nil {
+						t.Fatalf("%s = %q: %v", gotArgs[i-1], arg, err)
+					}
+					gotArgs[i] = parsed.UTC().Format(time.RFC3339)
+				}
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", gotArgs, tt.wantArgs)
+			}
+			if w.Body.String() != "monitor_up 1\n" {
+				t.Errorf("body = %q", w.Body.String())
+			}
+		})
+	}
+}